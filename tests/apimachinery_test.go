@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	deleteCollectionCount    = 5
+	deleteCollectionSelector = "test-batch=true"
+)
+
+func TestDeleteCollection(t *testing.T) {
+	configMapsKey := any("configmaps-key")
+	controlKey := any("control-key")
+
+	trackTest(t)
+
+	deleteCollectionFeature := features.New("apimachinery/delete-collection").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var configMaps []*corev1.ConfigMap
+			for i := range deleteCollectionCount {
+				configMap := newConfigMap(cfg.Namespace(), fmt.Sprintf("delete-collection-%d", i), "index", fmt.Sprint(i))
+				configMap.Labels["test-batch"] = "true"
+				if err := cfg.Client().Resources().Create(ctx, configMap); err != nil {
+					t.Fatal(err)
+				}
+				configMaps = append(configMaps, configMap)
+			}
+			ctx = context.WithValue(ctx, configMapsKey, configMaps)
+
+			// A ConfigMap with another label value must survive the deletion
+			control := newConfigMap(cfg.Namespace(), "delete-collection-control", "index", "control")
+			control.Labels["test-batch"] = "false"
+			if err := cfg.Client().Resources().Create(ctx, control); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, controlKey, control)
+
+			return ctx
+		}).
+		Assess("deleteCollection removes all matching ConfigMaps", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = clientset.CoreV1().RESTClient().Delete().
+				Resource("configmaps").
+				Namespace(cfg.Namespace()).
+				Param("labelSelector", deleteCollectionSelector).
+				Do(ctx).
+				Error()
+			if err != nil {
+				t.Fatalf("deleteCollection with selector %s failed: %v", deleteCollectionSelector, err)
+			}
+
+			var remaining int
+			err = wait.PollUntilContextTimeout(ctx, time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				var configMaps corev1.ConfigMapList
+				if err := cfg.Client().Resources(cfg.Namespace()).List(ctx, &configMaps, resources.WithLabelSelector(deleteCollectionSelector)); err != nil {
+					return false, err
+				}
+
+				remaining = len(configMaps.Items)
+				return remaining == 0, nil
+			})
+			if err != nil {
+				t.Fatalf("%d ConfigMaps matching %s remain after deleteCollection: %v", remaining, deleteCollectionSelector, err)
+			}
+			t.Logf("✓ All %d ConfigMaps matching %s were deleted", deleteCollectionCount, deleteCollectionSelector)
+
+			return ctx
+		}).
+		Assess("ConfigMaps with other labels are unaffected", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			control := ctx.Value(controlKey).(*corev1.ConfigMap)
+
+			var currentConfigMap corev1.ConfigMap
+			if err := cfg.Client().Resources().Get(ctx, control.Name, control.Namespace, &currentConfigMap); err != nil {
+				t.Fatalf("ConfigMap %s not matching the selector was affected: %v", control.Name, err)
+			}
+			if currentConfigMap.DeletionTimestamp != nil {
+				t.Fatalf("ConfigMap %s not matching the selector is being deleted", control.Name)
+			}
+			t.Logf("✓ ConfigMap %s labeled test-batch=false still exists", control.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete ConfigMaps, the matching ones are already gone when the test passed
+			configMaps, _ := ctx.Value(configMapsKey).([]*corev1.ConfigMap)
+			if control, ok := ctx.Value(controlKey).(*corev1.ConfigMap); ok && control != nil {
+				configMaps = append(configMaps, control)
+			}
+			for _, configMap := range configMaps {
+				if err := cfg.Client().Resources().Delete(ctx, configMap); err != nil && !apierrors.IsNotFound(err) {
+					t.Logf("Failed to delete ConfigMap %s: %v", configMap.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, deleteCollectionFeature)
+}
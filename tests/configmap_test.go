@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	configMountPath   = "/etc/config"
+	configTestKey     = "message"
+	configTestValue   = "configmap volume test data"
+	secretTestValue   = "secret volume test data"
+	configMapTestName = "test-configmap"
+	secretTestName    = "test-secret"
+)
+
+func TestConfigMapMountedAsVolume(t *testing.T) {
+	configMapKey := any("configmap-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	configMapFeature := features.New("configmap/volume").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create ConfigMap
+			cm := newConfigMap(cfg.Namespace(), configMapTestName, configTestKey, configTestValue)
+			if err := cfg.Client().Resources().Create(ctx, cm); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, configMapKey, cm)
+
+			// Create Pod reading the mounted ConfigMap
+			pod := newConfigMapPod(cfg.Namespace(), "test-configmap-pod", cm.Name, configTestKey, configTestValue)
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			// Run Pod to completion, the assessment reports a failed Pod
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatalf("Pod did not complete: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("configmap content is mounted", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+			assertPodSucceeded(ctx, t, cfg, pod)
+
+			t.Logf("Pod %s read the expected ConfigMap content from %s", pod.Name, configMountPath)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod
+			if pod := ctx.Value(podKey).(*corev1.Pod); pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			// Delete ConfigMap
+			if cm := ctx.Value(configMapKey).(*corev1.ConfigMap); cm != nil {
+				if err := cfg.Client().Resources().Delete(ctx, cm); err != nil {
+					t.Logf("Failed to delete ConfigMap: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, configMapFeature)
+}
+
+func TestSecretMountedAsVolume(t *testing.T) {
+	secretKey := any("secret-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	secretFeature := features.New("secret/volume").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create Secret (the API server stores Data base64-encoded)
+			secret := newSecret(cfg.Namespace(), secretTestName, configTestKey, secretTestValue)
+			if err := cfg.Client().Resources().Create(ctx, secret); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, secretKey, secret)
+
+			// Create Pod reading the mounted Secret, which the kubelet decodes
+			pod := newSecretPod(cfg.Namespace(), "test-secret-pod", secret.Name, configTestKey, secretTestValue)
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			// Run Pod to completion, the assessment reports a failed Pod
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatalf("Pod did not complete: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("secret content is decoded and mounted", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+			assertPodSucceeded(ctx, t, cfg, pod)
+
+			t.Logf("Pod %s read the expected decoded Secret content from %s", pod.Name, configMountPath)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod
+			if pod := ctx.Value(podKey).(*corev1.Pod); pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			// Delete Secret
+			if secret := ctx.Value(secretKey).(*corev1.Secret); secret != nil {
+				if err := cfg.Client().Resources().Delete(ctx, secret); err != nil {
+					t.Logf("Failed to delete Secret: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, secretFeature)
+}
+
+func TestConfigMapSecretProjection(t *testing.T) {
+	configMapKey := any("configmap-key")
+	secretKey := any("secret-key")
+	podKey := any("pod-key")
+	const updatedValue = "updated configmap projection data"
+
+	trackTest(t)
+
+	projectionFeature := features.New("configmap/projection").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			cm := newConfigMap(cfg.Namespace(), "projection-configmap", configTestKey, configTestValue)
+			if err := cfg.Client().Resources().Create(ctx, cm); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, configMapKey, cm)
+
+			secret := newSecret(cfg.Namespace(), "projection-secret", configTestKey, secretTestValue)
+			if err := cfg.Client().Resources().Create(ctx, secret); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, secretKey, secret)
+
+			pod := newProjectionPod(cfg.Namespace(), "projection-pod", cm.Name, secret.Name, configTestKey)
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("configmap file and secret env var hold the expected values", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			for _, line := range []string{"secret=" + secretTestValue, "config=" + configTestValue} {
+				if err := waitForPodLogLine(ctx, cfg, pod, line, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("✓ Pod %s logged %q", pod.Name, line)
+			}
+
+			return ctx
+		}).
+		Assess("configmap update propagates to the mounted file", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			cm := ctx.Value(configMapKey).(*corev1.ConfigMap)
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			patch := k8s.Patch{PatchType: types.MergePatchType, Data: []byte(`{"data":{"` + configTestKey + `":"` + updatedValue + `"}}`)}
+			if err := cfg.Client().Resources().Patch(ctx, cm, patch); err != nil {
+				t.Fatalf("Failed to patch ConfigMap %s: %v", cm.Name, err)
+			}
+
+			// The kubelet refreshes mounted ConfigMaps on its sync period plus the cache TTL, while env
+			// vars are only resolved when the container starts and are not checked here
+			updateStart := time.Now()
+			if err := waitForPodLogLine(ctx, cfg, pod, "config="+updatedValue, 3*time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("✓ Mounted file of pod %s updated after %s", pod.Name, time.Since(updateStart))
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+			if cm, ok := ctx.Value(configMapKey).(*corev1.ConfigMap); ok && cm != nil {
+				if err := cfg.Client().Resources().Delete(ctx, cm); err != nil {
+					t.Logf("Failed to delete ConfigMap: %v", err)
+				}
+			}
+			if secret, ok := ctx.Value(secretKey).(*corev1.Secret); ok && secret != nil {
+				if err := cfg.Client().Resources().Delete(ctx, secret); err != nil {
+					t.Logf("Failed to delete Secret: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, projectionFeature)
+}
+
+func TestImmutableConfig(t *testing.T) {
+	const (
+		configMapName = "immutable-test-configmap"
+		secretName    = "immutable-test-secret"
+	)
+
+	trackTest(t)
+
+	immutableFeature := features.New("config/immutable").
+		Assess("immutable ConfigMap rejects updates", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			cm := newConfigMap(cfg.Namespace(), configMapName, configTestKey, configTestValue)
+			cm.Immutable = &[]bool{true}[0]
+			assertImmutable(ctx, t, cfg, cm, func() {
+				cm.Data[configTestKey] = "updated"
+			}, func() *corev1.ConfigMap {
+				recreated := newConfigMap(cfg.Namespace(), cm.Name, configTestKey, "recreated")
+				recreated.Immutable = &[]bool{true}[0]
+				return recreated
+			})
+
+			return ctx
+		}).
+		Assess("immutable Secret rejects updates", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			secret := newSecret(cfg.Namespace(), secretName, configTestKey, secretTestValue)
+			secret.Immutable = &[]bool{true}[0]
+			assertImmutable(ctx, t, cfg, secret, func() {
+				secret.Data[configTestKey] = []byte("updated")
+			}, func() *corev1.Secret {
+				recreated := newSecret(cfg.Namespace(), secret.Name, configTestKey, "recreated")
+				recreated.Immutable = &[]bool{true}[0]
+				return recreated
+			})
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// The assessments delete what they create unless they fail midway. A failed step does not
+			// return its context, so the objects are deleted by name.
+			cm := newConfigMap(cfg.Namespace(), configMapName, configTestKey, configTestValue)
+			if err := cfg.Client().Resources().Delete(ctx, cm); err != nil && !apierrors.IsNotFound(err) {
+				t.Logf("Failed to delete ConfigMap: %v", err)
+			}
+			secret := newSecret(cfg.Namespace(), secretName, configTestKey, secretTestValue)
+			if err := cfg.Client().Resources().Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+				t.Logf("Failed to delete Secret: %v", err)
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, immutableFeature)
+}
+
+// assertImmutable creates an immutable object, checks that updating it with mutate is rejected,
+// then checks that it can still be deleted and recreated as returned by recreate
+func assertImmutable[T k8s.Object](ctx context.Context, t *testing.T, cfg *envconf.Config, obj T, mutate func(), recreate func() T) {
+	t.Helper()
+
+	if err := cfg.Client().Resources().Create(ctx, obj); err != nil {
+		t.Fatal(err)
+	}
+
+	mutate()
+	err := cfg.Client().Resources().Update(ctx, obj)
+	if err == nil {
+		t.Fatalf("Update of immutable %s should be rejected, but it succeeded", obj.GetName())
+	}
+	if !apierrors.IsInvalid(err) {
+		t.Fatalf("Expected 422 Invalid error when updating immutable %s, got: %v", obj.GetName(), err)
+	}
+	t.Logf("✓ Update of immutable %s rejected: %v", obj.GetName(), err)
+
+	if err := cfg.Client().Resources().Delete(ctx, obj); err != nil {
+		t.Fatalf("Failed to delete immutable %s: %v", obj.GetName(), err)
+	}
+	if err := waitForDeleted(ctx, cfg.Client().Resources(), obj); err != nil {
+		t.Fatalf("Immutable %s was not deleted: %v", obj.GetName(), err)
+	}
+
+	recreated := recreate()
+	if err := cfg.Client().Resources().Create(ctx, recreated); err != nil {
+		t.Fatalf("Failed to recreate immutable %s: %v", obj.GetName(), err)
+	}
+	t.Logf("✓ Immutable %s deleted and recreated", obj.GetName())
+
+	if err := cfg.Client().Resources().Delete(ctx, recreated); err != nil {
+		t.Logf("Failed to delete %s: %v", recreated.GetName(), err)
+	}
+}
+
+// newConfigMap creates a ConfigMap holding a single key
+func newConfigMap(namespace, name, key, value string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "test-config"},
+		},
+		Data: map[string]string{key: value},
+	}
+}
+
+// newSecret creates an Opaque Secret holding a single key
+func newSecret(namespace, name, key, value string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "test-config"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{key: []byte(value)},
+	}
+}
+
+// newConfigMapPod creates a Pod that checks a mounted ConfigMap key holds the expected value
+func newConfigMapPod(namespace, name, cmName, key, expectedValue string) *corev1.Pod {
+	return newConfigVolumePod(namespace, name, key, expectedValue, corev1.VolumeSource{
+		ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: cmName},
+		},
+	})
+}
+
+// newSecretPod creates a Pod that checks a mounted Secret key holds the expected value
+func newSecretPod(namespace, name, secretName, key, expectedValue string) *corev1.Pod {
+	return newConfigVolumePod(namespace, name, key, expectedValue, corev1.VolumeSource{
+		Secret: &corev1.SecretVolumeSource{
+			SecretName: secretName,
+		},
+	})
+}
+
+// newConfigVolumePod creates a Pod that mounts a volume at /etc/config and compares a file with the expected value
+func newConfigVolumePod(namespace, name, key, expectedValue string, source corev1.VolumeSource) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "test-config"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "config-test",
+					Image: "alpine:latest",
+					Command: []string{
+						"sh", "-c",
+						"cat " + configMountPath + "/" + key + " && " +
+							"test \"$(cat " + configMountPath + "/" + key + ")\" = '" + expectedValue + "' && " +
+							"echo 'Config volume test completed successfully'",
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "config",
+							MountPath: configMountPath,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name:         "config",
+					VolumeSource: source,
+				},
+			},
+		},
+	}
+}
+
+// newProjectionPod creates a Pod that logs a Secret key injected as an env var, then logs a mounted
+// ConfigMap key every 5 seconds
+func newProjectionPod(namespace, name, cmName, secretName, key string) *corev1.Pod {
+	pod := newConfigMapPod(namespace, name, cmName, key, "")
+	pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	container := &pod.Spec.Containers[0]
+	container.Command = []string{
+		"sh", "-c",
+		"echo \"secret=$SECRET_ENV\"; while true; do echo \"config=$(cat " + configMountPath + "/" + key + ")\"; sleep 5; done",
+	}
+	container.Env = []corev1.EnvVar{
+		{
+			Name: "SECRET_ENV",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+					Key:                  key,
+				},
+			},
+		},
+	}
+
+	return pod
+}
+
+// waitForPodLogLine waits until the logs of a pod contain the given line
+func waitForPodLogLine(ctx context.Context, cfg *envconf.Config, pod *corev1.Pod, line string, timeout time.Duration) error {
+	var logs string
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var err error
+		if logs, err = getPodLogs(ctx, cfg, pod, ""); err != nil {
+			return false, err
+		}
+
+		return slices.Contains(strings.Split(logs, "\n"), line), nil
+	})
+	if err != nil {
+		return fmt.Errorf("pod %s did not log %q: %w\n%s", pod.Name, line, err, logs)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	crdTestGroup  = "e2etests.example.com"
+	crdTestKind   = "Widget"
+	crdTestPlural = "widgets"
+)
+
+func TestCRDValidation(t *testing.T) {
+	crdKey := any("crd-key")
+
+	trackTest(t)
+
+	crdFeature := features.New("api/crd-validation").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			crd := newCRDWithValidation()
+			if err := cfg.Client().Resources().Create(ctx, crd); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, crdKey, crd)
+
+			if err := waitForCRDEstablished(ctx, cfg.Client().Resources(), crd.GetName()); err != nil {
+				t.Fatalf("CRD not established: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("out of range field is rejected", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			widget := newWidget(cfg.Namespace(), "crd-test-too-few", map[string]any{"replicas": int64(0), "image": "nginx"})
+			err := createCustomResource(ctx, cfg.Client().Resources(), widget)
+			if err == nil {
+				t.Fatal("Widget with spec.replicas=0 should be rejected, but it was accepted")
+			}
+			if !apierrors.IsInvalid(err) {
+				t.Fatalf("Expected 422 Invalid error, got: %v", err)
+			}
+			// The API server words minimum violations as "should be greater than or equal to"
+			if !strings.Contains(err.Error(), "spec.replicas") || !strings.Contains(err.Error(), "greater than or equal to 1") {
+				t.Fatalf("Validation error does not report the spec.replicas minimum: %v", err)
+			}
+			t.Logf("✓ spec.replicas=0 rejected: %v", err)
+
+			return ctx
+		}).
+		Assess("missing required field is rejected", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			widget := newWidget(cfg.Namespace(), "crd-test-no-image", map[string]any{"replicas": int64(5)})
+			err := createCustomResource(ctx, cfg.Client().Resources(), widget)
+			if err == nil {
+				t.Fatal("Widget without spec.image should be rejected, but it was accepted")
+			}
+			if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), "spec.image") {
+				t.Fatalf("Expected 422 Invalid error for spec.image, got: %v", err)
+			}
+			t.Logf("✓ missing spec.image rejected: %v", err)
+
+			return ctx
+		}).
+		Assess("valid resource is accepted", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			widget := newWidget(cfg.Namespace(), "crd-test-valid", map[string]any{"replicas": int64(5), "image": "nginx"})
+			if err := createCustomResource(ctx, cfg.Client().Resources(), widget); err != nil {
+				t.Fatalf("Valid widget was rejected: %v", err)
+			}
+			t.Logf("✓ Widget %s with spec.replicas=5 accepted", widget.GetName())
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Deleting the CRD also deletes its custom resources
+			if crd := ctx.Value(crdKey).(*unstructured.Unstructured); crd != nil {
+				if err := cfg.Client().Resources().Delete(ctx, crd); err != nil {
+					t.Logf("Failed to delete CRD: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, crdFeature)
+}
+
+// newCRDWithValidation creates a namespaced Widget CRD whose schema bounds spec.replicas to [1, 10]
+// and requires spec.image
+func newCRDWithValidation() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]any{
+			"name":   crdTestPlural + "." + crdTestGroup,
+			"labels": map[string]any{"app": "crd-test"},
+		},
+		"spec": map[string]any{
+			"group": crdTestGroup,
+			"scope": "Namespaced",
+			"names": map[string]any{
+				"kind":     crdTestKind,
+				"listKind": crdTestKind + "List",
+				"plural":   crdTestPlural,
+				"singular": strings.ToLower(crdTestKind),
+			},
+			"versions": []any{
+				map[string]any{
+					"name":    "v1",
+					"served":  true,
+					"storage": true,
+					"schema": map[string]any{
+						"openAPIV3Schema": map[string]any{
+							"type":     "object",
+							"required": []any{"spec"},
+							"properties": map[string]any{
+								"spec": map[string]any{
+									"type":     "object",
+									"required": []any{"replicas", "image"},
+									"properties": map[string]any{
+										"replicas": map[string]any{
+											"type":    "integer",
+											"minimum": int64(1),
+											"maximum": int64(10),
+										},
+										"image": map[string]any{
+											"type": "string",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// newWidget creates a Widget custom resource with the given spec
+func newWidget(namespace, name string, spec map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": crdTestGroup + "/v1",
+		"kind":       crdTestKind,
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]any{"app": "crd-test"},
+		},
+		"spec": spec,
+	}}
+}
+
+// waitForCRDEstablished waits for a CRD to report the Established condition
+func waitForCRDEstablished(ctx context.Context, client *resources.Resources, name string) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		crd := &unstructured.Unstructured{}
+		crd.SetAPIVersion("apiextensions.k8s.io/v1")
+		crd.SetKind("CustomResourceDefinition")
+		if err := client.Get(ctx, name, "", crd); err != nil {
+			return false, err
+		}
+
+		conditions, _, err := unstructured.NestedSlice(crd.Object, "status", "conditions")
+		if err != nil {
+			return false, err
+		}
+		for _, c := range conditions {
+			condition, ok := c.(map[string]any)
+			if ok && condition["type"] == "Established" && condition["status"] == "True" {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	})
+}
+
+// createCustomResource creates a custom resource, retrying while the client's REST mapper
+// has not yet discovered the freshly established kind
+func createCustomResource(ctx context.Context, client *resources.Resources, obj *unstructured.Unstructured) error {
+	var createErr error
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		createErr = client.Create(ctx, obj)
+		return !meta.IsNoMatchError(createErr), nil
+	})
+	if err != nil && createErr == nil {
+		return err
+	}
+
+	return createErr
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestDaemonSet(t *testing.T) {
+	daemonSetKey := any("daemonset-key")
+	var schedulableNodes int
+
+	// The number of schedulable nodes shows cluster size trends alongside the test duration
+	trackTestWithAttributes(t, func() []attribute.KeyValue {
+		return []attribute.KeyValue{attribute.Int("node_count", schedulableNodes)}
+	})
+
+	daemonSetFeature := features.New("appsv1/daemonset").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			daemonSet := newDaemonSet(cfg.Namespace(), "daemonset-test")
+			if err := cfg.Client().Resources().Create(ctx, daemonSet); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, daemonSetKey, daemonSet)
+
+			return ctx
+		}).
+		Assess("daemonset becomes ready", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			daemonSet := ctx.Value(daemonSetKey).(*appsv1.DaemonSet)
+
+			if err := waitForDaemonSetReady(ctx, cfg.Client().Resources(), daemonSet); err != nil {
+				t.Fatalf("DaemonSet %s not ready: %v", daemonSet.Name, err)
+			}
+			t.Logf("✓ DaemonSet %s is ready", daemonSet.Name)
+
+			return ctx
+		}).
+		Assess("one pod runs on every schedulable node", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			daemonSet := ctx.Value(daemonSetKey).(*appsv1.DaemonSet)
+
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+
+			var pods corev1.PodList
+			selector := metav1.FormatLabelSelector(daemonSet.Spec.Selector)
+			if err := cfg.Client().Resources(daemonSet.Namespace).List(ctx, &pods, resources.WithLabelSelector(selector)); err != nil {
+				t.Fatal(err)
+			}
+			podsPerNode := map[string]int{}
+			for _, pod := range pods.Items {
+				podsPerNode[pod.Spec.NodeName]++
+			}
+
+			schedulableNodes = 0
+			for i := range nodes.Items {
+				node := &nodes.Items[i]
+				switch {
+				case nodeSchedulable(withoutDaemonSetTolerations(node, &daemonSet.Spec.Template.Spec)):
+					schedulableNodes++
+					if podsPerNode[node.Name] != 1 {
+						t.Errorf("Expected 1 DaemonSet pod on node %s, found %d", node.Name, podsPerNode[node.Name])
+					}
+				case node.Spec.Unschedulable:
+					// The DaemonSet controller tolerates cordoned nodes, whether a pod runs there is not checked
+				case podsPerNode[node.Name] > 0:
+					t.Errorf("DaemonSet pod without tolerations landed on tainted node %s", node.Name)
+				}
+			}
+			if schedulableNodes == 0 {
+				t.Fatal("No schedulable node found")
+			}
+			t.Logf("✓ DaemonSet %s runs one pod on each of the %d schedulable nodes, none on tainted nodes",
+				daemonSet.Name, schedulableNodes)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if daemonSet, ok := ctx.Value(daemonSetKey).(*appsv1.DaemonSet); ok && daemonSet != nil {
+				if err := cfg.Client().Resources().Delete(ctx, daemonSet); err != nil {
+					t.Logf("Failed to delete DaemonSet: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, daemonSetFeature)
+}
+
+// daemonSetTolerations are the tolerations the DaemonSet controller adds to every DaemonSet pod
+var daemonSetTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+}
+
+// withoutDaemonSetTolerations returns a copy of a node without the taints the DaemonSet controller
+// tolerates for pods of the given spec, network-unavailable being only tolerated for host network pods
+func withoutDaemonSetTolerations(node *corev1.Node, podSpec *corev1.PodSpec) *corev1.Node {
+	tolerations := daemonSetTolerations
+	if podSpec.HostNetwork {
+		tolerations = append(slices.Clone(tolerations), corev1.Toleration{
+			Key: corev1.TaintNodeNetworkUnavailable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule,
+		})
+	}
+
+	node = node.DeepCopy()
+	node.Spec.Taints = slices.DeleteFunc(node.Spec.Taints, func(taint corev1.Taint) bool {
+		return slices.ContainsFunc(tolerations, func(toleration corev1.Toleration) bool {
+			return toleration.ToleratesTaint(&taint)
+		})
+	})
+
+	return node
+}
+
+// newDaemonSet creates a DaemonSet running a sleeping container, without tolerations so that it
+// skips tainted nodes such as control-plane ones
+func newDaemonSet(namespace, name string) *appsv1.DaemonSet {
+	labels := map[string]string{"app": "daemonset-test"}
+	podSpec := newSchedulingPod(namespace, name).Spec
+	podSpec.RestartPolicy = corev1.RestartPolicyAlways
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+// waitForDaemonSetReady waits until every pod scheduled by a DaemonSet runs its latest template and is ready
+func waitForDaemonSetReady(ctx context.Context, client *resources.Resources, daemonSet *appsv1.DaemonSet) error {
+	return wait.PollUntilContextTimeout(ctx, 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var current appsv1.DaemonSet
+		if err := client.Get(ctx, daemonSet.Name, daemonSet.Namespace, &current); err != nil {
+			return false, err
+		}
+
+		status := current.Status
+		return status.ObservedGeneration >= current.Generation &&
+			status.DesiredNumberScheduled > 0 &&
+			status.UpdatedNumberScheduled == status.DesiredNumberScheduled &&
+			status.NumberReady == status.DesiredNumberScheduled, nil
+	})
+}
@@ -1,24 +1,33 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 	"sigs.k8s.io/e2e-framework/pkg/features"
 )
 
 func TestDeployment(t *testing.T) {
-	start := time.Now()
 	deploymentKey := any("deployment-key")
 
-	t.Cleanup(func() {
-		metricsCollector.RecordTestExecution(testContext, t, time.Since(start))
-	})
+	trackTest(t)
 
 	deploymentFeature := features.New("appsv1/deployment").
 		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
@@ -48,6 +57,375 @@ func TestDeployment(t *testing.T) {
 	testenv.Test(t, deploymentFeature)
 }
 
+func TestBuiltImageDeploy(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	createdAtKey := any("created-at-key")
+	image := os.Getenv("TEST_IMAGE")
+	expectedVersion := os.Getenv("TEST_IMAGE_VERSION")
+	entrypoint := getEnv("TEST_IMAGE_ENTRYPOINT", "/e2e-tests")
+
+	trackTest(t)
+
+	if image == "" {
+		skipTest(t, skipReasonNotConfigured, "TEST_IMAGE not set, skipping built image deployment test")
+	}
+
+	builtImageFeature := features.New("appsv1/built-image").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := newDeployment(cfg.Namespace(), "built-image-test", 1)
+			setDeploymentAppLabel(deployment, "built-image-test")
+			container := &deployment.Spec.Template.Spec.Containers[0]
+			container.Name = "app"
+			container.Image = image
+			if command := os.Getenv("TEST_IMAGE_COMMAND"); command != "" {
+				container.Command = strings.Fields(command)
+			}
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+			ctx = context.WithValue(ctx, createdAtKey, createdAt)
+
+			return ctx
+		}).
+		Assess("built image runs", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := ctx.Value(deploymentKey).(*appsv1.Deployment)
+			createdAt := ctx.Value(createdAtKey).(time.Time)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment of %s not ready: %v", image, err)
+			}
+			t.Logf("✓ Deployment of %s is running", image)
+
+			return ctx
+		}).
+		Assess("built image reports the expected version", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if expectedVersion == "" {
+				skipTest(t, skipReasonNotConfigured, "TEST_IMAGE_VERSION not set, skipping version check")
+			}
+
+			pod := firstPodWithLabels(ctx, t, cfg, map[string]string{"app": "built-image-test"})
+
+			var stdout, stderr bytes.Buffer
+			command := []string{entrypoint, "--version"}
+			if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, "app", command, &stdout, &stderr); err != nil {
+				t.Fatalf("Failed to run %s --version in pod %s: %v: %s", entrypoint, pod.Name, err, stderr.String())
+			}
+
+			output := stdout.String() + stderr.String()
+			if !strings.Contains(output, expectedVersion) {
+				t.Fatalf("Expected %s --version output to contain %q, got %q", entrypoint, expectedVersion, output)
+			}
+			t.Logf("✓ %s reports version %s", image, expectedVersion)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, builtImageFeature)
+}
+
+func TestReplicaSetScale(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	replicaSetKey := any("replicaset-key")
+	const (
+		replicas       int32 = 2
+		scaledReplicas int32 = 3
+	)
+
+	trackTest(t)
+
+	replicaSetScaleFeature := features.New("appsv1/replicaset-scale").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := newDeployment(cfg.Namespace(), "rs-scale-test", replicas)
+			setDeploymentAppLabel(deployment, "rs-scale-test")
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			if err := waitForDeploymentRolledOut(ctx, cfg.Client().Resources(), deployment); err != nil {
+				t.Fatalf("Deployment not rolled out: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("deployment owns a replicaset", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := ctx.Value(deploymentKey).(*appsv1.Deployment)
+
+			replicaSet, err := replicaSetControlledBy(ctx, cfg.Client().Resources(cfg.Namespace()), deployment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("✓ Deployment %s owns ReplicaSet %s", deployment.Name, replicaSet.Name)
+
+			return context.WithValue(ctx, replicaSetKey, replicaSet)
+		}).
+		Assess("scale subresource accepts the new replica count", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			replicaSet := ctx.Value(replicaSetKey).(*appsv1.ReplicaSet)
+
+			scale, err := patchReplicaSetScale(ctx, cfg, replicaSet, scaledReplicas)
+			if err != nil {
+				t.Fatalf("Failed to patch the scale subresource of ReplicaSet %s: %v", replicaSet.Name, err)
+			}
+			if scale.Spec.Replicas != scaledReplicas {
+				t.Fatalf("Expected scale of ReplicaSet %s to have %d replicas, got %d", replicaSet.Name, scaledReplicas, scale.Spec.Replicas)
+			}
+
+			// The deployment controller reverts the change as soon as it notices it, even while the
+			// deployment is paused, so only the response of the scale subresource is checked
+			t.Logf("✓ Scale subresource of ReplicaSet %s accepted %d replicas", replicaSet.Name, scaledReplicas)
+
+			return ctx
+		}).
+		Assess("deployment reconciles the replicaset", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := ctx.Value(deploymentKey).(*appsv1.Deployment)
+			replicaSet := ctx.Value(replicaSetKey).(*appsv1.ReplicaSet)
+
+			reconcileStart := time.Now()
+			err := wait.PollUntilContextTimeout(ctx, time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				var currentReplicaSet appsv1.ReplicaSet
+				if err := cfg.Client().Resources().Get(ctx, replicaSet.Name, replicaSet.Namespace, &currentReplicaSet); err != nil {
+					return false, err
+				}
+				var currentDeployment appsv1.Deployment
+				if err := cfg.Client().Resources().Get(ctx, deployment.Name, deployment.Namespace, &currentDeployment); err != nil {
+					return false, err
+				}
+
+				return *currentReplicaSet.Spec.Replicas == replicas &&
+					currentReplicaSet.Status.Replicas == replicas &&
+					currentDeployment.Status.Replicas == replicas, nil
+			})
+			if err != nil {
+				t.Fatalf("Deployment %s did not scale ReplicaSet %s back to %d replicas within 30s: %v", deployment.Name, replicaSet.Name, replicas, err)
+			}
+			t.Logf("✓ Deployment %s scaled ReplicaSet %s back to %d replicas in %s", deployment.Name, replicaSet.Name, replicas, time.Since(reconcileStart))
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, replicaSetScaleFeature)
+}
+
+func TestReplicaSetAdoption(t *testing.T) {
+	podsKey := any("pods-key")
+	replicaSetKey := any("replicaset-key")
+	labels := map[string]string{"app": "rs-adoption-test"}
+	const replicas int32 = 2
+
+	trackTest(t)
+
+	adoptionFeature := features.New("appsv1/replicaset-adoption").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Orphaned pods matching the selector of the ReplicaSet created afterwards
+			var pods []*corev1.Pod
+			for i := range replicas {
+				pod := newSchedulingPod(cfg.Namespace(), fmt.Sprintf("rs-adoption-orphan-%d", i))
+				pod.Labels = labels
+				pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+				if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+					t.Fatal(err)
+				}
+				pods = append(pods, pod)
+			}
+			ctx = context.WithValue(ctx, podsKey, pods)
+
+			for _, pod := range pods {
+				if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+					t.Fatalf("Orphaned pod %s not running: %v", pod.Name, err)
+				}
+			}
+
+			return ctx
+		}).
+		Assess("replicaset adopts the orphaned pods", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			orphans := ctx.Value(podsKey).([]*corev1.Pod)
+
+			replicaSet := newReplicaSet(cfg.Namespace(), "rs-adoption-test", labels, replicas)
+			if err := cfg.Client().Resources().Create(ctx, replicaSet); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, replicaSetKey, replicaSet)
+
+			var controlled []corev1.Pod
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
+				var err error
+				controlled, err = podsControlledBy(ctx, cfg.Client().Resources(cfg.Namespace()), replicaSet, labels)
+				if err != nil {
+					return false, err
+				}
+				return len(controlled) == int(replicas), nil
+			})
+			if err != nil {
+				t.Fatalf("ReplicaSet %s controls %d pods, expected %d: %v", replicaSet.Name, len(controlled), replicas, err)
+			}
+
+			for _, orphan := range orphans {
+				if !slices.ContainsFunc(controlled, func(pod corev1.Pod) bool { return pod.Name == orphan.Name }) {
+					t.Fatalf("ReplicaSet %s did not adopt orphaned pod %s", replicaSet.Name, orphan.Name)
+				}
+			}
+			t.Logf("✓ ReplicaSet %s adopted the %d orphaned pods", replicaSet.Name, replicas)
+
+			return ctx
+		}).
+		Assess("replicaset creates no extra pods", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			replicaSet := ctx.Value(replicaSetKey).(*appsv1.ReplicaSet)
+
+			// Give the controller time to act on a wrong replica count before checking the pods
+			time.Sleep(10 * time.Second)
+
+			var pods corev1.PodList
+			selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: labels})
+			if err := cfg.Client().Resources(cfg.Namespace()).List(ctx, &pods, resources.WithLabelSelector(selector)); err != nil {
+				t.Fatal(err)
+			}
+			if len(pods.Items) != int(replicas) {
+				t.Fatalf("Expected %d pods matching ReplicaSet %s, found %d", replicas, replicaSet.Name, len(pods.Items))
+			}
+
+			var current appsv1.ReplicaSet
+			if err := cfg.Client().Resources().Get(ctx, replicaSet.Name, replicaSet.Namespace, &current); err != nil {
+				t.Fatal(err)
+			}
+			if current.Status.Replicas != replicas {
+				t.Fatalf("Expected ReplicaSet %s status to report %d replicas, got %d", replicaSet.Name, replicas, current.Status.Replicas)
+			}
+			t.Logf("✓ ReplicaSet %s kept %d pods without creating duplicates", replicaSet.Name, replicas)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Deleting the ReplicaSet garbage collects the pods it adopted, the orphaned pods are
+			// deleted as well in case the adoption failed
+			if replicaSet, ok := ctx.Value(replicaSetKey).(*appsv1.ReplicaSet); ok && replicaSet != nil {
+				if err := cfg.Client().Resources().Delete(ctx, replicaSet); err != nil {
+					t.Logf("Failed to delete ReplicaSet: %v", err)
+				}
+			}
+			pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+			for _, pod := range pods {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil && !apierrors.IsNotFound(err) {
+					t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+				}
+			}
+			for _, pod := range pods {
+				if err := waitForDeleted(ctx, cfg.Client().Resources(), pod); err != nil {
+					t.Logf("Pod %s not deleted: %v", pod.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, adoptionFeature)
+}
+
+// minReadySeconds of TestMinReadySeconds, long enough to stand out from the pod startup time
+const minReadySeconds = 15
+
+func TestMinReadySeconds(t *testing.T) {
+	deploymentKey := any("deployment-key")
+
+	trackTest(t)
+
+	minReadyFeature := features.New("appsv1/deployment-min-ready-seconds").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := newNamedPortDeployment(cfg.Namespace(), "min-ready-test", "min-ready-test", 8080)
+			deployment.Spec.MinReadySeconds = minReadySeconds
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			if err := waitForDeploymentRolledOut(ctx, cfg.Client().Resources(), deployment); err != nil {
+				t.Fatalf("Deployment %s not rolled out: %v", deployment.Name, err)
+			}
+
+			return ctx
+		}).
+		Assess("new pods become available minReadySeconds after being ready", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := ctx.Value(deploymentKey).(*appsv1.Deployment)
+
+			// Changing the pod template starts a rollout to a second revision
+			rolloutStart := time.Now()
+			patch := k8s.Patch{
+				PatchType: types.MergePatchType,
+				Data:      []byte(`{"spec":{"template":{"metadata":{"annotations":{"e2e-tests/restarted-at":"` + rolloutStart.Format(time.RFC3339) + `"}}}}}`),
+			}
+			if err := cfg.Client().Resources().Patch(ctx, deployment, patch); err != nil {
+				t.Fatalf("Failed to patch deployment %s: %v", deployment.Name, err)
+			}
+
+			// The new ReplicaSet reports ready and available replicas in the same status, observed on
+			// every poll, so the measured gap is accurate to one poll interval
+			const pollInterval = time.Second
+			var readyAt, availableAt time.Time
+			err := wait.PollUntilContextTimeout(ctx, pollInterval, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+				replicaSet, err := replicaSetForRevision(ctx, cfg.Client().Resources(), deployment, "2")
+				if err != nil || replicaSet == nil {
+					return false, err
+				}
+
+				now := time.Now()
+				if readyAt.IsZero() && replicaSet.Status.ReadyReplicas > 0 {
+					readyAt = now
+				}
+				if availableAt.IsZero() && replicaSet.Status.AvailableReplicas > 0 {
+					availableAt = now
+				}
+				return !availableAt.IsZero(), nil
+			})
+			if err != nil {
+				t.Fatalf("New pod of deployment %s never became available: %v", deployment.Name, err)
+			}
+			gap := availableAt.Sub(readyAt)
+			if gap < minReadySeconds*time.Second-pollInterval {
+				t.Fatalf("New pod of deployment %s became available %s after being ready, expected at least %ds",
+					deployment.Name, gap, minReadySeconds)
+			}
+			t.Logf("✓ New pod of deployment %s became available %s after being ready (minReadySeconds: %d)",
+				deployment.Name, gap.Round(time.Second), minReadySeconds)
+
+			if err := waitForDeploymentRolledOut(ctx, cfg.Client().Resources(), deployment); err != nil {
+				t.Fatalf("Deployment %s not rolled out: %v", deployment.Name, err)
+			}
+			t.Logf("✓ Rollout of deployment %s took %s", deployment.Name, time.Since(rolloutStart).Round(time.Second))
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete Deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, minReadyFeature)
+}
+
 func newDeployment(namespace string, name string, replicaCount int32) *appsv1.Deployment {
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": "test-app"}},
@@ -87,3 +465,102 @@ func newDeployment(namespace string, name string, replicaCount int32) *appsv1.De
 		},
 	}
 }
+
+// newReplicaSet creates a ReplicaSet of sleeping pods selecting the given labels
+func newReplicaSet(namespace, name string, labels map[string]string, replicas int32) *appsv1.ReplicaSet {
+	podSpec := newSchedulingPod(namespace, name).Spec
+	podSpec.RestartPolicy = corev1.RestartPolicyAlways
+
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+// replicaSetForRevision returns the ReplicaSet controlled by a deployment for one of its revisions,
+// or nil when the deployment controller did not create it yet
+func replicaSetForRevision(ctx context.Context, client *resources.Resources, deployment *appsv1.Deployment, revision string) (*appsv1.ReplicaSet, error) {
+	var replicaSets appsv1.ReplicaSetList
+	selector := metav1.FormatLabelSelector(deployment.Spec.Selector)
+	if err := client.List(ctx, &replicaSets, resources.WithLabelSelector(selector)); err != nil {
+		return nil, err
+	}
+	for i := range replicaSets.Items {
+		replicaSet := &replicaSets.Items[i]
+		if metav1.IsControlledBy(replicaSet, deployment) && replicaSet.Annotations["deployment.kubernetes.io/revision"] == revision {
+			return replicaSet, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// replicaSetControlledBy returns the ReplicaSet controlled by a deployment
+func replicaSetControlledBy(ctx context.Context, client *resources.Resources, deployment *appsv1.Deployment) (*appsv1.ReplicaSet, error) {
+	var current appsv1.Deployment
+	if err := client.Get(ctx, deployment.Name, deployment.Namespace, &current); err != nil {
+		return nil, err
+	}
+
+	var replicaSets appsv1.ReplicaSetList
+	selector := metav1.FormatLabelSelector(current.Spec.Selector)
+	if err := client.List(ctx, &replicaSets, resources.WithLabelSelector(selector)); err != nil {
+		return nil, err
+	}
+	for i := range replicaSets.Items {
+		if metav1.IsControlledBy(&replicaSets.Items[i], &current) {
+			return &replicaSets.Items[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no ReplicaSet is controlled by deployment %s", deployment.Name)
+}
+
+// podsControlledBy returns the pods matching the labels that are controlled by owner and not being deleted
+func podsControlledBy(ctx context.Context, client *resources.Resources, owner metav1.Object, labels map[string]string) ([]corev1.Pod, error) {
+	var pods corev1.PodList
+	selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: labels})
+	if err := client.List(ctx, &pods, resources.WithLabelSelector(selector)); err != nil {
+		return nil, err
+	}
+
+	var controlled []corev1.Pod
+	for _, pod := range pods.Items {
+		if metav1.IsControlledBy(&pod, owner) && pod.DeletionTimestamp == nil {
+			controlled = append(controlled, pod)
+		}
+	}
+
+	return controlled, nil
+}
+
+// patchReplicaSetScale sets the replicas of a ReplicaSet through its scale subresource
+func patchReplicaSetScale(ctx context.Context, cfg *envconf.Config, replicaSet *appsv1.ReplicaSet, replicas int32) (*autoscalingv1.Scale, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	var scale autoscalingv1.Scale
+	err = clientset.AppsV1().RESTClient().Patch(types.MergePatchType).
+		Namespace(replicaSet.Namespace).
+		Resource("replicasets").
+		Name(replicaSet.Name).
+		SubResource("scale").
+		Body([]byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))).
+		Do(ctx).
+		Into(&scale)
+
+	return &scale, err
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestDNSResolution(t *testing.T) {
+	serviceKey := any("service-key")
+	externalNameKey := any("external-name-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	dnsFeature := features.New("network/dns-resolution").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// A ClusterIP service gets its DNS record regardless of its endpoints, so no backend is needed
+			service := newNetworkService(cfg.Namespace(), "dns-test-service")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			// Alias the API server service, so that the CNAME target resolves without external DNS
+			externalName := newExternalNameService(cfg.Namespace(), "dns-test-external", "kubernetes.default.svc.cluster.local")
+			if err := cfg.Client().Resources().Create(ctx, externalName); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, externalNameKey, externalName)
+
+			// A running pod gets a pod-ip.namespace.pod.cluster.local record
+			pod := newSchedulingPod(cfg.Namespace(), "dns-test-target")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("service name resolves to its ClusterIP", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			var currentService corev1.Service
+			if err := cfg.Client().Resources().Get(ctx, service.Name, service.Namespace, &currentService); err != nil {
+				t.Fatal(err)
+			}
+
+			hostname := service.Name + "." + service.Namespace + ".svc.cluster.local"
+			logs := lookupFromPod(ctx, t, cfg, "dns-test-lookup", hostname)
+
+			addresses := parseNslookupAddresses(logs)
+			if !slices.Contains(addresses, currentService.Spec.ClusterIP) {
+				t.Fatalf("Expected %s to resolve to ClusterIP %s, got %v", hostname, currentService.Spec.ClusterIP, addresses)
+			}
+			t.Logf("✓ %s resolved to ClusterIP %s", hostname, currentService.Spec.ClusterIP)
+
+			return ctx
+		}).
+		Assess("pod DNS record resolves to the pod IP", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+
+			hostname := podDNSName(currentPod.Status.PodIP, pod.Namespace)
+			logs := lookupFromPod(ctx, t, cfg, "dns-test-pod-record", hostname)
+
+			addresses := parseNslookupAddresses(logs)
+			if !slices.Contains(addresses, currentPod.Status.PodIP) {
+				t.Fatalf("Expected %s to resolve to pod IP %s, got %v", hostname, currentPod.Status.PodIP, addresses)
+			}
+			t.Logf("✓ %s resolved to pod IP %s", hostname, currentPod.Status.PodIP)
+
+			return ctx
+		}).
+		Assess("ExternalName service resolves to its CNAME", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			externalName := ctx.Value(externalNameKey).(*corev1.Service)
+
+			hostname := externalName.Name + "." + externalName.Namespace + ".svc.cluster.local"
+			logs := lookupFromPod(ctx, t, cfg, "dns-test-external-name", hostname)
+
+			if cname := parseNslookupCNAME(logs); cname != externalName.Spec.ExternalName {
+				t.Fatalf("Expected %s to be a CNAME for %s, got %q", hostname, externalName.Spec.ExternalName, cname)
+			}
+			if len(parseNslookupAddresses(logs)) == 0 {
+				t.Fatalf("CNAME target %s of %s did not resolve", externalName.Spec.ExternalName, hostname)
+			}
+			t.Logf("✓ %s is a CNAME for %s", hostname, externalName.Spec.ExternalName)
+
+			return ctx
+		}).
+		Assess("nonexistent service returns NXDOMAIN", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			hostname := "dns-test-nonexistent." + cfg.Namespace() + ".svc.cluster.local"
+			pod := newDNSClientPod(cfg.Namespace(), "dns-test-nxdomain", hostname)
+			defer func() {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete DNS lookup pod: %v", err)
+				}
+			}()
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatal(err)
+			}
+
+			if !podFailedAsExpected(ctx, cfg.Client().Resources(), pod) {
+				t.Fatalf("Lookup of nonexistent service %s should fail with NXDOMAIN, but it succeeded", hostname)
+			}
+			t.Logf("✓ %s correctly returned NXDOMAIN", hostname)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete target pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete pod: %v", err)
+				}
+			}
+
+			// Delete services
+			for _, key := range []any{serviceKey, externalNameKey} {
+				if service, ok := ctx.Value(key).(*corev1.Service); ok && service != nil {
+					if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+						t.Logf("Failed to delete service %s: %v", service.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, dnsFeature)
+}
+
+// newDNSClientPod creates a pod that resolves a DNS query and exits non-zero on NXDOMAIN
+func newDNSClientPod(namespace, name, dnsQuery string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "dns-test-client"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "dns-lookup",
+					Image: "alpine:latest",
+					Command: []string{
+						"sh", "-c",
+						"out=$(nslookup " + dnsQuery + " 2>&1); rc=$?; " +
+							"echo \"$out\"; " +
+							"echo \"$out\" | grep -q NXDOMAIN && exit 1; " +
+							"exit $rc",
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// lookupFromPod resolves a DNS query from a short-lived client pod and returns its nslookup output,
+// failing the test when the lookup fails
+func lookupFromPod(ctx context.Context, t *testing.T, cfg *envconf.Config, name, dnsQuery string) string {
+	t.Helper()
+
+	pod := newDNSClientPod(cfg.Namespace(), name, dnsQuery)
+	defer func() {
+		if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+			t.Logf("Failed to delete DNS client pod: %v", err)
+		}
+	}()
+	if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil {
+		if logs, logErr := getPodLogs(ctx, cfg, pod, ""); logErr == nil {
+			t.Logf("DNS client pod logs:\n%s", logs)
+		}
+		t.Fatalf("DNS lookup of %s failed: %v", dnsQuery, err)
+	}
+
+	logs, err := getPodLogs(ctx, cfg, pod, "")
+	if err != nil {
+		t.Fatalf("Failed to read DNS client pod logs: %v", err)
+	}
+	return logs
+}
+
+// newExternalNameService creates an ExternalName service aliasing externalName
+func newExternalNameService(namespace, name, externalName string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "dns-test"},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: externalName,
+		},
+	}
+}
+
+// podDNSName returns the pod A/AAAA record name, with the IP dots (or IPv6 colons) replaced by dashes
+func podDNSName(podIP, namespace string) string {
+	dashed := strings.NewReplacer(".", "-", ":", "-").Replace(podIP)
+	return dashed + "." + namespace + ".pod.cluster.local"
+}
+
+// parseNslookupCNAME returns the canonical name reported in an nslookup output, or "" when there is none
+func parseNslookupCNAME(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if _, cname, found := strings.Cut(line, "canonical name ="); found {
+			return strings.TrimSuffix(strings.TrimSpace(cname), ".")
+		}
+	}
+
+	return ""
+}
+
+// parseNslookupAddresses returns the answer addresses of an nslookup output, skipping the
+// DNS server address printed before the first Name line
+func parseNslookupAddresses(output string) []string {
+	var addresses []string
+	inAnswer := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Name:"):
+			inAnswer = true
+		case inAnswer && strings.HasPrefix(line, "Address"):
+			// busybox prints "Address: 10.0.0.1", older variants "Address 1: 10.0.0.1 host"
+			_, value, found := strings.Cut(line, ":")
+			if !found {
+				continue
+			}
+			if fields := strings.Fields(value); len(fields) > 0 {
+				addresses = append(addresses, fields[0])
+			}
+		}
+	}
+
+	return addresses
+}
+
+func TestParseNslookupAddresses(t *testing.T) {
+	unitTest(t)
+
+	tests := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{
+			name: "busybox answer",
+			output: "Server:\t\t10.96.0.10\nAddress:\t10.96.0.10:53\n\n" +
+				"Name:\tdns-test-service.default.svc.cluster.local\nAddress: 10.96.12.34\n",
+			expected: []string{"10.96.12.34"},
+		},
+		{
+			name: "legacy busybox answer",
+			output: "Server:    10.96.0.10\nAddress 1: 10.96.0.10 kube-dns.kube-system.svc.cluster.local\n\n" +
+				"Name:      dns-test-service\nAddress 1: 10.96.12.34 dns-test-service.default.svc.cluster.local\n",
+			expected: []string{"10.96.12.34"},
+		},
+		{
+			name:     "NXDOMAIN",
+			output:   "Server:\t\t10.96.0.10\nAddress:\t10.96.0.10:53\n\n** server can't find nonexistent: NXDOMAIN\n",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addresses := parseNslookupAddresses(tt.output)
+			if !slices.Equal(addresses, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, addresses)
+			}
+		})
+	}
+}
+
+func TestParseNslookupCNAME(t *testing.T) {
+	unitTest(t)
+
+	output := "Server:\t\t10.96.0.10\nAddress:\t10.96.0.10:53\n\n" +
+		"dns-test-external.default.svc.cluster.local\tcanonical name = kubernetes.default.svc.cluster.local\n" +
+		"Name:\tkubernetes.default.svc.cluster.local\nAddress: 10.96.0.1\n"
+
+	if cname := parseNslookupCNAME(output); cname != "kubernetes.default.svc.cluster.local" {
+		t.Errorf("expected kubernetes.default.svc.cluster.local, got %q", cname)
+	}
+	if addresses := parseNslookupAddresses(output); !slices.Equal(addresses, []string{"10.96.0.1"}) {
+		t.Errorf("expected CNAME target address 10.96.0.1, got %v", addresses)
+	}
+	if cname := parseNslookupCNAME("Name:\tdns-test-service\nAddress: 10.96.12.34\n"); cname != "" {
+		t.Errorf("expected no canonical name, got %q", cname)
+	}
+}
+
+func TestPodDNSName(t *testing.T) {
+	unitTest(t)
+
+	if name := podDNSName("10.244.1.5", "default"); name != "10-244-1-5.default.pod.cluster.local" {
+		t.Errorf("unexpected IPv4 pod DNS name %q", name)
+	}
+	if name := podDNSName("fd00::5", "default"); name != "fd00--5.default.pod.cluster.local" {
+		t.Errorf("unexpected IPv6 pod DNS name %q", name)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestResourceFieldRef(t *testing.T) {
+	podKey := any("pod-key")
+	cpuLimit := resource.MustParse("250m")
+	cpuDivisor := resource.MustParse("1m")
+	memoryLimit := resource.MustParse("64Mi")
+	memoryDivisor := resource.MustParse("1Mi")
+	setupNamespace, teardownNamespace := withIsolatedNamespace("downward-api")
+
+	trackTest(t)
+
+	fieldRefFeature := features.New("workloads/downward-api-resource-fieldref").
+		WithSetup("create namespace", setupNamespace).
+		Assess("limits are projected divided by the divisor", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// The downward API rounds the division up
+			expectedCPU := divideRoundUp(cpuLimit.MilliValue(), cpuDivisor.MilliValue())
+			expectedMemory := divideRoundUp(memoryLimit.Value(), memoryDivisor.Value())
+
+			pod := newResourceFieldRefPod(cfg.Namespace(), "downward-api-test",
+				cpuLimit, cpuDivisor, memoryLimit, memoryDivisor, expectedCPU, expectedMemory)
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil {
+				if logs, logErr := getPodLogs(ctx, cfg, pod, ""); logErr == nil {
+					t.Logf("Downward API pod logs:\n%s", logs)
+				}
+				t.Fatalf("Projected limits do not match CPU=%d, MEMORY=%d: %v", expectedCPU, expectedMemory, err)
+			}
+			t.Logf("✓ CPU limit %s / %s projected as %d, memory limit %s / %s projected as %d",
+				cpuLimit.String(), cpuDivisor.String(), expectedCPU, memoryLimit.String(), memoryDivisor.String(), expectedMemory)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).
+		WithTeardown("delete namespace", teardownNamespace).
+		Feature()
+
+	testenv.Test(t, fieldRefFeature)
+}
+
+// divideRoundUp divides a by b, rounding up like the downward API does for resource fields
+func divideRoundUp(a, b int64) int64 {
+	return (a + b - 1) / b
+}
+
+// newResourceFieldRefPod creates a pod projecting its CPU and memory limits through the downward API,
+// exiting non-zero unless they match the expected values
+func newResourceFieldRefPod(namespace, name string, cpuLimit, cpuDivisor, memoryLimit, memoryDivisor resource.Quantity, expectedCPU, expectedMemory int64) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "downward-api-test"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "downward-api",
+					Image: "alpine:latest",
+					Command: []string{
+						"sh", "-c",
+						fmt.Sprintf("echo \"CPU_LIMIT=$CPU_LIMIT MEMORY_LIMIT=$MEMORY_LIMIT\" && "+
+							"test \"$CPU_LIMIT\" = '%d' && test \"$MEMORY_LIMIT\" = '%d'", expectedCPU, expectedMemory),
+					},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    cpuLimit,
+							corev1.ResourceMemory: memoryLimit,
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU:    cpuLimit,
+							corev1.ResourceMemory: memoryLimit,
+						},
+					},
+					Env: []corev1.EnvVar{
+						{
+							Name: "CPU_LIMIT",
+							ValueFrom: &corev1.EnvVarSource{
+								ResourceFieldRef: &corev1.ResourceFieldSelector{
+									Resource: "limits.cpu",
+									Divisor:  cpuDivisor,
+								},
+							},
+						},
+						{
+							Name: "MEMORY_LIMIT",
+							ValueFrom: &corev1.EnvVarSource{
+								ResourceFieldRef: &corev1.ResourceFieldSelector{
+									Resource: "limits.memory",
+									Divisor:  memoryDivisor,
+								},
+							},
+						},
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	apfBurstRequests    = 2000
+	apfBurstConcurrency = 200
+)
+
+// builtinPriorityLevels are the mandatory and suggested priority levels maintained by the API server
+var builtinPriorityLevels = []string{
+	flowcontrolv1.PriorityLevelConfigurationNameExempt,
+	flowcontrolv1.PriorityLevelConfigurationNameCatchAll,
+	"system",
+	"node-high",
+	"leader-election",
+	"workload-high",
+	"workload-low",
+	"global-default",
+}
+
+// builtinFlowSchemas are the mandatory flow schemas maintained by the API server
+var builtinFlowSchemas = []string{
+	flowcontrolv1.FlowSchemaNameExempt,
+	flowcontrolv1.FlowSchemaNameCatchAll,
+}
+
+func TestAPIPriorityFairness(t *testing.T) {
+	loadTest := os.Getenv("APF_LOAD_TEST") == "true"
+
+	trackTest(t)
+
+	apfFeature := features.New("api/priority-and-fairness").
+		Assess("built-in priority levels are configured", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var levels flowcontrolv1.PriorityLevelConfigurationList
+			if err := cfg.Client().Resources().List(ctx, &levels); err != nil {
+				t.Fatalf("Failed to list PriorityLevelConfigurations: %v", err)
+			}
+
+			byName := map[string]flowcontrolv1.PriorityLevelConfiguration{}
+			for _, level := range levels.Items {
+				byName[level.Name] = level
+			}
+
+			for _, name := range builtinPriorityLevels {
+				level, ok := byName[name]
+				if !ok {
+					t.Fatalf("Built-in priority level %s is missing", name)
+				}
+
+				switch level.Spec.Type {
+				case flowcontrolv1.PriorityLevelEnablementExempt:
+					t.Logf("✓ Priority level %s is exempt", name)
+				case flowcontrolv1.PriorityLevelEnablementLimited:
+					limited := level.Spec.Limited
+					if limited == nil || limited.NominalConcurrencyShares == nil || *limited.NominalConcurrencyShares <= 0 {
+						t.Fatalf("Limited priority level %s has no nominal concurrency shares", name)
+					}
+					t.Logf("✓ Priority level %s is limited with %d nominal concurrency shares", name, *limited.NominalConcurrencyShares)
+				default:
+					t.Fatalf("Priority level %s has unexpected type %q", name, level.Spec.Type)
+				}
+			}
+
+			return ctx
+		}).
+		Assess("built-in flow schemas are configured", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var schemas flowcontrolv1.FlowSchemaList
+			if err := cfg.Client().Resources().List(ctx, &schemas); err != nil {
+				t.Fatalf("Failed to list FlowSchemas: %v", err)
+			}
+
+			byName := map[string]flowcontrolv1.FlowSchema{}
+			for _, schema := range schemas.Items {
+				byName[schema.Name] = schema
+			}
+
+			for _, name := range builtinFlowSchemas {
+				schema, ok := byName[name]
+				if !ok {
+					t.Fatalf("Built-in flow schema %s is missing", name)
+				}
+				// The mandatory schemas route to the priority level of the same name
+				if schema.Spec.PriorityLevelConfiguration.Name != name {
+					t.Fatalf("Flow schema %s references priority level %s, expected %s", name, schema.Spec.PriorityLevelConfiguration.Name, name)
+				}
+				t.Logf("✓ Flow schema %s routes to priority level %s", name, schema.Spec.PriorityLevelConfiguration.Name)
+			}
+
+			return ctx
+		}).
+		Assess("exempt probes are served during a request burst", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if !loadTest {
+				skipTest(t, skipReasonOptIn, "APF_LOAD_TEST not set to true, skipping request burst")
+			}
+
+			httpClient, err := rest.HTTPClientFor(cfg.Client().RESTConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			host := cfg.Client().RESTConfig().Host
+
+			var burstThrottled, probesThrottled, probesServed atomic.Int64
+			burstCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			// Probe /livez, which the built-in probes flow schema routes to the exempt priority level
+			probesDone := make(chan struct{})
+			go func() {
+				defer close(probesDone)
+				for burstCtx.Err() == nil {
+					switch status := apfRequest(burstCtx, httpClient, host+"/livez"); status {
+					case http.StatusTooManyRequests:
+						probesThrottled.Add(1)
+					case http.StatusOK:
+						probesServed.Add(1)
+					}
+					time.Sleep(50 * time.Millisecond)
+				}
+			}()
+
+			requests := make(chan struct{}, apfBurstRequests)
+			for range apfBurstRequests {
+				requests <- struct{}{}
+			}
+			close(requests)
+
+			var wg sync.WaitGroup
+			for range apfBurstConcurrency {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range requests {
+						if apfRequest(burstCtx, httpClient, host+"/api/v1/pods?limit=500") == http.StatusTooManyRequests {
+							burstThrottled.Add(1)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			cancel()
+			<-probesDone
+
+			t.Logf("Burst of %d list requests: %d throttled with 429", apfBurstRequests, burstThrottled.Load())
+			if probesThrottled.Load() > 0 {
+				t.Fatalf("%d exempt /livez probes were throttled during the burst", probesThrottled.Load())
+			}
+			if probesServed.Load() == 0 {
+				t.Fatal("No /livez probe was served during the burst")
+			}
+			t.Logf("✓ %d exempt /livez probes served without throttling during the burst", probesServed.Load())
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, apfFeature)
+}
+
+// apfRequest issues a GET request and returns the response status code, or 0 when the request failed
+func apfRequest(ctx context.Context, client *http.Client, url string) int {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0
+	}
+	_ = resp.Body.Close()
+
+	return resp.StatusCode
+}
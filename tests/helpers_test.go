@@ -0,0 +1,622 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/envfuncs"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+
+	"github.com/clementnuss/e2e-tests/tests/metrics"
+)
+
+var (
+	// errPodFailed is returned when a pod terminates in the Failed phase
+	errPodFailed = errors.New("pod failed")
+	// errPodTimedOut is returned when a pod does not terminate before the timeout
+	errPodTimedOut = errors.New("timed out waiting for pod")
+	// errRetriesExhausted is returned when a retried function did not succeed within its attempts
+	errRetriesExhausted = errors.New("retries exhausted")
+)
+
+// runPodToCompletion creates a pod and waits for it to terminate, returning the first container's
+// exit code and the final phase. A pod that terminates in the Failed phase yields an errPodFailed
+// error, a pod that does not terminate in time (including one that never schedules) an errPodTimedOut
+// error.
+func runPodToCompletion(ctx context.Context, client *resources.Resources, pod *corev1.Pod, timeout time.Duration) (int32, corev1.PodPhase, error) {
+	createdAt := time.Now()
+	if err := client.Create(ctx, pod); err != nil {
+		return 0, "", err
+	}
+
+	var (
+		exitCode int32
+		phase    corev1.PodPhase
+	)
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var currentPod corev1.Pod
+		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+			return false, err
+		}
+
+		var done bool
+		done, exitCode = podTerminalState(&currentPod)
+		phase = currentPod.Status.Phase
+		return done, nil
+	})
+
+	switch {
+	case wait.Interrupted(err):
+		return 0, phase, fmt.Errorf("%w %s: still %s after %s", errPodTimedOut, pod.Name, phase, timeout)
+	case err != nil:
+		return 0, phase, err
+	}
+
+	recordResourceCreation(ctx, "Pod", pod.Name, createdAt)
+	if phase == corev1.PodFailed {
+		return exitCode, phase, fmt.Errorf("%w: %s exited with code %d", errPodFailed, pod.Name, exitCode)
+	}
+
+	return exitCode, phase, nil
+}
+
+// recordResourceCreation records the time elapsed since an object that just reached its desired
+// state was created, with createdAt captured before the create request like runPodToCompletion does
+func recordResourceCreation(ctx context.Context, kind, name string, createdAt time.Time) {
+	metricsCollector.RecordResourceCreation(ctx, kind, name, time.Since(createdAt))
+}
+
+// waitForDeleted waits until an object no longer exists
+func waitForDeleted(ctx context.Context, client *resources.Resources, obj k8s.Object) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		current := obj.DeepCopyObject().(k8s.Object)
+		err := client.Get(ctx, obj.GetName(), obj.GetNamespace(), current)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+
+		return false, err
+	})
+}
+
+// dependentResource is a resource to delete along with the resources it depends on, e.g. a pod
+// depending on the PVC it mounts
+type dependentResource struct {
+	object    k8s.Object
+	dependsOn []k8s.Object
+}
+
+// teardownOrder orders resources leaf-first: a resource comes after every resource depending on it
+func teardownOrder(resources []dependentResource) ([]k8s.Object, error) {
+	remaining := slices.Clone(resources)
+	order := make([]k8s.Object, 0, len(resources))
+
+	for len(remaining) > 0 {
+		leaf := slices.IndexFunc(remaining, func(candidate dependentResource) bool {
+			return !slices.ContainsFunc(remaining, func(other dependentResource) bool {
+				return slices.Contains(other.dependsOn, candidate.object)
+			})
+		})
+		if leaf < 0 {
+			return nil, fmt.Errorf("dependency cycle between %d resources", len(remaining))
+		}
+
+		order = append(order, remaining[leaf].object)
+		remaining = slices.Delete(remaining, leaf, leaf+1)
+	}
+
+	return order, nil
+}
+
+// deleteInOrder deletes resources leaf-first, waiting for each one to be gone before deleting the
+// resources it depends on, so that a PVC is not held by the pod still using it. It stops at the
+// first error and returns the resources deleted so far, in deletion order.
+func deleteInOrder(ctx context.Context, client *resources.Resources, resources ...dependentResource) ([]k8s.Object, error) {
+	order, err := teardownOrder(resources)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]k8s.Object, 0, len(order))
+	for _, obj := range order {
+		if err := client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return deleted, fmt.Errorf("failed to delete %s: %w", obj.GetName(), err)
+		}
+		if err := waitForDeleted(ctx, client, obj); err != nil {
+			return deleted, fmt.Errorf("%s was not deleted: %w", obj.GetName(), err)
+		}
+		deleted = append(deleted, obj)
+	}
+
+	return deleted, nil
+}
+
+// nameGenerator generates random resource names from a seeded source, so that setting
+// E2E_RANDOM_SEED to the seed logged by a run replays its names
+type nameGenerator struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// newNameGenerator creates a name generator seeded with seed
+func newNameGenerator(seed uint64) *nameGenerator {
+	return &nameGenerator{rng: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// randomName generates a name of n characters with the given prefix, formatted like envconf.RandomName
+func (g *nameGenerator) randomName(prefix string, n int) string {
+	if n == 0 {
+		n = 32
+	}
+	if len(prefix) >= n {
+		return prefix
+	}
+
+	p := make([]byte, n)
+	g.mu.Lock()
+	for i := range p {
+		p[i] = byte(g.rng.UintN(256))
+	}
+	g.mu.Unlock()
+
+	if prefix == "" {
+		return hex.EncodeToString(p)[:n]
+	}
+	return fmt.Sprintf("%s-%s", prefix, hex.EncodeToString(p))[:n]
+}
+
+// withIsolatedNamespace returns setup and teardown steps creating and deleting a dedicated namespace
+// for a feature. The setup step points cfg.Namespace() at the new namespace; since every feature
+// runs with its own copy of the config, other features keep using the shared test namespace. Options
+// are applied to the namespace before it is created.
+func withIsolatedNamespace(name string, opts ...envfuncs.CreateNamespaceOpts) (features.Func, features.Func) {
+	namespace := names.randomName(name, 24)
+
+	setup := func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		ctx, err := envfuncs.CreateNamespace(namespace, opts...)(ctx, cfg)
+		if err != nil {
+			t.Fatalf("Failed to create namespace %s: %v", namespace, err)
+		}
+		return ctx
+	}
+	teardown := func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		ctx, err := envfuncs.DeleteNamespace(namespace)(ctx, cfg)
+		if err != nil {
+			t.Logf("Failed to delete namespace %s: %v", namespace, err)
+		}
+		return ctx
+	}
+
+	return setup, teardown
+}
+
+// auxNamespacesKey holds the names of the namespaces created by createAuxNamespace in a feature
+var auxNamespacesKey = any("aux-namespaces-key")
+
+// createAuxNamespace creates a namespace next to the feature one through envfuncs.CreateNamespace,
+// keeping cfg.Namespace() unchanged, and records it in the context for deleteAuxNamespaces
+func createAuxNamespace(ctx context.Context, cfg *envconf.Config, name string) (context.Context, error) {
+	featureNamespace := cfg.Namespace()
+	ctx, err := envfuncs.CreateNamespace(name)(ctx, cfg)
+	cfg.WithNamespace(featureNamespace)
+	if err != nil {
+		return ctx, err
+	}
+
+	names, _ := ctx.Value(auxNamespacesKey).([]string)
+	return context.WithValue(ctx, auxNamespacesKey, append(slices.Clone(names), name)), nil
+}
+
+// deleteAuxNamespaces is a teardown step deleting the namespaces created by createAuxNamespace
+func deleteAuxNamespaces(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+	names, _ := ctx.Value(auxNamespacesKey).([]string)
+	for _, name := range names {
+		if _, err := envfuncs.DeleteNamespace(name)(ctx, cfg); err != nil {
+			t.Logf("Failed to delete namespace %s: %v", name, err)
+		}
+	}
+
+	return ctx
+}
+
+// serverVersionAtLeast reports whether the API server runs at least the given Kubernetes version
+func serverVersionAtLeast(cfg *envconf.Config, major, minor uint) (bool, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return false, err
+	}
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return false, err
+	}
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return false, err
+	}
+
+	return serverVersion.AtLeast(version.MajorMinor(major, minor)), nil
+}
+
+// retryWithBackoff calls fn until it reports done, at most attempts times, doubling the wait between attempts
+// starting from backoff. Errors for which retriable returns false are returned immediately, as is
+// the context error when ctx is cancelled while waiting. Every retry is counted in the test metrics.
+func retryWithBackoff(ctx context.Context, t *testing.T, attempts int, backoff time.Duration, retriable func(error) bool, fn func() (bool, error)) error {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		done, err := fn()
+		switch {
+		case err != nil && !retriable(err):
+			return err
+		case err == nil && done:
+			return nil
+		}
+		lastErr = err
+
+		if attempt >= attempts {
+			break
+		}
+		t.Logf("Attempt %d/%d did not succeed, retrying in %s: %v", attempt, attempts, backoff, err)
+		metricsCollector.RecordTestRetry(ctx, t.Name(), attempt+1)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("%w after %d attempts: %w", errRetriesExhausted, attempts, lastErr)
+	}
+	return fmt.Errorf("%w after %d attempts", errRetriesExhausted, attempts)
+}
+
+// isTransientAPIError reports whether an API error is worth retrying: throttling, timeouts and
+// temporary server-side failures
+func isTransientAPIError(err error) bool {
+	return apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err)
+}
+
+// trackTest counts t as an active test and records its execution metrics once it completes
+func trackTest(t *testing.T) {
+	trackTestWithAttributes(t, nil)
+}
+
+// trackTestWithAttributes is trackTest with extra metric attributes, evaluated once t completes.
+// Cluster tests are skipped in short mode, which has no test environment.
+func trackTestWithAttributes(t *testing.T, attributes func() []attribute.KeyValue) {
+	if testing.Short() {
+		t.Skip("Cluster test, skipped in short mode")
+	}
+
+	start := time.Now()
+	metricsCollector.IncrementActiveTests(testContext, t.Name())
+	t.Cleanup(func() {
+		metricsCollector.DecrementActiveTests(testContext, t.Name())
+
+		var extraAttrs []attribute.KeyValue
+		if attributes != nil {
+			extraAttrs = attributes()
+		}
+		metricsCollector.RecordTestExecution(testContext, t, time.Since(start), extraAttrs...)
+	})
+}
+
+// unitTest skips a unit test outside of short mode, so that cluster runs neither need nor report
+// it. Unit tests run without a cluster with go test -short.
+func unitTest(t *testing.T) {
+	t.Helper()
+	if !testing.Short() {
+		t.Skip("Unit test, run with -short")
+	}
+}
+
+// stageTracker records the feature stage a test was in when it first failed, so that failures can
+// be broken down by stage in the test metrics
+type stageTracker struct {
+	t     *testing.T
+	stage string
+}
+
+// newStageTracker creates a stageTracker for the top-level test t
+func newStageTracker(t *testing.T) *stageTracker {
+	return &stageTracker{t: t}
+}
+
+// setup, assess and teardown wrap a feature step of the matching stage
+func (s *stageTracker) setup(fn features.Func) features.Func    { return s.step("setup", fn) }
+func (s *stageTracker) assess(fn features.Func) features.Func   { return s.step("assess", fn) }
+func (s *stageTracker) teardown(fn features.Func) features.Func { return s.step("teardown", fn) }
+
+// step records the stage fn runs in before running it, unless the test already failed. Failures in
+// setup and assessment subtests propagate to t immediately, so the failing stage is kept.
+func (s *stageTracker) step(stage string, fn features.Func) features.Func {
+	return func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		if !s.t.Failed() {
+			s.stage = stage
+		}
+		return fn(ctx, t, cfg)
+	}
+}
+
+// attributes returns the failure_stage attribute of a failed test, or none when it did not fail
+func (s *stageTracker) attributes() []attribute.KeyValue {
+	if !s.t.Failed() || s.stage == "" {
+		return nil
+	}
+
+	return []attribute.KeyValue{attribute.String(metrics.FailureStageKey, s.stage)}
+}
+
+// podTerminalState reports whether a pod has terminated and the exit code of its first container,
+// or -1 when the container never reported a termination state
+func podTerminalState(pod *corev1.Pod) (bool, int32) {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded, corev1.PodFailed:
+	default:
+		return false, 0
+	}
+
+	if len(pod.Status.ContainerStatuses) == 0 || pod.Status.ContainerStatuses[0].State.Terminated == nil {
+		return true, -1
+	}
+
+	return true, pod.Status.ContainerStatuses[0].State.Terminated.ExitCode
+}
+
+func TestPodTerminalState(t *testing.T) {
+	unitTest(t)
+
+	terminated := func(exitCode int32) []corev1.ContainerStatus {
+		return []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode}},
+		}}
+	}
+
+	tests := []struct {
+		name             string
+		status           corev1.PodStatus
+		expectedDone     bool
+		expectedExitCode int32
+	}{
+		{
+			name:         "pending pod never scheduled",
+			status:       corev1.PodStatus{Phase: corev1.PodPending},
+			expectedDone: false,
+		},
+		{
+			name: "running pod",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+				}},
+			},
+			expectedDone: false,
+		},
+		{
+			name:             "succeeded pod",
+			status:           corev1.PodStatus{Phase: corev1.PodSucceeded, ContainerStatuses: terminated(0)},
+			expectedDone:     true,
+			expectedExitCode: 0,
+		},
+		{
+			name:             "failed pod with exit code",
+			status:           corev1.PodStatus{Phase: corev1.PodFailed, ContainerStatuses: terminated(3)},
+			expectedDone:     true,
+			expectedExitCode: 3,
+		},
+		{
+			name:             "failed pod without container status",
+			status:           corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+			expectedDone:     true,
+			expectedExitCode: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, exitCode := podTerminalState(&corev1.Pod{Status: tt.status})
+			if done != tt.expectedDone {
+				t.Errorf("expected done=%t, got %t", tt.expectedDone, done)
+			}
+			if exitCode != tt.expectedExitCode {
+				t.Errorf("expected exit code %d, got %d", tt.expectedExitCode, exitCode)
+			}
+		})
+	}
+}
+
+func TestTeardownOrder(t *testing.T) {
+	unitTest(t)
+
+	storageClass := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "storage-class"}}
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-pod"}}
+
+	names := func(objects []k8s.Object) []string {
+		var result []string
+		for _, obj := range objects {
+			result = append(result, obj.GetName())
+		}
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		resources []dependentResource
+		expected  []string
+		wantErr   bool
+	}{
+		{
+			name: "dependency chain declared root first",
+			resources: []dependentResource{
+				{object: storageClass},
+				{object: pvc, dependsOn: []k8s.Object{storageClass}},
+				{object: pod, dependsOn: []k8s.Object{pvc}},
+			},
+			expected: []string{"pod", "pvc", "storage-class"},
+		},
+		{
+			name: "shared dependency",
+			resources: []dependentResource{
+				{object: pvc},
+				{object: pod, dependsOn: []k8s.Object{pvc}},
+				{object: otherPod, dependsOn: []k8s.Object{pvc}},
+			},
+			expected: []string{"pod", "other-pod", "pvc"},
+		},
+		{
+			name: "dependency cycle",
+			resources: []dependentResource{
+				{object: pvc, dependsOn: []k8s.Object{pod}},
+				{object: pod, dependsOn: []k8s.Object{pvc}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := teardownOrder(tt.resources)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got order %v", names(order))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(order); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	unitTest(t)
+
+	transientErr := apierrors.NewTooManyRequests("throttled", 1)
+	fatalErr := apierrors.NewForbidden(corev1.Resource("pods"), "pod", errors.New("denied"))
+
+	tests := []struct {
+		name          string
+		results       []error
+		expectedCalls int
+		expectedErr   error
+	}{
+		{
+			name:          "fails twice then succeeds",
+			results:       []error{transientErr, transientErr, nil},
+			expectedCalls: 3,
+		},
+		{
+			name:          "fatal error is not retried",
+			results:       []error{fatalErr},
+			expectedCalls: 1,
+			expectedErr:   fatalErr,
+		},
+		{
+			name:          "attempts exhausted",
+			results:       []error{transientErr, transientErr, transientErr, transientErr},
+			expectedCalls: 4,
+			expectedErr:   errRetriesExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryWithBackoff(t.Context(), t, 4, time.Millisecond, isTransientAPIError, func() (bool, error) {
+				err := tt.results[calls]
+				calls++
+				return err == nil, err
+			})
+
+			if !errors.Is(err, tt.expectedErr) || (tt.expectedErr == nil && err != nil) {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+
+	t.Run("context cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		calls := 0
+		err := retryWithBackoff(ctx, t, 4, time.Hour, isTransientAPIError, func() (bool, error) {
+			calls++
+			cancel()
+			return false, transientErr
+		})
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+		if calls != 1 {
+			t.Errorf("expected 1 call, got %d", calls)
+		}
+	})
+}
+
+// Reasons tests are skipped for, reported as the skip_reason attribute. They are kept to a fixed
+// set, the details going to the skip message, as every distinct value creates a new series.
+const (
+	// skipReasonOptIn is used by disruptive or privileged tests not enabled by their variable
+	skipReasonOptIn = "opt_in"
+	// skipReasonDisabled is used by tests disabled through a variable
+	skipReasonDisabled = "disabled"
+	// skipReasonNotConfigured is used by tests missing the configuration they check against
+	skipReasonNotConfigured = "not_configured"
+	// skipReasonAPIUnavailable is used when the API server does not serve an API or feature
+	skipReasonAPIUnavailable = "api_unavailable"
+	// skipReasonUnsupportedVersion is used when the Kubernetes version is too old
+	skipReasonUnsupportedVersion = "unsupported_version"
+	// skipReasonUnsupportedNode is used when the node kernel or container runtime lacks a feature
+	skipReasonUnsupportedNode = "unsupported_node"
+	// skipReasonMissingPrerequisite is used when a cluster component, such as a class, is missing
+	skipReasonMissingPrerequisite = "missing_prerequisite"
+	// skipReasonNoEligibleNode is used when no node can run the test pods
+	skipReasonNoEligibleNode = "no_eligible_node"
+	// skipReasonNotApplicable is used when the cluster is not in the state the test validates
+	skipReasonNotApplicable = "not_applicable"
+	// skipReasonForbidden is used when the test ServiceAccount lacks an opt-in permission
+	skipReasonForbidden = "forbidden"
+	// skipReasonInaccessible is used when node endpoints cannot be reached
+	skipReasonInaccessible = "inaccessible"
+)
+
+// skipTest skips a test like t.Skipf, registering reason, one of the skipReason codes, as the
+// skip_reason reported in the test metrics. The formatted message carries the details.
+func skipTest(t *testing.T, reason, format string, args ...any) {
+	t.Helper()
+	metricsCollector.SetSkipReason(t, reason)
+	t.Skipf(format, args...)
+}
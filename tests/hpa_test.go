@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const defaultHPAScaleTimeout = 10 * time.Minute
+
+func TestHorizontalPodAutoscaler(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	hpaKey := any("hpa-key")
+	stressPodKey := any("stress-pod-key")
+	scaleTimeout := getEnvDuration("HPA_SCALE_TIMEOUT", defaultHPAScaleTimeout)
+
+	trackTest(t)
+
+	hpaFeature := features.New("autoscaling/hpa").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			available, err := apiResourceAvailable(cfg, "metrics.k8s.io/v1beta1", "pods")
+			if err != nil {
+				t.Fatalf("Failed to discover metrics.k8s.io API: %v", err)
+			}
+			if !available {
+				skipTest(t, skipReasonAPIUnavailable, "metrics.k8s.io API not available (metrics-server missing), skipping")
+			}
+
+			// Create nginx deployment with a small CPU request
+			deployment := newHPADeployment(cfg.Namespace(), "hpa-test-nginx")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			// Wait for deployment to be ready
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			// Create service
+			service := newNetworkService(cfg.Namespace(), "hpa-test-service")
+			service.Spec.Selector = map[string]string{"app": "hpa-test"}
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			// Create HPA
+			hpa := newHPA(cfg.Namespace(), "hpa-test", deployment.Name, 1, 3, 50)
+			if err := cfg.Client().Resources().Create(ctx, hpa); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, hpaKey, hpa)
+
+			return ctx
+		}).
+		Assess("scale out under load", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			hpa := ctx.Value(hpaKey).(*autoscalingv2.HorizontalPodAutoscaler)
+
+			stressPod := newStressPod(cfg.Namespace(), "hpa-test-stress", service.Name)
+			if err := cfg.Client().Resources().Create(ctx, stressPod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, stressPodKey, stressPod)
+
+			replicas, err := waitForHPAReplicas(ctx, cfg.Client().Resources(), hpa, scaleTimeout, func(current, minReplicas int32) bool {
+				return current > minReplicas
+			})
+			if err != nil {
+				t.Fatalf("HPA did not scale out within %s: %v", scaleTimeout, err)
+			}
+			t.Logf("✓ HPA scaled out to %d replicas", replicas)
+
+			return ctx
+		}).
+		Assess("scale in without load", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			hpa := ctx.Value(hpaKey).(*autoscalingv2.HorizontalPodAutoscaler)
+
+			if stressPod, ok := ctx.Value(stressPodKey).(*corev1.Pod); ok && stressPod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, stressPod); err != nil {
+					t.Fatalf("Failed to delete stress pod: %v", err)
+				}
+			}
+
+			replicas, err := waitForHPAReplicas(ctx, cfg.Client().Resources(), hpa, scaleTimeout, func(current, minReplicas int32) bool {
+				return current == minReplicas
+			})
+			if err != nil {
+				t.Fatalf("HPA did not scale in within %s: %v", scaleTimeout, err)
+			}
+			t.Logf("✓ HPA scaled back in to %d replicas", replicas)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete stress pod (ignore errors, it may already be gone)
+			if stressPod, ok := ctx.Value(stressPodKey).(*corev1.Pod); ok && stressPod != nil {
+				_ = cfg.Client().Resources().Delete(ctx, stressPod)
+			}
+
+			// Delete HPA
+			if hpa := ctx.Value(hpaKey).(*autoscalingv2.HorizontalPodAutoscaler); hpa != nil {
+				if err := cfg.Client().Resources().Delete(ctx, hpa); err != nil {
+					t.Logf("Failed to delete HPA: %v", err)
+				}
+			}
+
+			// Delete service
+			if service := ctx.Value(serviceKey).(*corev1.Service); service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment := ctx.Value(deploymentKey).(*appsv1.Deployment); deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, hpaFeature)
+}
+
+// newHPADeployment creates an nginx deployment with a tight CPU request so that load drives utilization up
+func newHPADeployment(namespace, name string) *appsv1.Deployment {
+	deployment := newNetworkDeployment(namespace, name)
+	setDeploymentAppLabel(deployment, "hpa-test")
+	deployment.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("20m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+	}
+	return deployment
+}
+
+// newHPA creates a HorizontalPodAutoscaler scaling a deployment on average CPU utilization
+func newHPA(namespace, name, deploymentName string, minReplicas, maxReplicas, cpuUtilization int32) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "hpa-test"},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       deploymentName,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: maxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: &cpuUtilization,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newStressPod creates a pod that continuously sends requests to a service to generate CPU load
+func newStressPod(namespace, name, serviceName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "hpa-test-stress"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "stress",
+					Image: "curlimages/curl:latest",
+					Command: []string{
+						"sh", "-c",
+						"for i in 1 2 3 4; do " +
+							"(while true; do curl -s -o /dev/null http://" + serviceName + "; done) & " +
+							"done; wait",
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65532}[0], // curl user
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// waitForHPAReplicas waits until the HPA's current replicas satisfy the condition and returns them
+func waitForHPAReplicas(ctx context.Context, client *resources.Resources, hpa *autoscalingv2.HorizontalPodAutoscaler, timeout time.Duration, condition func(current, minReplicas int32) bool) (int32, error) {
+	var replicas int32
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var currentHPA autoscalingv2.HorizontalPodAutoscaler
+		if err := client.Get(ctx, hpa.Name, hpa.Namespace, &currentHPA); err != nil {
+			return false, err
+		}
+
+		replicas = currentHPA.Status.CurrentReplicas
+		return condition(replicas, *currentHPA.Spec.MinReplicas), nil
+	})
+
+	return replicas, err
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestIngress(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	ingressKey := any("ingress-key")
+	podKey := any("pod-key")
+	createdKey := any("created-key")
+
+	trackTest(t)
+
+	ingressFeature := features.New("network/ingress").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var classes networkingv1.IngressClassList
+			if err := cfg.Client().Resources().List(ctx, &classes); err != nil {
+				t.Fatalf("Failed to list IngressClasses: %v", err)
+			}
+			className := defaultIngressClass(classes.Items)
+			if className == "" {
+				skipTest(t, skipReasonMissingPrerequisite, "No IngressClass found, skipping Ingress test")
+			}
+			t.Logf("Using IngressClass %s", className)
+
+			deployment := newNetworkDeployment(cfg.Namespace(), "ingress-http-test-nginx")
+			setDeploymentAppLabel(deployment, "ingress-http-test")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			service := newNetworkService(cfg.Namespace(), "ingress-http-test-service")
+			service.Spec.Selector = map[string]string{"app": "ingress-http-test"}
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			// Scope the host to the namespace, so that concurrent runs sharing a controller do not collide
+			host := "http." + cfg.Namespace() + ".e2e-tests.example.com"
+			ingress := newIngress(cfg.Namespace(), "ingress-http-test", className, host, service.Name, "")
+			if err := cfg.Client().Resources().Create(ctx, ingress); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, ingressKey, ingress)
+			ctx = context.WithValue(ctx, createdKey, time.Now())
+
+			return ctx
+		}).
+		Assess("HTTP request with the Ingress host returns 200", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			ingress := ctx.Value(ingressKey).(*networkingv1.Ingress)
+			created := ctx.Value(createdKey).(time.Time)
+			host := ingress.Spec.Rules[0].Host
+
+			address, err := waitForIngressAddress(ctx, cfg.Client().Resources(), ingress)
+			if err != nil {
+				t.Fatalf("Ingress %s was not assigned an address: %v", ingress.Name, err)
+			}
+			t.Logf("Ingress %s is served at %s", ingress.Name, address)
+
+			pod, err := curlUntilSuccess(ctx, t, cfg, "ingress-http-test-client", "http://"+address+"/", "-H", "Host: "+host)
+			ctx = context.WithValue(ctx, podKey, pod)
+			if err != nil {
+				t.Fatalf("HTTP request to %s with host %s did not return 200: %v", address, host, err)
+			}
+			metricsCollector.RecordIngressReady(ctx, *ingress.Spec.IngressClassName, time.Since(created))
+			t.Logf("✓ HTTP request to %s with host %s returned 200, %s after the Ingress was created",
+				address, host, time.Since(created).Round(time.Second))
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete client pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete client pod: %v", err)
+				}
+			}
+
+			// Delete Ingress
+			if ingress, ok := ctx.Value(ingressKey).(*networkingv1.Ingress); ok && ingress != nil {
+				if err := cfg.Client().Resources().Delete(ctx, ingress); err != nil {
+					t.Logf("Failed to delete Ingress: %v", err)
+				}
+			}
+
+			// Delete service
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, ingressFeature)
+}
+
+func TestIngressHTTPS(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	secretKey := any("secret-key")
+	ingressKey := any("ingress-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	ingressFeature := features.New("network/ingress-https").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var classes networkingv1.IngressClassList
+			if err := cfg.Client().Resources().List(ctx, &classes); err != nil {
+				t.Fatalf("Failed to list IngressClasses: %v", err)
+			}
+			className := defaultIngressClass(classes.Items)
+			if className == "" {
+				skipTest(t, skipReasonMissingPrerequisite, "No IngressClass found, skipping Ingress test")
+			}
+			t.Logf("Using IngressClass %s", className)
+
+			deployment := newNetworkDeployment(cfg.Namespace(), "ingress-https-test-nginx")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			service := newNetworkService(cfg.Namespace(), "ingress-https-test-service")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			// Scope the host to the namespace, so that concurrent runs sharing a controller do not collide
+			host := cfg.Namespace() + ".e2e-tests.example.com"
+			cert, key, err := newSelfSignedCertificate(host)
+			if err != nil {
+				t.Fatalf("Failed to generate certificate: %v", err)
+			}
+			secret := newTLSSecret(cfg.Namespace(), "ingress-https-test-tls", cert, key)
+			if err := cfg.Client().Resources().Create(ctx, secret); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, secretKey, secret)
+
+			ingress := newIngress(cfg.Namespace(), "ingress-https-test", className, host, service.Name, secret.Name)
+			if err := cfg.Client().Resources().Create(ctx, ingress); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, ingressKey, ingress)
+
+			return ctx
+		}).
+		Assess("HTTPS request through the Ingress returns 200", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			ingress := ctx.Value(ingressKey).(*networkingv1.Ingress)
+			host := ingress.Spec.Rules[0].Host
+
+			address, err := waitForIngressAddress(ctx, cfg.Client().Resources(), ingress)
+			if err != nil {
+				t.Fatalf("Ingress %s was not assigned an address: %v", ingress.Name, err)
+			}
+			t.Logf("Ingress %s is served at %s", ingress.Name, address)
+
+			// Resolve the test host to the Ingress address, the host has no DNS record
+			pod, err := curlUntilSuccess(ctx, t, cfg, "ingress-https-test-client", "https://"+host+"/",
+				"--insecure", "--connect-to", host+":443:"+address+":443")
+			ctx = context.WithValue(ctx, podKey, pod)
+			if err != nil {
+				t.Fatalf("HTTPS request to %s through Ingress %s did not return 200: %v", host, ingress.Name, err)
+			}
+			t.Logf("✓ HTTPS request to %s was terminated by the Ingress controller and returned 200", host)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete client pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete client pod: %v", err)
+				}
+			}
+
+			// Delete Ingress
+			if ingress, ok := ctx.Value(ingressKey).(*networkingv1.Ingress); ok && ingress != nil {
+				if err := cfg.Client().Resources().Delete(ctx, ingress); err != nil {
+					t.Logf("Failed to delete Ingress: %v", err)
+				}
+			}
+
+			// Delete TLS secret
+			if secret, ok := ctx.Value(secretKey).(*corev1.Secret); ok && secret != nil {
+				if err := cfg.Client().Resources().Delete(ctx, secret); err != nil {
+					t.Logf("Failed to delete secret: %v", err)
+				}
+			}
+
+			// Delete service
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, ingressFeature)
+}
+
+// defaultIngressClass returns the default IngressClass, otherwise the first one, or "" when there is none
+func defaultIngressClass(classes []networkingv1.IngressClass) string {
+	for _, class := range classes {
+		if class.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true" {
+			return class.Name
+		}
+	}
+	if len(classes) > 0 {
+		return classes[0].Name
+	}
+
+	return ""
+}
+
+// newIngress creates an Ingress routing host to a service on port 80, terminating TLS with the given
+// secret unless tlsSecretName is empty
+func newIngress(namespace, name, className, host, serviceName, tlsSecretName string) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "ingress-test"},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: &className,
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: serviceName,
+											Port: networkingv1.ServiceBackendPort{Number: 80},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if tlsSecretName != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      []string{host},
+				SecretName: tlsSecretName,
+			},
+		}
+	}
+
+	return ingress
+}
+
+// newTLSSecret creates a kubernetes.io/tls Secret from PEM encoded certificate and key
+func newTLSSecret(namespace, name, cert, key string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "ingress-test"},
+		},
+		Type: corev1.SecretTypeTLS,
+		StringData: map[string]string{
+			corev1.TLSCertKey:       cert,
+			corev1.TLSPrivateKeyKey: key,
+		},
+	}
+}
+
+// newSelfSignedCertificate returns a PEM encoded self-signed certificate for host, valid for a day, and its key
+func newSelfSignedCertificate(host string) (string, string, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-5 * time.Minute),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(cert), string(keyPEM), nil
+}
+
+// waitForIngressAddress waits for the Ingress controller to publish an address for the Ingress
+func waitForIngressAddress(ctx context.Context, client *resources.Resources, ingress *networkingv1.Ingress) (string, error) {
+	var address string
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var currentIngress networkingv1.Ingress
+		if err := client.Get(ctx, ingress.Name, ingress.Namespace, &currentIngress); err != nil {
+			return false, err
+		}
+
+		for _, lb := range currentIngress.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				address = lb.IP
+				return true, nil
+			}
+			if lb.Hostname != "" {
+				address = lb.Hostname
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+
+	return address, err
+}
+
+// curlUntilSuccess runs curl client pods against url until one gets HTTP 200, giving Ingress
+// controllers and load balancers time to program new routes. It returns the last client pod, for deletion.
+func curlUntilSuccess(ctx context.Context, t *testing.T, cfg *envconf.Config, name, url string, curlArgs ...string) (*corev1.Pod, error) {
+	t.Helper()
+
+	var pod *corev1.Pod
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if pod != nil {
+			if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+				return false, err
+			}
+			if err := waitForDeleted(ctx, cfg.Client().Resources(), pod); err != nil {
+				return false, err
+			}
+		}
+
+		pod = newCurlPod(cfg.Namespace(), name, url, curlArgs...)
+		_, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute)
+		if err == nil {
+			return true, nil
+		}
+		if logs, logErr := getPodLogs(ctx, cfg, pod, ""); logErr == nil {
+			t.Logf("Request to %s failed, retrying:\n%s", url, logs)
+		}
+		return false, nil
+	})
+
+	return pod, err
+}
+
+// newCurlPod creates a pod requesting url with curl, succeeding only when the response status is 200
+func newCurlPod(namespace, name, url string, curlArgs ...string) *corev1.Pod {
+	args := strings.Join(append(curlArgs, url), "' '")
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "curl-test"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "curl",
+					Image: "curlimages/curl:latest",
+					Command: []string{
+						"sh", "-c",
+						"code=$(curl -sS -o /dev/null -w '%{http_code}' --max-time 30 --connect-timeout 10 '" + args + "') ; " +
+							"echo \"HTTP $code\" && test \"$code\" = 200",
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65532}[0], // curl user
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestInitContainers(t *testing.T) {
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	initFeature := features.New("workloads/init-containers").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newInitContainerPod(cfg.Namespace(), "init-container-test")
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			// Run Pod to completion, the assessment reports a failed Pod
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatalf("Pod did not complete: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("main container reads init messages in order", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			assertPodSucceeded(ctx, t, cfg, pod)
+			t.Logf("✓ Main container of %s found both init messages in order", pod.Name)
+
+			return ctx
+		}).
+		Assess("init containers completed before the main container", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(currentPod.Status.InitContainerStatuses) != 2 {
+				t.Fatalf("Expected 2 init container statuses, got %d", len(currentPod.Status.InitContainerStatuses))
+			}
+			if len(currentPod.Status.ContainerStatuses) == 0 || currentPod.Status.ContainerStatuses[0].State.Terminated == nil {
+				t.Fatal("Main container not terminated")
+			}
+			mainStarted := currentPod.Status.ContainerStatuses[0].State.Terminated.StartedAt
+
+			var previousFinished metav1.Time
+			for _, status := range currentPod.Status.InitContainerStatuses {
+				terminated := status.State.Terminated
+				if terminated == nil {
+					t.Fatalf("Init container %s not terminated", status.Name)
+				}
+				if terminated.ExitCode != 0 {
+					t.Fatalf("Init container %s exited with non-zero code: %d", status.Name, terminated.ExitCode)
+				}
+				if terminated.StartedAt.Before(&previousFinished) {
+					t.Fatalf("Init container %s started at %s, before the previous init container finished at %s",
+						status.Name, terminated.StartedAt, previousFinished)
+				}
+				if mainStarted.Before(&terminated.FinishedAt) {
+					t.Fatalf("Main container started at %s, before init container %s finished at %s",
+						mainStarted, status.Name, terminated.FinishedAt)
+				}
+				previousFinished = terminated.FinishedAt
+				t.Logf("✓ Init container %s exited 0 at %s", status.Name, terminated.FinishedAt)
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod
+			if pod := ctx.Value(podKey).(*corev1.Pod); pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, initFeature)
+}
+
+// newInitContainerPod creates a pod whose two init containers append ordered messages to a shared
+// emptyDir, and whose main container fails unless both messages are present in order
+func newInitContainerPod(namespace, name string) *corev1.Pod {
+	securityContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &[]bool{false}[0],
+		RunAsNonRoot:             &[]bool{true}[0],
+		RunAsUser:                &[]int64{65534}[0],
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "shared",
+			MountPath: "/shared",
+		},
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "init-container-test"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			InitContainers: []corev1.Container{
+				{
+					Name:            "init-first",
+					Image:           "alpine:latest",
+					Command:         []string{"sh", "-c", "echo 'first' >> /shared/messages"},
+					SecurityContext: securityContext,
+					VolumeMounts:    volumeMounts,
+				},
+				{
+					Name:            "init-second",
+					Image:           "alpine:latest",
+					Command:         []string{"sh", "-c", "echo 'second' >> /shared/messages"},
+					SecurityContext: securityContext,
+					VolumeMounts:    volumeMounts,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "main",
+					Image: "alpine:latest",
+					Command: []string{
+						"sh", "-c",
+						"cat /shared/messages && " +
+							"test \"$(cat /shared/messages)\" = \"$(printf 'first\\nsecond')\"",
+					},
+					SecurityContext: securityContext,
+					VolumeMounts:    volumeMounts,
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "shared",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestMultiNamespaceIsolation(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	policyKey := any("policy-key")
+	clientNamespaceKey := any("client-namespace-key")
+
+	trackTest(t)
+
+	isolationFeature := features.New("network/multi-namespace-isolation").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			available, err := apiResourceAvailable(cfg, networkingv1.SchemeGroupVersion.String(), "networkpolicies")
+			if err != nil {
+				t.Fatalf("Failed to discover networking.k8s.io API: %v", err)
+			}
+			if !available {
+				skipTest(t, skipReasonAPIUnavailable, "networking.k8s.io/v1 NetworkPolicy API not available, skipping")
+			}
+
+			// Namespace B holds the client, the test namespace A the service
+			clientNamespace := names.randomName("isolation-client", 24)
+			ctx, err = createAuxNamespace(ctx, cfg, clientNamespace)
+			if err != nil {
+				t.Fatalf("Failed to create namespace %s: %v", clientNamespace, err)
+			}
+			ctx = context.WithValue(ctx, clientNamespaceKey, clientNamespace)
+
+			deployment := newNetworkDeployment(cfg.Namespace(), "isolation-nginx")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			service := newNetworkService(cfg.Namespace(), "isolation-service")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			// Only pods of the same namespace may reach the service, no policy admits other namespaces
+			policy := newSameNamespaceIngressPolicy(cfg.Namespace(), "isolation-same-namespace")
+			if err := cfg.Client().Resources().Create(ctx, policy); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, policyKey, policy)
+
+			return ctx
+		}).
+		Assess("same-namespace client reaches the service", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			clientPod := newClientPod(cfg.Namespace(), "isolation-client-local", service.Name)
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatal(err)
+			}
+
+			if podFailedAsExpected(ctx, cfg.Client().Resources(), clientPod) {
+				t.Fatalf("Client pod could not reach service %s from its own namespace", service.Name)
+			}
+			t.Logf("✓ Client pod reached service %s from namespace %s", service.Name, cfg.Namespace())
+
+			if err := cfg.Client().Resources().Delete(ctx, clientPod); err != nil {
+				t.Logf("Failed to delete client pod: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("client in another namespace is denied", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			clientNamespace := ctx.Value(clientNamespaceKey).(string)
+
+			clientPod := newClientPod(clientNamespace, "isolation-client-remote", service.Name+"."+service.Namespace)
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatal(err)
+			}
+
+			if !podFailedAsExpected(ctx, cfg.Client().Resources(), clientPod) {
+				t.Fatalf("Client pod in namespace %s reached service %s/%s although no policy allows cross-namespace traffic",
+					clientNamespace, service.Namespace, service.Name)
+			}
+			t.Logf("✓ Client pod in namespace %s denied access to service %s/%s", clientNamespace, service.Namespace, service.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if policy, ok := ctx.Value(policyKey).(*networkingv1.NetworkPolicy); ok && policy != nil {
+				if err := cfg.Client().Resources().Delete(ctx, policy); err != nil {
+					t.Logf("Failed to delete network policy %s: %v", policy.Name, err)
+				}
+			}
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).
+		WithTeardown("delete client namespace", deleteAuxNamespaces).
+		Feature()
+
+	testenv.Test(t, isolationFeature)
+}
+
+// newSameNamespaceIngressPolicy creates a NetworkPolicy admitting ingress to every pod of the
+// namespace from pods of the same namespace only
+func newSameNamespaceIngressPolicy(namespace, name string) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "network-test"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{
+							PodSelector: &metav1.LabelSelector{},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestJobCompletion(t *testing.T) {
+	successJobKey := any("success-job-key")
+	failingJobKey := any("failing-job-key")
+
+	trackTest(t)
+
+	jobFeature := features.New("batchv1/job").
+		Assess("job completes all completions", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			job := newJob(cfg.Namespace(), "job-test-success", "echo 'Job completed successfully'", 3, 2, 6)
+			created := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, successJobKey, job)
+
+			currentJob, err := waitForJobComplete(ctx, cfg.Client().Resources(), job)
+			if err != nil {
+				t.Fatalf("Job did not complete: %v", err)
+			}
+			metricsCollector.RecordJobCompletion(ctx, job.Name, "complete", time.Since(created))
+
+			if currentJob.Status.Succeeded != 3 {
+				t.Fatalf("Expected 3 succeeded pods, got %d", currentJob.Status.Succeeded)
+			}
+			t.Logf("✓ Job %s completed %d pods in %s", job.Name, currentJob.Status.Succeeded, time.Since(created).Round(time.Second))
+
+			return ctx
+		}).
+		Assess("failing job exhausts backoff limit", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			backoffLimit := int32(2)
+			job := newJob(cfg.Namespace(), "job-test-failing", "echo 'Failing on purpose' && exit 1", 1, 1, backoffLimit)
+			created := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, failingJobKey, job)
+
+			currentJob, err := waitForJobComplete(ctx, cfg.Client().Resources(), job)
+			if err != nil {
+				t.Fatalf("Job did not reach a final state: %v", err)
+			}
+			metricsCollector.RecordJobCompletion(ctx, job.Name, "failed", time.Since(created))
+
+			if !jobHasCondition(currentJob, batchv1.JobFailed) {
+				t.Fatalf("Expected Job %s to fail, conditions: %v", job.Name, currentJob.Status.Conditions)
+			}
+			if currentJob.Status.Failed != backoffLimit+1 {
+				t.Fatalf("Expected %d failed pods (backoffLimit+1), got %d", backoffLimit+1, currentJob.Status.Failed)
+			}
+			t.Logf("✓ Job %s failed after %d attempts", job.Name, currentJob.Status.Failed)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete jobs along with their pods
+			for _, key := range []any{successJobKey, failingJobKey} {
+				if job, ok := ctx.Value(key).(*batchv1.Job); ok && job != nil {
+					if err := cfg.Client().Resources().Delete(ctx, job, resources.WithDeletePropagation(string(metav1.DeletePropagationBackground))); err != nil {
+						t.Logf("Failed to delete Job %s: %v", job.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, jobFeature)
+}
+
+func TestJobBackoffLimitPerIndex(t *testing.T) {
+	jobKey := any("job-key")
+	failingIndex := 2
+	backoffLimitPerIndex := int32(2)
+
+	trackTest(t)
+
+	perIndexFeature := features.New("batchv1/job-backoff-limit-per-index").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			supported, err := serverVersionAtLeast(cfg, 1, 29)
+			if err != nil {
+				t.Fatalf("Failed to get server version: %v", err)
+			}
+			if !supported {
+				skipTest(t, skipReasonUnsupportedVersion, "BackoffLimitPerIndex requires Kubernetes 1.29 or later, skipping")
+			}
+
+			job := newJob(cfg.Namespace(), "job-test-per-index",
+				fmt.Sprintf("echo \"Running index $JOB_COMPLETION_INDEX\" && test \"$JOB_COMPLETION_INDEX\" != '%d'", failingIndex), 5, 5, 0)
+			completionMode := batchv1.IndexedCompletion
+			job.Spec.CompletionMode = &completionMode
+			job.Spec.BackoffLimit = nil
+			job.Spec.BackoffLimitPerIndex = &backoffLimitPerIndex
+			if err := cfg.Client().Resources().Create(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, jobKey, job)
+
+			// The field is dropped when the JobBackoffLimitPerIndex feature gate is disabled
+			if job.Spec.BackoffLimitPerIndex == nil {
+				skipTest(t, skipReasonAPIUnavailable, "BackoffLimitPerIndex is disabled on the API server, skipping")
+			}
+
+			return ctx
+		}).
+		Assess("failing index exhausts its own backoff limit", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			job := ctx.Value(jobKey).(*batchv1.Job)
+
+			currentJob, err := waitForJobComplete(ctx, cfg.Client().Resources(), job)
+			if err != nil {
+				t.Fatalf("Job did not reach a final state: %v", err)
+			}
+
+			// A Job with failed indexes is marked Failed once every other index has completed
+			if !jobHasCondition(currentJob, batchv1.JobFailed) {
+				t.Fatalf("Expected Job %s to fail with failed indexes, conditions: %v", job.Name, currentJob.Status.Conditions)
+			}
+
+			failedIndexes := parseFailedIndexes(currentJob.Status)
+			if !slices.Equal(failedIndexes, []int{failingIndex}) {
+				t.Fatalf("Expected failed indexes [%d], got %v", failingIndex, failedIndexes)
+			}
+			if currentJob.Status.Failed != backoffLimitPerIndex+1 {
+				t.Fatalf("Expected %d failed pods for index %d (backoffLimitPerIndex+1), got %d",
+					backoffLimitPerIndex+1, failingIndex, currentJob.Status.Failed)
+			}
+			t.Logf("✓ Index %d failed after %d attempts", failingIndex, currentJob.Status.Failed)
+
+			return ctx
+		}).
+		Assess("other indexes succeed", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			job := ctx.Value(jobKey).(*batchv1.Job)
+
+			var currentJob batchv1.Job
+			if err := cfg.Client().Resources().Get(ctx, job.Name, job.Namespace, &currentJob); err != nil {
+				t.Fatal(err)
+			}
+
+			completedIndexes := parseIndexes(currentJob.Status.CompletedIndexes)
+			if expected := []int{0, 1, 3, 4}; !slices.Equal(completedIndexes, expected) {
+				t.Fatalf("Expected completed indexes %v, got %v (%q)", expected, completedIndexes, currentJob.Status.CompletedIndexes)
+			}
+			t.Logf("✓ Indexes %s completed despite index %d failing", currentJob.Status.CompletedIndexes, failingIndex)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Job along with its pods
+			if job, ok := ctx.Value(jobKey).(*batchv1.Job); ok && job != nil {
+				if err := cfg.Client().Resources().Delete(ctx, job, resources.WithDeletePropagation(string(metav1.DeletePropagationBackground))); err != nil {
+					t.Logf("Failed to delete Job %s: %v", job.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, perIndexFeature)
+}
+
+// newJob creates a Job running a shell command
+func newJob(namespace, name, command string, completions, parallelism, backoffLimit int32) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "job-test"},
+		},
+		Spec: batchv1.JobSpec{
+			Completions:  &completions,
+			Parallelism:  &parallelism,
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "job-test"},
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					SecurityContext: &corev1.PodSecurityContext{
+						RunAsNonRoot: &[]bool{true}[0],
+						RunAsUser:    &[]int64{65534}[0], // nobody user
+						FSGroup:      &[]int64{65534}[0],
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+					Containers: []corev1.Container{
+						{
+							Name:    "job",
+							Image:   "alpine:latest",
+							Command: []string{"sh", "-c", command},
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: &[]bool{false}[0],
+								RunAsNonRoot:             &[]bool{true}[0],
+								RunAsUser:                &[]int64{65534}[0],
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+								SeccompProfile: &corev1.SeccompProfile{
+									Type: corev1.SeccompProfileTypeRuntimeDefault,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// waitForJobComplete waits for a Job to reach the Complete or Failed condition and returns it
+func waitForJobComplete(ctx context.Context, client *resources.Resources, job *batchv1.Job) (*batchv1.Job, error) {
+	var currentJob batchv1.Job
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := client.Get(ctx, job.Name, job.Namespace, &currentJob); err != nil {
+			return false, err
+		}
+
+		return jobHasCondition(&currentJob, batchv1.JobComplete) || jobHasCondition(&currentJob, batchv1.JobFailed), nil
+	})
+
+	return &currentJob, err
+}
+
+// jobHasCondition reports whether a Job carries the given condition with status True
+func jobHasCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// parseFailedIndexes returns the indexes listed in the failedIndexes status of an indexed Job
+func parseFailedIndexes(status batchv1.JobStatus) []int {
+	if status.FailedIndexes == nil {
+		return nil
+	}
+
+	return parseIndexes(*status.FailedIndexes)
+}
+
+// parseIndexes expands a Job index list such as "1,3-5" into its indexes, ignoring malformed entries
+func parseIndexes(list string) []int {
+	var indexes []int
+	for _, interval := range strings.Split(list, ",") {
+		if interval == "" {
+			continue
+		}
+
+		first, last, isRange := strings.Cut(interval, "-")
+		low, err := strconv.Atoi(first)
+		if err != nil {
+			continue
+		}
+		high := low
+		if isRange {
+			if high, err = strconv.Atoi(last); err != nil {
+				continue
+			}
+		}
+		for index := low; index <= high; index++ {
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes
+}
+
+func TestParseFailedIndexes(t *testing.T) {
+	unitTest(t)
+
+	tests := []struct {
+		name     string
+		status   batchv1.JobStatus
+		expected []int
+	}{
+		{
+			name:     "unset",
+			status:   batchv1.JobStatus{},
+			expected: nil,
+		},
+		{
+			name:     "single index",
+			status:   batchv1.JobStatus{FailedIndexes: &[]string{"2"}[0]},
+			expected: []int{2},
+		},
+		{
+			name:     "indexes and ranges",
+			status:   batchv1.JobStatus{FailedIndexes: &[]string{"0,3-5,7"}[0]},
+			expected: []int{0, 3, 4, 5, 7},
+		},
+		{
+			name:     "malformed entries",
+			status:   batchv1.JobStatus{FailedIndexes: &[]string{"1,x,4-y"}[0]},
+			expected: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseFailedIndexes(tt.status); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
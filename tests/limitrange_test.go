@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestLimitRange(t *testing.T) {
+	limitRangeKey := any("limitrange-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	limitRangeFeature := features.New("policy/limitrange").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			limitRange := newLimitRange(cfg.Namespace(), "limitrange-test", "100m", "200m", "500m")
+			if err := cfg.Client().Resources().Create(ctx, limitRange); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, limitRangeKey, limitRange)
+
+			return ctx
+		}).
+		Assess("defaults injected into pod", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newSchedulingPod(cfg.Namespace(), "limitrange-test-defaults")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+
+			resources := currentPod.Spec.Containers[0].Resources
+			cpuRequest := resources.Requests[corev1.ResourceCPU]
+			if cpuRequest.IsZero() {
+				t.Fatal("Expected the LimitRange to inject a default CPU request, found none")
+			}
+			if cpuRequest.Cmp(resource.MustParse("100m")) != 0 {
+				t.Fatalf("Expected default CPU request 100m, got %s", cpuRequest.String())
+			}
+			cpuLimit := resources.Limits[corev1.ResourceCPU]
+			if cpuLimit.Cmp(resource.MustParse("200m")) != 0 {
+				t.Fatalf("Expected default CPU limit 200m, got %s", cpuLimit.String())
+			}
+			t.Logf("✓ LimitRange injected CPU request %s and limit %s", cpuRequest.String(), cpuLimit.String())
+
+			return ctx
+		}).
+		Assess("pod above max rejected", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newSchedulingPod(cfg.Namespace(), "limitrange-test-above-max")
+			pod.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}
+
+			err := cfg.Client().Resources().Create(ctx, pod)
+			if err == nil {
+				_ = cfg.Client().Resources().Delete(ctx, pod)
+				t.Fatal("Pod exceeding the LimitRange max was admitted")
+			}
+
+			// The LimitRanger admission plugin reports violations as forbidden, validation as invalid
+			if !apierrors.IsInvalid(err) && !apierrors.IsForbidden(err) {
+				t.Fatalf("Pod exceeding the LimitRange max was rejected with an unexpected error: %v", err)
+			}
+			t.Logf("✓ Pod exceeding the LimitRange max rejected: %v", err)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete pod: %v", err)
+				}
+			}
+
+			// Delete LimitRange
+			if limitRange := ctx.Value(limitRangeKey).(*corev1.LimitRange); limitRange != nil {
+				if err := cfg.Client().Resources().Delete(ctx, limitRange); err != nil {
+					t.Logf("Failed to delete LimitRange: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, limitRangeFeature)
+}
+
+// newLimitRange creates a LimitRange with container CPU defaults and a maximum
+func newLimitRange(namespace, name, defaultRequest, defaultLimit, max string) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "limitrange-test"},
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type: corev1.LimitTypeContainer,
+					DefaultRequest: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse(defaultRequest),
+					},
+					Default: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse(defaultLimit),
+					},
+					Max: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse(max),
+					},
+				},
+			},
+		},
+	}
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"log/slog"
+	"math/rand/v2"
 	"os"
-	"runtime/debug"
+	"strconv"
 	"testing"
+	"time"
 
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/azure"
 	"sigs.k8s.io/e2e-framework/klient/conf"
 	"sigs.k8s.io/e2e-framework/pkg/env"
@@ -21,19 +26,44 @@ var (
 	metricsCollector *metrics.Collector
 	metricsShutdown  func(context.Context) error
 	testContext      context.Context
+	names            *nameGenerator
+
+	metricsConfigPath = flag.String("metrics-config", "", "YAML or JSON metrics pipeline config file, overridden by the OTEL_* environment variables")
 )
 
 func TestMain(m *testing.M) {
 	var exitCode int
 
+	// Log as text, or as JSON for CI log aggregation
+	metrics.SetupLogging()
+
+	// Short mode only runs the unit tests, which need neither a cluster nor a metrics pipeline
+	flag.Parse()
+	if testing.Short() {
+		var err error
+		if metricsCollector, err = metrics.NewCollector(); err != nil {
+			slog.Error("failed to create metrics collector", "stage", "setup", "error", err)
+			os.Exit(1)
+		}
+		names = newNameGenerator(rand.Uint64())
+		os.Exit(m.Run())
+	}
+
 	// Log build information
 	logBuildInfo()
 
-	// Initialize metrics
+	// Initialize metrics, from the --metrics-config file when given
 	config := metrics.NewConfigFromEnv()
+	if *metricsConfigPath != "" {
+		var err error
+		if config, err = metrics.ReadFromFile(*metricsConfigPath); err != nil {
+			slog.Error("failed to read metrics config", "stage", "setup", "error", err)
+			os.Exit(1)
+		}
+	}
 	shutdown, err := metrics.SetupMetrics(config)
 	if err != nil {
-		log.Printf("Failed to setup metrics: %v", err)
+		slog.Error("failed to setup metrics", "stage", "setup", "error", err)
 		os.Exit(1)
 	}
 	metricsShutdown = shutdown
@@ -41,18 +71,28 @@ func TestMain(m *testing.M) {
 	// Initialize metrics collector
 	metricsCollector, err = metrics.NewCollector()
 	if err != nil {
-		log.Printf("Failed to create metrics collector: %v", err)
+		slog.Error("failed to create metrics collector", "stage", "setup", "error", err)
 		os.Exit(1)
 	}
 
+	// Seed the resource name generator, logging the seed so that the run can be replayed
+	seed, err := randomSeed()
+	if err != nil {
+		slog.Error("invalid E2E_RANDOM_SEED", "stage", "setup", "error", err)
+		os.Exit(1)
+	}
+	names = newNameGenerator(seed)
+	slog.Info("seeded random resource names, set E2E_RANDOM_SEED to replay them", "stage", "setup", "seed", seed)
+
 	// Setup test environment
 	testenv = env.New()
 	path := conf.ResolveKubeConfigFile()
 	cfg := envconf.NewWithKubeConfig(path)
 	testenv = env.NewWithConfig(cfg)
-	namespace := envconf.RandomName("sample-ns", 16)
+	namespace := names.randomName("sample-ns", 16)
 	testenv.Setup(
 		envfuncs.CreateNamespace(namespace),
+		bindPodExec(namespace),
 	)
 	testenv.Finish(
 		envfuncs.DeleteNamespace(namespace),
@@ -61,53 +101,113 @@ func TestMain(m *testing.M) {
 	// Run tests
 	exitCode = testenv.Run(m)
 
+	// Print a human-readable run summary for CI logs
+	slog.Info("=== E2E Tests Summary ===", "stage", "summary")
+	metricsCollector.PrintSummary(os.Stdout)
+
 	// Shutdown metrics pipeline
 	if metricsShutdown != nil {
 		ctx := context.Background()
 		if err := metricsShutdown(ctx); err != nil {
-			log.Printf("Failed to shutdown metrics: %v", err)
+			slog.Error("failed to shutdown metrics", "stage", "shutdown", "error", err)
 		}
 	}
 
 	os.Exit(exitCode)
 }
 
-// logBuildInfo logs version and build information using debug.ReadBuildInfo()
+// bindPodExec grants the e2e-tests ServiceAccount pods/exec in the test namespace only, through a
+// RoleBinding to the e2e-tests-pod-exec ClusterRole. A failure is logged rather than aborting the
+// run, since only the tests running commands in pods need the grant.
+func bindPodExec(namespace string) env.Func {
+	return func(ctx context.Context, cfg *envconf.Config) (context.Context, error) {
+		binding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "e2e-tests-pod-exec",
+				Namespace: namespace,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     "e2e-tests-pod-exec",
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      "e2e-tests",
+					Namespace: "e2e-tests",
+				},
+			},
+		}
+		if err := cfg.Client().Resources().Create(ctx, binding); err != nil {
+			slog.Warn("failed to grant pods/exec in the test namespace", "stage", "setup", "namespace", namespace, "error", err)
+		}
+
+		return ctx, nil
+	}
+}
+
+// logBuildInfo logs version and build information using metrics.ReadBuildInfo()
 func logBuildInfo() {
-	log.Printf("=== E2E Tests Starting ===")
+	buildInfo, ok := metrics.ReadBuildInfo()
+	if !ok {
+		slog.Info("=== E2E Tests Starting ===", "stage", "startup", "build_info", "not available")
+		return
+	}
 
-	if buildInfo, ok := debug.ReadBuildInfo(); ok {
-		log.Printf("Go version: %s", buildInfo.GoVersion)
-		log.Printf("Module path: %s", buildInfo.Main.Path)
-		if buildInfo.Main.Version != "(devel)" {
-			log.Printf("Module version: %s", buildInfo.Main.Version)
-		}
+	fields := []any{
+		"stage", "startup",
+		"go_version", buildInfo.GoVersion,
+		"module_path", buildInfo.ModulePath,
+	}
+	if buildInfo.ModuleVersion != "(devel)" {
+		fields = append(fields, "module_version", buildInfo.ModuleVersion)
+	}
+	if buildInfo.Revision != "" {
+		fields = append(fields, "git_revision", buildInfo.Revision)
+	}
+	if buildInfo.Time != "" {
+		fields = append(fields, "git_time", buildInfo.Time)
+	}
+	if buildInfo.Modified {
+		// Uncommitted changes
+		fields = append(fields, "modified", true)
+	}
+	slog.Info("=== E2E Tests Starting ===", fields...)
+}
 
-		// Extract VCS information from build settings
-		var revision, time, modified string
-		for _, setting := range buildInfo.Settings {
-			switch setting.Key {
-			case "vcs.revision":
-				revision = setting.Value
-			case "vcs.time":
-				time = setting.Value
-			case "vcs.modified":
-				modified = setting.Value
-			}
-		}
+// randomSeed returns the seed set in E2E_RANDOM_SEED, or a random one when unset
+func randomSeed() (uint64, error) {
+	if value := os.Getenv("E2E_RANDOM_SEED"); value != "" {
+		return strconv.ParseUint(value, 10, 64)
+	}
+	return rand.Uint64(), nil
+}
 
-		if revision != "" {
-			log.Printf("Git revision: %s", revision)
-		}
-		if time != "" {
-			log.Printf("Git time: %s", time)
-		}
-		if modified == "true" {
-			log.Printf("Modified: true (uncommitted changes)")
+// getEnv returns the value of an environment variable or a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvDuration parses a duration from an environment variable, falling back to a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
 		}
-	} else {
-		log.Printf("Build info not available")
 	}
+	return defaultValue
+}
 
-	log.Printf("========================")
+// getEnvFloat parses a float from an environment variable, falling back to a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
 }
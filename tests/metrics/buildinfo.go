@@ -0,0 +1,41 @@
+package metrics
+
+import "runtime/debug"
+
+// BuildInfo holds the version and VCS information embedded in the test binary
+type BuildInfo struct {
+	GoVersion     string
+	ModulePath    string
+	ModuleVersion string
+	Revision      string
+	Time          string
+	Modified      bool
+}
+
+// ReadBuildInfo extracts build information from the running binary, reporting false when it is not available
+func ReadBuildInfo() (BuildInfo, bool) {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{}, false
+	}
+
+	info := BuildInfo{
+		GoVersion:     buildInfo.GoVersion,
+		ModulePath:    buildInfo.Main.Path,
+		ModuleVersion: buildInfo.Main.Version,
+	}
+
+	// Extract VCS information from build settings
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info, true
+}
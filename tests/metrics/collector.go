@@ -3,8 +3,13 @@ package metrics
 import (
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"text/tabwriter"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -12,19 +17,61 @@ import (
 	"go.opentelemetry.io/otel/metric"
 )
 
-var meter = otel.Meter("e2e-tests")
+// Test statuses reported in the run summary
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// SkipReasonUnspecified is the skip_reason of tests skipped without registering a reason
+const SkipReasonUnspecified = "unspecified"
+
+// FailureStageKey is the attribute recording the feature stage a failed test failed in
+const FailureStageKey = "failure_stage"
+
+// TestResult is the outcome of a single test, retained for the run summary
+type TestResult struct {
+	Name     string
+	Status   string
+	Duration time.Duration
+}
 
 // Collector handles all metrics collection for e2e tests
 type Collector struct {
 	testDuration metric.Float64Histogram
 	testExecuted metric.Int64Counter
 	testErrors   metric.Int64Counter
+	testSkipped  metric.Int64Counter
+	nodeReserved metric.Float64Gauge
+	jobDuration  metric.Float64Histogram
+	cpuThrottle  metric.Float64Gauge
+	storageRatio metric.Float64Gauge
+	ingressReady metric.Float64Histogram
+	cidrViolate  metric.Int64Counter
+	lossRTT      metric.Float64Histogram
+	testRetries  metric.Int64Counter
+	svcPropagate metric.Float64Histogram
+	resCreation  metric.Float64Histogram
+	activeTests  metric.Float64UpDownCounter
 	initialized  bool
+
+	resultsMu sync.Mutex
+	results   []TestResult
+
+	skipMu      sync.Mutex
+	skipReasons map[string]string
 }
 
-// NewCollector creates a new metrics collector
+// NewCollector creates a new metrics collector on the global meter provider
 func NewCollector() (*Collector, error) {
-	c := &Collector{}
+	return NewCollectorForProvider(otel.GetMeterProvider())
+}
+
+// NewCollectorForProvider creates a new metrics collector on the given meter provider
+func NewCollectorForProvider(provider metric.MeterProvider) (*Collector, error) {
+	c := &Collector{skipReasons: make(map[string]string)}
+	meter := provider.Meter("e2e-tests")
 
 	var err error
 
@@ -56,32 +103,398 @@ func NewCollector() (*Collector, error) {
 		return nil, fmt.Errorf("failed to create test_errors_total counter: %w", err)
 	}
 
+	// Create test skipped counter
+	c.testSkipped, err = meter.Int64Counter(
+		"test_skipped_total",
+		metric.WithDescription("Total number of tests skipped"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create test_skipped_total counter: %w", err)
+	}
+
+	// Create node reservation gauge
+	c.nodeReserved, err = meter.Float64Gauge(
+		"node_resource_reserved_percent",
+		metric.WithDescription("Percentage of node capacity reserved for system and kube daemons"),
+		metric.WithUnit("%"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node_resource_reserved_percent gauge: %w", err)
+	}
+
+	// Create job completion histogram
+	c.jobDuration, err = meter.Float64Histogram(
+		"job_completion_seconds",
+		metric.WithDescription("Time for a test Job to reach its final state in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job_completion_seconds histogram: %w", err)
+	}
+
+	// Create CPU throttle ratio gauge
+	c.cpuThrottle, err = meter.Float64Gauge(
+		"cpu_throttle_ratio",
+		metric.WithDescription("Share of a container's runnable CPU time spent throttled by its CFS quota"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cpu_throttle_ratio gauge: %w", err)
+	}
+
+	// Create storage throughput regression gauge
+	c.storageRatio, err = meter.Float64Gauge(
+		"storage_throughput_regression_ratio",
+		metric.WithDescription("Measured storage write throughput relative to the stored baseline"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage_throughput_regression_ratio gauge: %w", err)
+	}
+
+	// Create ingress readiness histogram
+	c.ingressReady, err = meter.Float64Histogram(
+		"ingress_ready_seconds",
+		metric.WithDescription("Time from Ingress creation to the first successful request through it in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ingress_ready_seconds histogram: %w", err)
+	}
+
+	// Create pod CIDR violation counter
+	c.cidrViolate, err = meter.Int64Counter(
+		"pod_cidr_violation_count",
+		metric.WithDescription("Number of running pod IPs found outside the cluster pod CIDR"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pod_cidr_violation_count counter: %w", err)
+	}
+
+	// Create network round-trip under packet loss histogram
+	c.lossRTT, err = meter.Float64Histogram(
+		"network_rtt_with_loss_seconds",
+		metric.WithDescription("Duration of HTTP requests, retries included, sent over a link with simulated packet loss in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network_rtt_with_loss_seconds histogram: %w", err)
+	}
+
+	// Create test retries counter
+	c.testRetries, err = meter.Int64Counter(
+		"test_retries_total",
+		metric.WithDescription("Total number of operations retried by tests after a transient failure"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create test_retries_total counter: %w", err)
+	}
+
+	// Create service update propagation histogram
+	c.svcPropagate, err = meter.Float64Histogram(
+		"service_update_propagation_seconds",
+		metric.WithDescription("Time from a Service selector update to the first request routed to the new backends in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service_update_propagation_seconds histogram: %w", err)
+	}
+
+	// Create resource creation histogram
+	c.resCreation, err = meter.Float64Histogram(
+		"resource_creation_seconds",
+		metric.WithDescription("Time for a Kubernetes object created by a test to reach its desired state in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource_creation_seconds histogram: %w", err)
+	}
+
+	// Create active tests counter
+	c.activeTests, err = meter.Float64UpDownCounter(
+		"active_tests",
+		metric.WithDescription("Number of tests currently running"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create active_tests counter: %w", err)
+	}
+
 	c.initialized = true
-	log.Println("Metrics collector initialized successfully")
+	slog.Info("metrics collector initialized")
 	return c, nil
 }
 
-// RecordTestExecution records metrics for a test execution
-func (c *Collector) RecordTestExecution(ctx context.Context, t *testing.T, duration time.Duration) {
+// IncrementActiveTests counts a test as running, until DecrementActiveTests is called for it
+func (c *Collector) IncrementActiveTests(ctx context.Context, testName string) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping active tests metric", "test_name", testName)
+		return
+	}
+
+	c.activeTests.Add(ctx, 1, metric.WithAttributes(attribute.String("test_name", testName)))
+}
+
+// DecrementActiveTests stops counting a test as running
+func (c *Collector) DecrementActiveTests(ctx context.Context, testName string) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping active tests metric", "test_name", testName)
+		return
+	}
+
+	c.activeTests.Add(ctx, -1, metric.WithAttributes(attribute.String("test_name", testName)))
+}
+
+// RecordTestExecution records metrics for a test execution. Extra attributes, such as node_count,
+// are recorded as separate attributes next to test_name. A FailureStageKey attribute is only
+// recorded on test_errors_total, so that it does not split the execution and duration series.
+func (c *Collector) RecordTestExecution(ctx context.Context, t *testing.T, duration time.Duration, extraAttrs ...attribute.KeyValue) {
 	testName := t.Name()
 
+	// A test skipped from one of its steps, which run as subtests, is not marked as skipped itself
+	// but has a registered reason
+	reason, registered := c.skipReason(testName)
+	skipped := !t.Failed() && (t.Skipped() || registered)
+
+	// Retain the result for the run summary, even when metrics are not exported
+	status := StatusPassed
+	switch {
+	case t.Failed():
+		status = StatusFailed
+	case skipped:
+		status = StatusSkipped
+	}
+	c.resultsMu.Lock()
+	c.results = append(c.results, TestResult{Name: testName, Status: status, Duration: duration})
+	c.resultsMu.Unlock()
+
 	if !c.initialized {
-		log.Printf("Warning: metrics collector not initialized, skipping metrics for test %s", testName)
+		slog.Warn("metrics collector not initialized, skipping test metrics", "test_name", testName)
 		return
 	}
 
-	attrs := []attribute.KeyValue{
-		attribute.String("test_name", testName),
+	attrs := []attribute.KeyValue{attribute.String("test_name", testName)}
+	var errorAttrs []attribute.KeyValue
+	for _, attr := range extraAttrs {
+		if attr.Key == FailureStageKey {
+			errorAttrs = append(errorAttrs, attr)
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	errorAttrs = append(slices.Clone(attrs), errorAttrs...)
+
+	// Skipped tests are counted apart, with the reason registered through SetSkipReason
+	if skipped {
+		c.recordSkipped(ctx, testName, reason, attrs)
+		return
 	}
 
 	c.testExecuted.Add(ctx, 1, metric.WithAttributes(attrs...))
 	c.testDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
 
 	if t.Failed() {
-		c.testErrors.Add(ctx, 1, metric.WithAttributes(attrs...))
-		log.Printf("Recorded test error for %s", testName)
+		c.testErrors.Add(ctx, 1, metric.WithAttributes(errorAttrs...))
+		slog.Info("recorded test error", logAttrs(errorAttrs)...)
 	}
 
-	log.Printf("Recorded metrics for test %s: duration=%.3fs", testName, duration.Seconds())
+	slog.Info("recorded test metrics", append(logAttrs(errorAttrs), slog.Float64("duration", duration.Seconds()))...)
 }
 
+// RecordTestSkipped records a skipped test along with the reason it was skipped for
+func (c *Collector) RecordTestSkipped(ctx context.Context, t *testing.T, reason string) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping test metrics", "test_name", t.Name())
+		return
+	}
+
+	c.recordSkipped(ctx, t.Name(), reason, []attribute.KeyValue{attribute.String("test_name", t.Name())})
+}
+
+func (c *Collector) recordSkipped(ctx context.Context, testName, reason string, attrs []attribute.KeyValue) {
+	attrs = append(attrs, attribute.String("skip_reason", reason))
+	c.testSkipped.Add(ctx, 1, metric.WithAttributes(attrs...))
+	slog.Info("recorded skipped test", logAttrs(attrs)...)
+}
+
+// SetSkipReason registers why a test is being skipped, since testing.T does not expose its skip
+// message. The reason is registered under the top-level test, so that a skip from a subtest marks
+// the whole test as skipped, and RecordTestExecution reports it once the test is done. It should
+// be a short code out of a fixed set, as every distinct reason creates a new series.
+func (c *Collector) SetSkipReason(t *testing.T, reason string) {
+	c.skipMu.Lock()
+	defer c.skipMu.Unlock()
+	c.skipReasons[topLevelTestName(t.Name())] = reason
+}
+
+// skipReason returns the reason registered for a test or any of its subtests, reporting whether
+// one was registered, or SkipReasonUnspecified
+func (c *Collector) skipReason(testName string) (string, bool) {
+	c.skipMu.Lock()
+	defer c.skipMu.Unlock()
+	if reason, ok := c.skipReasons[topLevelTestName(testName)]; ok {
+		return reason, true
+	}
+
+	return SkipReasonUnspecified, false
+}
+
+// topLevelTestName strips the subtest names from a test name
+func topLevelTestName(testName string) string {
+	name, _, _ := strings.Cut(testName, "/")
+	return name
+}
+
+// RecordNodeReservation records the share of a node resource that is not allocatable to pods
+func (c *Collector) RecordNodeReservation(ctx context.Context, node, resource string, percent float64) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping reservation metric", "node", node)
+		return
+	}
+
+	c.nodeReserved.Record(ctx, percent, metric.WithAttributes(
+		attribute.String("node", node),
+		attribute.String("resource", resource),
+	))
+}
+
+// RecordJobCompletion records how long a test Job took to complete or fail
+func (c *Collector) RecordJobCompletion(ctx context.Context, jobName, outcome string, duration time.Duration) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping completion metric", "job", jobName)
+		return
+	}
+
+	c.jobDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("job_name", jobName),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// RecordCPUThrottleRatio records the CFS throttling ratio observed for a test container
+func (c *Collector) RecordCPUThrottleRatio(ctx context.Context, pod, container string, ratio float64) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping throttle metric", "pod", pod)
+		return
+	}
+
+	c.cpuThrottle.Record(ctx, ratio, metric.WithAttributes(
+		attribute.String("pod", pod),
+		attribute.String("container", container),
+	))
+}
+
+// RecordStorageThroughputRatio records the measured write throughput relative to the baseline
+func (c *Collector) RecordStorageThroughputRatio(ctx context.Context, storageClass string, ratio float64) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping throughput metric", "storage_class", storageClass)
+		return
+	}
+
+	c.storageRatio.Record(ctx, ratio, metric.WithAttributes(
+		attribute.String("storage_class", storageClass),
+	))
+}
+
+// RecordIngressReady records how long an Ingress took to serve its first successful request
+func (c *Collector) RecordIngressReady(ctx context.Context, ingressClass string, duration time.Duration) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping readiness metric", "ingress_class", ingressClass)
+		return
+	}
+
+	c.ingressReady.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("ingress_class", ingressClass),
+	))
+}
+
+// RecordPodCIDRViolations records the number of pod IPs found outside the cluster pod CIDR
+func (c *Collector) RecordPodCIDRViolations(ctx context.Context, count int) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping pod CIDR violations", "count", count)
+		return
+	}
+
+	c.cidrViolate.Add(ctx, int64(count))
+}
+
+// RecordNetworkRTTWithLoss records the duration of a request sent over a link losing the given share of packets
+func (c *Collector) RecordNetworkRTTWithLoss(ctx context.Context, lossPercent int, duration time.Duration) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping round-trip metric", "loss_percent", lossPercent)
+		return
+	}
+
+	c.lossRTT.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.Int("packet_loss_percent", lossPercent),
+	))
+}
+
+// RecordTestRetry records that a test retried an operation after a transient failure, attempt
+// being the number of the attempt that is about to run
+func (c *Collector) RecordTestRetry(ctx context.Context, testName string, attempt int) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping retry metric", "test_name", testName)
+		return
+	}
+
+	c.testRetries.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("test_name", testName),
+		attribute.Int("attempt_number", attempt),
+	))
+}
+
+// RecordServiceUpdatePropagation records how long a Service selector update took to reroute requests
+func (c *Collector) RecordServiceUpdatePropagation(ctx context.Context, duration time.Duration) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping service update propagation metric")
+		return
+	}
+
+	c.svcPropagate.Record(ctx, duration.Seconds())
+}
+
+// RecordResourceCreation records how long an object took from its creation to its desired state,
+// e.g. a ready Deployment or a bound PersistentVolumeClaim
+func (c *Collector) RecordResourceCreation(ctx context.Context, kind, name string, duration time.Duration) {
+	if !c.initialized {
+		slog.Warn("metrics collector not initialized, skipping creation metric", "kind", kind, "name", name)
+		return
+	}
+
+	c.resCreation.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("resource_kind", kind),
+		attribute.String("resource_name", name),
+	))
+}
+
+// Results returns the test results recorded so far, in recording order
+func (c *Collector) Results() []TestResult {
+	c.resultsMu.Lock()
+	defer c.resultsMu.Unlock()
+
+	return append([]TestResult(nil), c.results...)
+}
+
+// PrintSummary writes a table of the recorded test results followed by totals
+func (c *Collector) PrintSummary(w io.Writer) {
+	results := c.Results()
+
+	var passed, failed, skipped int
+	var total time.Duration
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "TEST\tSTATUS\tDURATION")
+	for _, result := range results {
+		switch result.Status {
+		case StatusPassed:
+			passed++
+		case StatusFailed:
+			failed++
+		case StatusSkipped:
+			skipped++
+		}
+		total += result.Duration
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%.3fs\n", result.Name, result.Status, result.Duration.Seconds())
+	}
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintf(w, "Total: %d tests, %d passed, %d failed, %d skipped in %.3fs\n",
+		len(results), passed, failed, skipped, total.Seconds())
+}
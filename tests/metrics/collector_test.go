@@ -0,0 +1,263 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestPrintSummary(t *testing.T) {
+	c, err := NewCollector()
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+
+	t.Run("passing", func(t *testing.T) {
+		t.Cleanup(func() { c.RecordTestExecution(t.Context(), t, 2*time.Second) })
+	})
+	t.Run("skipped", func(t *testing.T) {
+		t.Cleanup(func() { c.RecordTestExecution(t.Context(), t, 500*time.Millisecond) })
+		t.Skip("skipped on purpose")
+	})
+
+	results := c.Results()
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Status != StatusPassed || results[1].Status != StatusSkipped {
+		t.Fatalf("unexpected statuses: %s, %s", results[0].Status, results[1].Status)
+	}
+
+	var buf bytes.Buffer
+	c.PrintSummary(&buf)
+	summary := buf.String()
+
+	for _, expected := range []string{
+		"TestPrintSummary/passing",
+		"TestPrintSummary/skipped",
+		"2.000s",
+		"Total: 2 tests, 1 passed, 0 failed, 1 skipped in 2.500s",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("summary does not contain %q:\n%s", expected, summary)
+		}
+	}
+}
+
+func TestRecordTestExecutionSkipped(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	// Reasons are registered under the top-level test, which every case shares, so each case uses
+	// its own collector
+	newCollector := func() *Collector {
+		c, err := NewCollector()
+		if err != nil {
+			t.Fatalf("NewCollector failed: %v", err)
+		}
+		return c
+	}
+
+	skipped := newCollector()
+	t.Run("skipped", func(t *testing.T) {
+		t.Cleanup(func() { skipped.RecordTestExecution(context.Background(), t, time.Second) })
+		skipped.SetSkipReason(t, "capability_missing")
+		t.Skip("capability not available")
+	})
+	unregistered := newCollector()
+	t.Run("unregistered", func(t *testing.T) {
+		t.Cleanup(func() { unregistered.RecordTestExecution(context.Background(), t, time.Second) })
+		t.Skip("no reason registered")
+	})
+	// e2e-framework runs feature steps as subtests, leaving the test itself not skipped
+	step := newCollector()
+	t.Run("step", func(t *testing.T) {
+		t.Cleanup(func() { step.RecordTestExecution(context.Background(), t, time.Second) })
+		t.Run("setup", func(t *testing.T) {
+			step.SetSkipReason(t, "api_unavailable")
+			t.Skip("API not served")
+		})
+	})
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	sums := map[string]int64{}
+	reasons := map[string]string{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, dp := range sum.DataPoints {
+					sums[m.Name] += dp.Value
+					if reason, ok := dp.Attributes.Value("skip_reason"); ok {
+						name, _ := dp.Attributes.Value("test_name")
+						reasons[name.AsString()] = reason.AsString()
+					}
+				}
+			}
+		}
+	}
+
+	if sums["test_skipped_total"] != 3 {
+		t.Errorf("expected test_skipped_total=3, got %d", sums["test_skipped_total"])
+	}
+	if reason := reasons["TestRecordTestExecutionSkipped/skipped"]; reason != "capability_missing" {
+		t.Errorf("expected registered skip_reason, got %q", reason)
+	}
+	if reason := reasons["TestRecordTestExecutionSkipped/step"]; reason != "api_unavailable" {
+		t.Errorf("expected skip_reason registered from a subtest, got %q", reason)
+	}
+	if results := step.Results(); len(results) != 1 || results[0].Status != StatusSkipped {
+		t.Errorf("expected a test skipped from a subtest to be summarized as skipped, got %v", results)
+	}
+	if reason := reasons["TestRecordTestExecutionSkipped/unregistered"]; reason != SkipReasonUnspecified {
+		t.Errorf("expected skip_reason=%s without a registered reason, got %q", SkipReasonUnspecified, reason)
+	}
+	if sums["test_executed_total"] != 0 {
+		t.Errorf("expected skipped test not to be counted as executed, got test_executed_total=%d", sums["test_executed_total"])
+	}
+}
+
+func TestRecordTestExecutionExtraAttributes(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	c, err := NewCollector()
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+
+	// Record a failed run without failing this test, using a separate testing.T
+	failed := &testing.T{}
+	failed.Fail()
+	c.RecordTestExecution(context.Background(), failed, time.Second, attribute.String(FailureStageKey, "setup"))
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					stage, ok := dp.Attributes.Value(FailureStageKey)
+					switch {
+					case m.Name == "test_errors_total" && ok && stage.AsString() == "setup":
+						found = true
+					case m.Name == "test_executed_total" && ok:
+						t.Errorf("expected no %s attribute on test_executed_total", FailureStageKey)
+					}
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					if _, ok := dp.Attributes.Value(FailureStageKey); ok {
+						t.Errorf("expected no %s attribute on %s", FailureStageKey, m.Name)
+					}
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("expected test_errors_total data point with failure_stage=setup")
+	}
+}
+
+func TestRecordTestRetry(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	c, err := NewCollector()
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+
+	c.RecordTestRetry(context.Background(), "TestFlaky", 2)
+	c.RecordTestRetry(context.Background(), "TestFlaky", 3)
+	c.RecordTestRetry(context.Background(), "TestFlaky", 2)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	retries := map[int64]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "test_retries_total" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				if name, ok := dp.Attributes.Value("test_name"); !ok || name.AsString() != "TestFlaky" {
+					t.Errorf("unexpected test_name attribute on data point: %v", dp.Attributes)
+				}
+				attempt, _ := dp.Attributes.Value("attempt_number")
+				retries[attempt.AsInt64()] += dp.Value
+			}
+		}
+	}
+
+	if retries[2] != 2 || retries[3] != 1 {
+		t.Errorf("expected 2 retries at attempt 2 and 1 at attempt 3, got %v", retries)
+	}
+}
+
+func TestActiveTests(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	c, err := NewCollector()
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+
+	ctx := context.Background()
+	c.IncrementActiveTests(ctx, "TestFirst")
+	c.IncrementActiveTests(ctx, "TestSecond")
+	c.DecrementActiveTests(ctx, "TestFirst")
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	active := map[string]float64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "active_tests" {
+				continue
+			}
+			sum := m.Data.(metricdata.Sum[float64])
+			if sum.IsMonotonic {
+				t.Error("expected active_tests to be a non-monotonic sum")
+			}
+			for _, dp := range sum.DataPoints {
+				name, _ := dp.Attributes.Value("test_name")
+				active[name.AsString()] = dp.Value
+			}
+		}
+	}
+
+	if active["TestFirst"] != 0 || active["TestSecond"] != 1 {
+		t.Errorf("expected TestFirst=0 and TestSecond=1 active, got %v", active)
+	}
+}
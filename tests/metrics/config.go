@@ -2,77 +2,222 @@ package metrics
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc/credentials"
+	"sigs.k8s.io/yaml"
 )
 
 const (
 	defaultServiceName    = "e2e-tests"
 	defaultServiceVersion = "0.1.0"
+	defaultPrometheusPort = 9464
+	defaultFlushTimeout   = 10 * time.Second
 	shutdownTimeout       = 1 * time.Second
+	// otlpMetricsPath is the path of the OTLP/HTTP metrics endpoint
+	otlpMetricsPath = "/v1/metrics"
+
+	// ExporterPrometheus selects the Prometheus pull exporter via OTEL_METRICS_EXPORTER, or the
+	// OTEL_EXPORTER_TYPE alias
+	ExporterPrometheus = "prometheus"
 )
 
-// Config holds the OpenTelemetry configuration
+// defaultDurationBuckets cover test durations from a second to several minutes
+var defaultDurationBuckets = []float64{1, 5, 10, 30, 60, 120, 300}
+
+// Config holds the OpenTelemetry configuration. The JSON names are the keys of the file read by
+// ReadFromFile
 type Config struct {
-	ServiceName    string
-	ServiceVersion string
-	Endpoint       string
-	Headers        map[string]string
-	UseHTTP        bool
-	Insecure       bool
+	ServiceName    string            `json:"serviceName"`
+	ServiceVersion string            `json:"serviceVersion"`
+	Endpoint       string            `json:"endpoint"`
+	Headers        map[string]string `json:"headers"`
+	UseHTTP        bool              `json:"useHTTP"`
+	Insecure       bool              `json:"insecure"`
+	CACertPath     string            `json:"caCertPath"`
+	ClientCertPath string            `json:"clientCertPath"`
+	ClientKeyPath  string            `json:"clientKeyPath"`
+	Exporter       string            `json:"exporter"`
+	PrometheusPort int               `json:"prometheusPort"`
+	FlushTimeout   time.Duration     `json:"-"`
+	ClusterName    string            `json:"clusterName"`
+	Environment    string            `json:"environment"`
+	// DurationBuckets are the test_duration_seconds histogram boundaries, in seconds
+	DurationBuckets []float64 `json:"durationBuckets"`
 }
 
 // NewConfigFromEnv creates a new config from environment variables
 func NewConfigFromEnv() *Config {
-	config := &Config{
-		ServiceName:    getEnv("OTEL_SERVICE_NAME", defaultServiceName),
-		ServiceVersion: getEnv("OTEL_SERVICE_VERSION", defaultServiceVersion),
-		Endpoint:       getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
-		UseHTTP:        getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc") == "http/protobuf",
-		Insecure:       getEnv("OTEL_EXPORTER_OTLP_INSECURE", "false") == "true",
-		Headers:        make(map[string]string),
+	config := newDefaultConfig()
+	config.applyEnv()
+
+	return config
+}
+
+// ReadFromFile creates a config from a YAML or JSON file, environment variables overriding the
+// values of the file. The flush timeout is given as a duration string, e.g. "30s".
+func ReadFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics config %s: %w", path, err)
+	}
+
+	config := newDefaultConfig()
+	file := struct {
+		*Config
+		FlushTimeout string `json:"flushTimeout"`
+	}{Config: config}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics config %s: %w", path, err)
+	}
+	if file.FlushTimeout != "" {
+		if config.FlushTimeout, err = time.ParseDuration(file.FlushTimeout); err != nil {
+			return nil, fmt.Errorf("invalid flushTimeout in metrics config %s: %w", path, err)
+		}
+	}
+
+	config.applyEnv()
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid metrics config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// newDefaultConfig creates a config holding the default values
+func newDefaultConfig() *Config {
+	return &Config{
+		ServiceName:     defaultServiceName,
+		ServiceVersion:  defaultServiceVersion,
+		Exporter:        "otlp",
+		PrometheusPort:  defaultPrometheusPort,
+		FlushTimeout:    defaultFlushTimeout,
+		Headers:         make(map[string]string),
+		DurationBuckets: slices.Clone(defaultDurationBuckets),
+	}
+}
+
+// applyEnv overrides the config with the environment variables that are set
+func (config *Config) applyEnv() {
+	config.ServiceName = getEnv("OTEL_SERVICE_NAME", config.ServiceName)
+	config.ServiceVersion = getEnv("OTEL_SERVICE_VERSION", config.ServiceVersion)
+	config.Endpoint = getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", config.Endpoint)
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" {
+		config.UseHTTP = protocol == "http/protobuf"
+	}
+	if insecure := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE"); insecure != "" {
+		config.Insecure = insecure == "true"
+	}
+	config.CACertPath = getEnv("OTEL_EXPORTER_OTLP_CERTIFICATE", config.CACertPath)
+	config.ClientCertPath = getEnv("OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE", config.ClientCertPath)
+	config.ClientKeyPath = getEnv("OTEL_EXPORTER_OTLP_CLIENT_KEY", config.ClientKeyPath)
+	config.Exporter = getEnv("OTEL_METRICS_EXPORTER", getEnv("OTEL_EXPORTER_TYPE", config.Exporter))
+	config.ClusterName = getEnv("CLUSTER_NAME", config.ClusterName)
+	config.Environment = getEnv("ENVIRONMENT", config.Environment)
+
+	if portStr := os.Getenv("PROMETHEUS_PORT"); portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			config.PrometheusPort = port
+		} else {
+			slog.Warn("ignoring invalid PROMETHEUS_PORT", "value", portStr, "error", err)
+		}
+	}
+
+	if timeoutStr := os.Getenv("OTEL_METRICS_FLUSH_TIMEOUT"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil {
+			config.FlushTimeout = timeout
+		} else {
+			slog.Warn("ignoring invalid OTEL_METRICS_FLUSH_TIMEOUT", "value", timeoutStr, "error", err)
+		}
+	}
+
+	if bucketsStr := os.Getenv("TEST_DURATION_BUCKETS"); bucketsStr != "" {
+		if buckets, err := parseBuckets(bucketsStr); err == nil {
+			config.DurationBuckets = buckets
+		} else {
+			slog.Warn("ignoring invalid TEST_DURATION_BUCKETS", "value", bucketsStr, "error", err)
+		}
 	}
 
 	// Parse headers from OTEL_EXPORTER_OTLP_HEADERS
 	if headersStr := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); headersStr != "" {
 		// Simple parsing of "key1=value1,key2=value2" format
 		// For production use, consider a more robust parser
-		log.Printf("Parsing OTLP headers: %s", headersStr)
+		slog.Info("parsing OTLP headers", "headers", headersStr)
 	}
+}
 
-	return config
+// validate checks the service name is set and the endpoint, when set, is a URL or a host:port
+func (config *Config) validate() error {
+	if config.ServiceName == "" {
+		return errors.New("serviceName must not be empty")
+	}
+	if err := checkBuckets(config.DurationBuckets); err != nil {
+		return fmt.Errorf("invalid durationBuckets: %w", err)
+	}
+	if config.Endpoint == "" {
+		return nil
+	}
+
+	if strings.Contains(config.Endpoint, "://") {
+		u, err := url.Parse(config.Endpoint)
+		if err != nil {
+			return fmt.Errorf("endpoint %q is not a valid URL: %w", config.Endpoint, err)
+		}
+		if u.Hostname() == "" {
+			return fmt.Errorf("endpoint %q has no host", config.Endpoint)
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(config.Endpoint); err != nil {
+		return fmt.Errorf("endpoint %q is neither a URL nor a host:port: %w", config.Endpoint, err)
+	}
+
+	return nil
 }
 
 // SetupMetrics initializes the OpenTelemetry metrics pipeline
 func SetupMetrics(config *Config) (func(context.Context) error, error) {
-	// Create resource with service information
-	res, err := resource.New(
-		context.Background(),
-		resource.WithAttributes(
-			semconv.ServiceName(config.ServiceName),
-			semconv.ServiceVersion(config.ServiceVersion),
-		),
-	)
+	res, err := newResource(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
+		return nil, err
+	}
+
+	// Serve metrics for scraping instead of pushing them
+	if config.Exporter == ExporterPrometheus {
+		return setupPrometheus(res, config)
 	}
 
 	// Skip OTLP setup if no endpoint is configured
 	if config.Endpoint == "" {
-		log.Println("No OTLP endpoint configured, metrics will be collected but not exported")
+		slog.Info("no OTLP endpoint configured, metrics will be collected but not exported")
 
 		// Create a basic meter provider without exporter for local testing
 		mp := metric.NewMeterProvider(
 			metric.WithResource(res),
+			metric.WithView(durationBucketsView(config.DurationBuckets)),
 		)
 		otel.SetMeterProvider(mp)
 
@@ -81,6 +226,57 @@ func SetupMetrics(config *Config) (func(context.Context) error, error) {
 		}, nil
 	}
 
+	mp, err := NewMeterProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set the global meter provider
+	otel.SetMeterProvider(mp)
+
+	slog.Info("metrics pipeline initialized",
+		"endpoint", config.Endpoint,
+		"protocol", map[bool]string{true: "http/protobuf", false: "grpc"}[config.UseHTTP])
+
+	// Return shutdown function
+	return func(ctx context.Context) error {
+		// Export the metrics recorded since the last periodic export, with a timeout long enough
+		// for slow networks, so that the final test results are not lost
+		if err := forceFlush(ctx, mp, config.FlushTimeout); err != nil {
+			slog.Error("failed to flush metrics", "error", err)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
+		defer cancel()
+
+		slog.Info("shutting down metrics pipeline")
+		if err := mp.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shutdown meter provider: %w", err)
+		}
+		slog.Info("metrics pipeline shutdown complete")
+		return nil
+	}, nil
+}
+
+// NewMeterProvider creates a meter provider exporting to the OTLP endpoint of config, without
+// making it the global one
+func NewMeterProvider(config *Config) (*metric.MeterProvider, error) {
+	res, err := newResource(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Adapt the endpoint to the protocol before anything relies on the Insecure flag
+	normalized := *config
+	normalized.Endpoint, normalized.Insecure = normalizeEndpoint(config.Endpoint, config.UseHTTP, config.Insecure)
+	config = &normalized
+
+	// Load the certificates before creating the exporter so that a misconfiguration fails fast
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create OTLP exporter
 	var exporter metric.Exporter
 	if config.UseHTTP {
@@ -89,6 +285,8 @@ func SetupMetrics(config *Config) (func(context.Context) error, error) {
 		}
 		if config.Insecure {
 			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else if tlsConfig != nil {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsConfig))
 		}
 		exporter, err = otlpmetrichttp.New(context.Background(), opts...)
 	} else {
@@ -97,6 +295,8 @@ func SetupMetrics(config *Config) (func(context.Context) error, error) {
 		}
 		if config.Insecure {
 			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		} else if tlsConfig != nil {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
 		}
 		exporter, err = otlpmetricgrpc.New(context.Background(), opts...)
 	}
@@ -106,31 +306,229 @@ func SetupMetrics(config *Config) (func(context.Context) error, error) {
 	}
 
 	// Create meter provider with periodic reader
-	mp := metric.NewMeterProvider(
+	return metric.NewMeterProvider(
 		metric.WithResource(res),
+		metric.WithView(durationBucketsView(config.DurationBuckets)),
 		metric.WithReader(metric.NewPeriodicReader(
 			exporter,
 			metric.WithInterval(5*time.Second),
 		)),
+	), nil
+}
+
+// normalizeEndpoint adapts an OTLP endpoint to the exporter protocol, returning the endpoint and
+// whether the connection is insecure. gRPC expects a bare host:port, so the scheme and path of a URL
+// are stripped, an http:// scheme making the connection insecure. HTTP expects a URL, so a bare
+// host:port gets the scheme matching the Insecure flag, and a URL without path gets the
+// /v1/metrics path, as the exporter would otherwise post to /.
+func normalizeEndpoint(endpoint string, useHTTP, insecure bool) (string, bool) {
+	if !strings.Contains(endpoint, "://") {
+		if !useHTTP {
+			return endpoint, insecure
+		}
+		if insecure {
+			return "http://" + endpoint + otlpMetricsPath, insecure
+		}
+		return "https://" + endpoint + otlpMetricsPath, insecure
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		slog.Warn("failed to parse OTLP endpoint, using it as is", "endpoint", endpoint, "error", err)
+		return endpoint, insecure
+	}
+
+	switch u.Scheme {
+	case "http":
+		if !insecure {
+			slog.Info("OTLP endpoint uses http://, using an insecure connection", "endpoint", endpoint)
+		}
+		insecure = true
+	case "https":
+		if insecure {
+			slog.Warn("OTLP endpoint uses https:// but OTEL_EXPORTER_OTLP_INSECURE is true, using an insecure connection", "endpoint", endpoint)
+		}
+	default:
+		slog.Warn("OTLP endpoint has an unexpected scheme", "endpoint", endpoint, "scheme", u.Scheme)
+	}
+
+	if useHTTP {
+		if strings.Trim(u.Path, "/") == "" {
+			u.Path = otlpMetricsPath
+			return u.String(), insecure
+		}
+		return endpoint, insecure
+	}
+	if strings.Trim(u.Path, "/") != "" {
+		slog.Warn("ignoring path of OTLP endpoint, gRPC endpoints have no path", "endpoint", endpoint, "path", u.Path)
+	}
+	return u.Host, insecure
+}
+
+// newTLSConfig builds the TLS configuration for the OTLP exporter from the configured certificate
+// files. It returns nil when the connection is insecure or no certificate is configured, in which
+// case the exporter uses the system roots.
+func newTLSConfig(config *Config) (*tls.Config, error) {
+	if config.Insecure {
+		return nil, nil
+	}
+	if config.CACertPath == "" && config.ClientCertPath == "" && config.ClientKeyPath == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.CACertPath != "" {
+		caCert, err := os.ReadFile(config.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OTLP CA certificate %s: %w", config.CACertPath, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no PEM certificate found in OTLP CA certificate %s", config.CACertPath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// A client certificate is useless without its key, so both must be set for mTLS
+	if (config.ClientCertPath == "") != (config.ClientKeyPath == "") {
+		return nil, errors.New("OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE and OTEL_EXPORTER_OTLP_CLIENT_KEY must be set together")
+	}
+	if config.ClientCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertPath, config.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load OTLP client certificate %s and key %s: %w",
+				config.ClientCertPath, config.ClientKeyPath, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// durationBucketsView sets the bucket boundaries of the test_duration_seconds histogram, using the
+// default ones when none are given
+func durationBucketsView(buckets []float64) metric.View {
+	if len(buckets) == 0 {
+		buckets = defaultDurationBuckets
+	}
+
+	return metric.NewView(
+		metric.Instrument{Name: "test_duration_seconds"},
+		metric.Stream{Aggregation: metric.AggregationExplicitBucketHistogram{Boundaries: buckets}},
 	)
+}
 
-	// Set the global meter provider
+// parseBuckets parses comma-separated histogram bucket boundaries, which must be strictly increasing
+func parseBuckets(value string) ([]float64, error) {
+	var buckets []float64
+	for _, field := range strings.Split(value, ",") {
+		bucket, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, checkBuckets(buckets)
+}
+
+// checkBuckets checks histogram bucket boundaries are strictly increasing
+func checkBuckets(buckets []float64) error {
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("bucket %v is not greater than %v", buckets[i], buckets[i-1])
+		}
+	}
+
+	return nil
+}
+
+// forceFlush exports all metrics recorded by the meter provider, giving up after timeout
+func forceFlush(ctx context.Context, mp *metric.MeterProvider, timeout time.Duration) error {
+	flushCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	slog.Info("flushing metrics")
+	if err := mp.ForceFlush(flushCtx); err != nil {
+		return fmt.Errorf("failed to flush meter provider: %w", err)
+	}
+	return nil
+}
+
+// newResource creates the resource identifying the service, cluster and build the metrics come from
+func newResource(config *Config) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+	}
+	if config.ClusterName != "" {
+		attrs = append(attrs, semconv.K8SClusterName(config.ClusterName))
+	}
+	if config.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(config.Environment))
+	}
+	if buildInfo, ok := ReadBuildInfo(); ok && buildInfo.Revision != "" {
+		attrs = append(attrs, attribute.String("vcs.revision", buildInfo.Revision))
+	}
+
+	res, err := resource.New(context.Background(), resource.WithAttributes(attrs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	return res, nil
+}
+
+// setupPrometheus registers a Prometheus reader and serves it on /metrics
+func setupPrometheus(res *resource.Resource, config *Config) (func(context.Context) error, error) {
+	registry := prometheus.NewRegistry()
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Prometheus exporter: %w", err)
+	}
+
+	mp := metric.NewMeterProvider(
+		metric.WithResource(res),
+		metric.WithView(durationBucketsView(config.DurationBuckets)),
+		metric.WithReader(exporter),
+	)
 	otel.SetMeterProvider(mp)
 
-	log.Printf("Metrics pipeline initialized: endpoint=%s, protocol=%s",
-		config.Endpoint,
-		map[bool]string{true: "http/protobuf", false: "grpc"}[config.UseHTTP])
+	// Listen synchronously so that port conflicts fail the setup
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.PrometheusPort))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on Prometheus port %d: %w", config.PrometheusPort, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Prometheus metrics server failed", "error", err)
+		}
+	}()
+
+	slog.Info("metrics pipeline initialized", "prometheus_endpoint", listener.Addr().String()+"/metrics")
 
 	// Return shutdown function
 	return func(ctx context.Context) error {
 		shutdownCtx, cancel := context.WithTimeout(ctx, shutdownTimeout)
 		defer cancel()
 
-		log.Println("Shutting down metrics pipeline...")
+		slog.Info("shutting down metrics pipeline")
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shutdown Prometheus server: %w", err)
+		}
 		if err := mp.Shutdown(shutdownCtx); err != nil {
 			return fmt.Errorf("failed to shutdown meter provider: %w", err)
 		}
-		log.Println("Metrics pipeline shutdown complete")
+		slog.Info("metrics pipeline shutdown complete")
 		return nil
 	}, nil
 }
@@ -142,4 +540,3 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
-
@@ -0,0 +1,553 @@
+package metrics
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestSetupMetricsPrometheus(t *testing.T) {
+	port := freePort(t)
+	shutdown, err := SetupMetrics(&Config{
+		ServiceName:    defaultServiceName,
+		ServiceVersion: defaultServiceVersion,
+		Exporter:       ExporterPrometheus,
+		PrometheusPort: port,
+	})
+	if err != nil {
+		t.Fatalf("SetupMetrics failed: %v", err)
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown failed: %v", err)
+		}
+	}()
+
+	collector, err := NewCollector()
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+	collector.RecordTestExecution(context.Background(), t, 2*time.Second)
+
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/metrics", port))
+	if err != nil {
+		t.Fatalf("failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(string(body), "test_executed_total") {
+		t.Fatalf("test_executed_total not found in scrape output:\n%s", body)
+	}
+}
+
+func TestNewResourceClusterAttributes(t *testing.T) {
+	t.Setenv("CLUSTER_NAME", "test-cluster")
+	t.Setenv("ENVIRONMENT", "staging")
+
+	res, err := newResource(NewConfigFromEnv())
+	if err != nil {
+		t.Fatalf("newResource failed: %v", err)
+	}
+
+	for key, expected := range map[attribute.Key]string{
+		semconv.K8SClusterNameKey:        "test-cluster",
+		semconv.DeploymentEnvironmentKey: "staging",
+		semconv.ServiceNameKey:           defaultServiceName,
+	} {
+		value, ok := res.Set().Value(key)
+		if !ok {
+			t.Errorf("resource is missing attribute %s", key)
+			continue
+		}
+		if value.AsString() != expected {
+			t.Errorf("expected %s=%q, got %q", key, expected, value.AsString())
+		}
+	}
+}
+
+func TestForceFlushExportsPendingMetrics(t *testing.T) {
+	exporter := &memoryExporter{}
+	// An interval longer than the test ensures only the flush exports the metrics
+	mp := metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(exporter, metric.WithInterval(time.Hour))))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	counter, err := mp.Meter("test").Int64Counter("flush_test_total")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1)
+
+	if names := exporter.exported(); len(names) != 0 {
+		t.Fatalf("expected no export before the flush, got %v", names)
+	}
+	if err := forceFlush(context.Background(), mp, time.Second); err != nil {
+		t.Fatalf("forceFlush failed: %v", err)
+	}
+	if names := exporter.exported(); !slices.Contains(names, "flush_test_total") {
+		t.Fatalf("expected flush_test_total to be exported, got %v", names)
+	}
+}
+
+func TestNewConfigFromEnvFlushTimeout(t *testing.T) {
+	if timeout := NewConfigFromEnv().FlushTimeout; timeout != defaultFlushTimeout {
+		t.Errorf("expected default flush timeout %s, got %s", defaultFlushTimeout, timeout)
+	}
+
+	t.Setenv("OTEL_METRICS_FLUSH_TIMEOUT", "30s")
+	if timeout := NewConfigFromEnv().FlushTimeout; timeout != 30*time.Second {
+		t.Errorf("expected flush timeout 30s, got %s", timeout)
+	}
+}
+
+func TestNewConfigFromEnvExporter(t *testing.T) {
+	if exporter := NewConfigFromEnv().Exporter; exporter != "otlp" {
+		t.Errorf("expected default exporter otlp, got %s", exporter)
+	}
+
+	t.Setenv("OTEL_EXPORTER_TYPE", ExporterPrometheus)
+	if exporter := NewConfigFromEnv().Exporter; exporter != ExporterPrometheus {
+		t.Errorf("expected OTEL_EXPORTER_TYPE to select %s, got %s", ExporterPrometheus, exporter)
+	}
+
+	// The standard variable wins over the alias
+	t.Setenv("OTEL_METRICS_EXPORTER", "otlp")
+	if exporter := NewConfigFromEnv().Exporter; exporter != "otlp" {
+		t.Errorf("expected OTEL_METRICS_EXPORTER to take precedence, got %s", exporter)
+	}
+}
+
+func TestDurationBucketsView(t *testing.T) {
+	buckets := []float64{0.5, 2, 15, 600}
+	reader := metric.NewManualReader()
+	provider := metric.NewMeterProvider(metric.WithReader(reader), metric.WithView(durationBucketsView(buckets)))
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+
+	c, err := NewCollectorForProvider(provider)
+	if err != nil {
+		t.Fatalf("NewCollectorForProvider failed: %v", err)
+	}
+	c.RecordTestExecution(context.Background(), t, 3*time.Second)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	found := false
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "test_duration_seconds" {
+				continue
+			}
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok || len(histogram.DataPoints) != 1 {
+				t.Fatalf("expected a single float64 histogram data point, got %T", m.Data)
+			}
+			found = true
+			if bounds := histogram.DataPoints[0].Bounds; !slices.Equal(bounds, buckets) {
+				t.Errorf("expected bucket boundaries %v, got %v", buckets, bounds)
+			}
+		}
+	}
+	if !found {
+		t.Error("test_duration_seconds not collected")
+	}
+}
+
+func TestNewConfigFromEnvDurationBuckets(t *testing.T) {
+	if buckets := NewConfigFromEnv().DurationBuckets; !slices.Equal(buckets, defaultDurationBuckets) {
+		t.Errorf("expected default buckets %v, got %v", defaultDurationBuckets, buckets)
+	}
+
+	t.Setenv("TEST_DURATION_BUCKETS", "0.5, 2,15,600")
+	if buckets := NewConfigFromEnv().DurationBuckets; !slices.Equal(buckets, []float64{0.5, 2, 15, 600}) {
+		t.Errorf("expected TEST_DURATION_BUCKETS to be parsed, got %v", buckets)
+	}
+
+	// Invalid values keep the defaults
+	for _, value := range []string{"1,abc", "10,5", "1,1"} {
+		t.Setenv("TEST_DURATION_BUCKETS", value)
+		if buckets := NewConfigFromEnv().DurationBuckets; !slices.Equal(buckets, defaultDurationBuckets) {
+			t.Errorf("expected %q to be ignored, got %v", value, buckets)
+		}
+	}
+}
+
+func TestReadFromFile(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		path := writeConfig("config.yaml", `serviceName: e2e-ci
+endpoint: https://otel.example.com:4317
+useHTTP: true
+flushTimeout: 30s
+clusterName: ci
+`)
+		config, err := ReadFromFile(path)
+		if err != nil {
+			t.Fatalf("ReadFromFile failed: %v", err)
+		}
+		if config.ServiceName != "e2e-ci" || config.Endpoint != "https://otel.example.com:4317" || !config.UseHTTP || config.ClusterName != "ci" {
+			t.Errorf("file values not applied: %+v", config)
+		}
+		if config.FlushTimeout != 30*time.Second {
+			t.Errorf("expected flush timeout 30s, got %s", config.FlushTimeout)
+		}
+		if config.ServiceVersion != defaultServiceVersion || config.PrometheusPort != defaultPrometheusPort {
+			t.Errorf("defaults not kept for values missing from the file: %+v", config)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		path := writeConfig("config.json", `{"serviceName": "e2e-json", "endpoint": "otel.example.com:4317"}`)
+		config, err := ReadFromFile(path)
+		if err != nil {
+			t.Fatalf("ReadFromFile failed: %v", err)
+		}
+		if config.ServiceName != "e2e-json" || config.Endpoint != "otel.example.com:4317" {
+			t.Errorf("file values not applied: %+v", config)
+		}
+	})
+
+	t.Run("duration buckets", func(t *testing.T) {
+		path := writeConfig("buckets.json", `{"durationBuckets": [2, 3]}`)
+		config, err := ReadFromFile(path)
+		if err != nil {
+			t.Fatalf("ReadFromFile failed: %v", err)
+		}
+		if !slices.Equal(config.DurationBuckets, []float64{2, 3}) {
+			t.Errorf("expected buckets [2 3], got %v", config.DurationBuckets)
+		}
+		if !slices.Equal(defaultDurationBuckets, []float64{1, 5, 10, 30, 60, 120, 300}) {
+			t.Errorf("file buckets overwrote the defaults: %v", defaultDurationBuckets)
+		}
+	})
+
+	t.Run("environment overrides file", func(t *testing.T) {
+		path := writeConfig("override.yaml", "serviceName: e2e-file\nclusterName: file\n")
+		t.Setenv("OTEL_SERVICE_NAME", "e2e-env")
+		config, err := ReadFromFile(path)
+		if err != nil {
+			t.Fatalf("ReadFromFile failed: %v", err)
+		}
+		if config.ServiceName != "e2e-env" {
+			t.Errorf("expected OTEL_SERVICE_NAME to override the file, got %s", config.ServiceName)
+		}
+		if config.ClusterName != "file" {
+			t.Errorf("expected file value for unset variables, got %s", config.ClusterName)
+		}
+	})
+
+	for name, content := range map[string]string{
+		"empty service name": `serviceName: ""`,
+		"invalid endpoint":   `endpoint: "otel.example.com"`,
+		"endpoint URL":       `endpoint: "http://:4317"`,
+		"unknown key":        `serviceNamespace: e2e`,
+		"invalid timeout":    `flushTimeout: soon`,
+		"duplicate buckets":  `durationBuckets: [1, 1, 5]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfig("invalid.yaml", content)
+			if _, err := ReadFromFile(path); err == nil {
+				t.Errorf("expected %q to be rejected", content)
+			}
+		})
+	}
+
+	if _, err := ReadFromFile(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected a missing file to be rejected")
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certPath, keyPath := writeCertificate(t)
+
+	tlsConfig, err := newTLSConfig(&Config{CACertPath: certPath, ClientCertPath: certPath, ClientKeyPath: keyPath})
+	if err != nil {
+		t.Fatalf("newTLSConfig failed: %v", err)
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Error("expected the CA certificate to be trusted")
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Errorf("expected one client certificate, got %d", len(tlsConfig.Certificates))
+	}
+
+	// The certificate files are ignored when the connection is insecure
+	tlsConfig, err = newTLSConfig(&Config{Insecure: true, CACertPath: filepath.Join(t.TempDir(), "missing.pem")})
+	if err != nil || tlsConfig != nil {
+		t.Errorf("expected no TLS config for an insecure connection, got %v (err: %v)", tlsConfig, err)
+	}
+
+	for name, config := range map[string]*Config{
+		"missing CA":              {CACertPath: filepath.Join(t.TempDir(), "missing.pem")},
+		"CA without certificate":  {CACertPath: keyPath},
+		"certificate without key": {ClientCertPath: certPath},
+		"mismatched key pair":     {ClientCertPath: certPath, ClientKeyPath: certPath},
+	} {
+		if _, err := newTLSConfig(config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNormalizeEndpoint(t *testing.T) {
+	tests := []struct {
+		name             string
+		endpoint         string
+		useHTTP          bool
+		insecure         bool
+		expectedEndpoint string
+		expectedInsecure bool
+	}{
+		{
+			name:             "grpc http scheme",
+			endpoint:         "http://collector.example.com:4317",
+			expectedEndpoint: "collector.example.com:4317",
+			expectedInsecure: true,
+		},
+		{
+			name:             "grpc https scheme",
+			endpoint:         "https://collector.example.com:4317",
+			expectedEndpoint: "collector.example.com:4317",
+		},
+		{
+			name:             "grpc https scheme with insecure flag",
+			endpoint:         "https://collector.example.com:4317",
+			insecure:         true,
+			expectedEndpoint: "collector.example.com:4317",
+			expectedInsecure: true,
+		},
+		{
+			name:             "grpc bare host and port",
+			endpoint:         "collector.example.com:4317",
+			expectedEndpoint: "collector.example.com:4317",
+		},
+		{
+			name:             "grpc trailing path",
+			endpoint:         "https://collector.example.com:4317/otlp/",
+			expectedEndpoint: "collector.example.com:4317",
+		},
+		{
+			name:             "http scheme kept",
+			endpoint:         "http://collector.example.com:4318/v1/metrics",
+			useHTTP:          true,
+			expectedEndpoint: "http://collector.example.com:4318/v1/metrics",
+			expectedInsecure: true,
+		},
+		{
+			name:             "http bare host and port",
+			endpoint:         "collector.example.com:4318",
+			useHTTP:          true,
+			expectedEndpoint: "https://collector.example.com:4318/v1/metrics",
+		},
+		{
+			name:             "http bare host and port with insecure flag",
+			endpoint:         "collector.example.com:4318",
+			useHTTP:          true,
+			insecure:         true,
+			expectedEndpoint: "http://collector.example.com:4318/v1/metrics",
+			expectedInsecure: true,
+		},
+		{
+			name:             "http URL without path",
+			endpoint:         "https://collector.example.com:4318/",
+			useHTTP:          true,
+			expectedEndpoint: "https://collector.example.com:4318/v1/metrics",
+		},
+		{
+			name:             "http custom path kept",
+			endpoint:         "https://collector.example.com/otlp/v1/metrics",
+			useHTTP:          true,
+			expectedEndpoint: "https://collector.example.com/otlp/v1/metrics",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, insecure := normalizeEndpoint(tt.endpoint, tt.useHTTP, tt.insecure)
+			if endpoint != tt.expectedEndpoint {
+				t.Errorf("expected endpoint %q, got %q", tt.expectedEndpoint, endpoint)
+			}
+			if insecure != tt.expectedInsecure {
+				t.Errorf("expected insecure=%t, got %t", tt.expectedInsecure, insecure)
+			}
+		})
+	}
+}
+
+// writeCertificate writes a self-signed certificate and its key to PEM files, returning their paths
+func writeCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "e2e-tests"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	return certPath, keyPath
+}
+
+func TestSetupMetricsUnreachableEndpoint(t *testing.T) {
+	for _, useHTTP := range []bool{false, true} {
+		t.Run(map[bool]string{true: "http", false: "grpc"}[useHTTP], func(t *testing.T) {
+			previous := otel.GetMeterProvider()
+			t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+			// Nothing listens on a port freed right after it was found
+			endpoint := fmt.Sprintf("127.0.0.1:%d", freePort(t))
+			if useHTTP {
+				endpoint = "http://" + endpoint
+			}
+			config := &Config{
+				ServiceName:    defaultServiceName,
+				ServiceVersion: defaultServiceVersion,
+				Endpoint:       endpoint,
+				UseHTTP:        useHTTP,
+				Insecure:       true,
+				FlushTimeout:   500 * time.Millisecond,
+			}
+
+			shutdown, err := SetupMetrics(config)
+			if err != nil {
+				t.Fatalf("SetupMetrics failed for an unreachable endpoint: %v", err)
+			}
+
+			collector, err := NewCollector()
+			if err != nil {
+				t.Fatalf("NewCollector failed: %v", err)
+			}
+			recordStart := time.Now()
+			for range 100 {
+				collector.RecordTestExecution(context.Background(), t, time.Second)
+			}
+			if elapsed := time.Since(recordStart); elapsed > time.Second {
+				t.Errorf("recording metrics took %s with an unreachable endpoint", elapsed)
+			}
+
+			// The export failure is logged, shutdown must only be bounded by the flush and shutdown timeouts
+			shutdownStart := time.Now()
+			if err := shutdown(context.Background()); err != nil {
+				t.Logf("shutdown reported the export failure: %v", err)
+			}
+			if elapsed, limit := time.Since(shutdownStart), config.FlushTimeout+shutdownTimeout+time.Second; elapsed > limit {
+				t.Errorf("shutdown took %s, more than %s", elapsed, limit)
+			}
+		})
+	}
+}
+
+// memoryExporter is a metric exporter keeping the names of exported metrics in memory
+type memoryExporter struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (e *memoryExporter) Temporality(kind metric.InstrumentKind) metricdata.Temporality {
+	return metric.DefaultTemporalitySelector(kind)
+}
+
+func (e *memoryExporter) Aggregation(kind metric.InstrumentKind) metric.Aggregation {
+	return metric.DefaultAggregationSelector(kind)
+}
+
+func (e *memoryExporter) Export(_ context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// The reader reuses rm after Export returns, so only the names are retained
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			e.names = append(e.names, m.Name)
+		}
+	}
+	return nil
+}
+
+func (e *memoryExporter) ForceFlush(context.Context) error { return nil }
+
+func (e *memoryExporter) Shutdown(context.Context) error { return nil }
+
+// exported returns the names of the metrics exported so far
+func (e *memoryExporter) exported() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return slices.Clone(e.names)
+}
+
+// freePort returns a TCP port that is currently free on the loopback interface
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
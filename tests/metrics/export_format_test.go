@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/metric"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+)
+
+func TestOTLPMetricExportFormat(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	receiver := &metricsReceiver{}
+	collectormetricspb.RegisterMetricsServiceServer(server, receiver)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	previous := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	shutdown, err := SetupMetrics(&Config{
+		ServiceName:    defaultServiceName,
+		ServiceVersion: defaultServiceVersion,
+		Endpoint:       listener.Addr().String(),
+		Insecure:       true,
+		FlushTimeout:   5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("SetupMetrics failed: %v", err)
+	}
+	defer func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("shutdown failed: %v", err)
+		}
+	}()
+
+	collector, err := NewCollector()
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+	for _, name := range []string{"first", "second", "third"} {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() { collector.RecordTestExecution(t.Context(), t, time.Second) })
+		})
+	}
+
+	mp, ok := otel.GetMeterProvider().(*metric.MeterProvider)
+	if !ok {
+		t.Fatalf("expected an SDK meter provider, got %T", otel.GetMeterProvider())
+	}
+	if err := forceFlush(context.Background(), mp, 5*time.Second); err != nil {
+		t.Fatalf("forceFlush failed: %v", err)
+	}
+
+	// Metrics are cumulative, so the last request holds every recording
+	request := receiver.last()
+	if request == nil {
+		t.Fatal("no ExportMetricsServiceRequest received")
+	}
+	if len(request.ResourceMetrics) == 0 || len(request.ResourceMetrics[0].ScopeMetrics) == 0 {
+		t.Fatalf("expected resource and scope metrics, got %v", request)
+	}
+
+	metrics := map[string]*metricspb.Metric{}
+	for _, m := range request.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	duration, ok := metrics["test_duration_seconds"]
+	if !ok {
+		t.Fatalf("test_duration_seconds not exported, got %v", request)
+	}
+	histogram := duration.GetHistogram()
+	if histogram == nil {
+		t.Fatalf("expected test_duration_seconds to be a Histogram, got %T", duration.Data)
+	}
+	var count uint64
+	for _, dp := range histogram.DataPoints {
+		count += dp.Count
+	}
+	if count != 3 {
+		t.Errorf("expected 3 test_duration_seconds recordings, got %d", count)
+	}
+	if duration.Unit != "s" {
+		t.Errorf("expected test_duration_seconds unit s, got %q", duration.Unit)
+	}
+
+	executed, ok := metrics["test_executed_total"]
+	if !ok {
+		t.Fatalf("test_executed_total not exported, got %v", request)
+	}
+	sum := executed.GetSum()
+	if sum == nil {
+		t.Fatalf("expected test_executed_total to be a Sum, got %T", executed.Data)
+	}
+	if !sum.IsMonotonic || sum.AggregationTemporality != metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		t.Errorf("expected test_executed_total to be a cumulative monotonic counter, got monotonic=%t temporality=%s",
+			sum.IsMonotonic, sum.AggregationTemporality)
+	}
+	var total int64
+	for _, dp := range sum.DataPoints {
+		total += dp.GetAsInt()
+	}
+	if total != 3 {
+		t.Errorf("expected test_executed_total=3, got %d", total)
+	}
+}
+
+// metricsReceiver is an OTLP gRPC metrics service keeping the export requests it receives
+type metricsReceiver struct {
+	collectormetricspb.UnimplementedMetricsServiceServer
+
+	mu       sync.Mutex
+	requests []*collectormetricspb.ExportMetricsServiceRequest
+}
+
+func (r *metricsReceiver) Export(_ context.Context, request *collectormetricspb.ExportMetricsServiceRequest) (*collectormetricspb.ExportMetricsServiceResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = append(r.requests, request)
+	return &collectormetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+// last returns the last request received, or nil if none was
+func (r *metricsReceiver) last() *collectormetricspb.ExportMetricsServiceRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.requests) == 0 {
+		return nil
+	}
+	return r.requests[len(r.requests)-1]
+}
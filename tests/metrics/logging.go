@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Log formats of the test harness, selected with LOG_FORMAT
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// NewLogger creates a logger writing to w in the given format, falling back to text for an
+// unknown format
+func NewLogger(w io.Writer, format string) *slog.Logger {
+	if format == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, nil))
+	}
+
+	return slog.New(slog.NewTextHandler(w, nil))
+}
+
+// SetupLogging makes the logger for the LOG_FORMAT environment variable the default one, which the
+// standard log package also writes through
+func SetupLogging() {
+	format := getEnv("LOG_FORMAT", LogFormatText)
+	slog.SetDefault(NewLogger(os.Stderr, format))
+	if format != LogFormatText && format != LogFormatJSON {
+		slog.Warn("unknown LOG_FORMAT, using text", "log_format", format)
+	}
+}
+
+// logAttrs converts metric attributes to log fields, so that log lines carry the same labels
+func logAttrs(attrs []attribute.KeyValue) []any {
+	fields := make([]any, 0, len(attrs))
+	for _, kv := range attrs {
+		fields = append(fields, slog.String(string(kv.Key), kv.Value.Emit()))
+	}
+
+	return fields
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestNewLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, LogFormatJSON)
+	logger.Info("recorded test metrics", "test_name", "TestSample", "stage", "assess", "duration", 1.5)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log output is not valid JSON: %v: %s", err, buf.String())
+	}
+
+	expected := map[string]any{
+		"msg":       "recorded test metrics",
+		"level":     "INFO",
+		"test_name": "TestSample",
+		"stage":     "assess",
+		"duration":  1.5,
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, entry[key])
+		}
+	}
+}
+
+func TestNewLoggerDefaultsToText(t *testing.T) {
+	for _, format := range []string{LogFormatText, "unknown"} {
+		var buf bytes.Buffer
+		NewLogger(&buf, format).Info("sample", "test_name", "TestSample")
+
+		if json.Valid(buf.Bytes()) {
+			t.Errorf("expected text output for format %q, got JSON: %s", format, buf.String())
+		}
+		if !strings.Contains(buf.String(), "test_name=TestSample") {
+			t.Errorf("expected text output for format %q to contain test_name=TestSample, got: %s", format, buf.String())
+		}
+	}
+}
+
+func TestRecordTestExecutionLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(NewLogger(&buf, LogFormatJSON))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	c, err := NewCollector()
+	if err != nil {
+		t.Fatalf("NewCollector failed: %v", err)
+	}
+
+	failed := &testing.T{}
+	failed.Fail()
+	c.RecordTestExecution(context.Background(), failed, 2*time.Second, attribute.String(FailureStageKey, "teardown"))
+
+	found := false
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("log line is not valid JSON: %v: %s", err, line)
+		}
+		if entry["msg"] != "recorded test metrics" {
+			continue
+		}
+
+		found = true
+		if entry["failure_stage"] != "teardown" {
+			t.Errorf("expected failure_stage=teardown, got %v", entry["failure_stage"])
+		}
+		if entry["duration"] != 2.0 {
+			t.Errorf("expected duration=2, got %v", entry["duration"])
+		}
+		if _, ok := entry["test_name"]; !ok {
+			t.Error("expected a test_name field")
+		}
+	}
+	if !found {
+		t.Errorf("no recorded test metrics line logged: %s", buf.String())
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestMultiContainerPod(t *testing.T) {
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	multiContainerFeature := features.New("workloads/multi-container").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newMultiContainerPod(cfg.Namespace(), "multi-container-test")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("sidecar writes to the shared volume", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			// The pod is Running once its containers started, possibly before the sidecar's first write
+			var timestamp string
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				var stdout, stderr bytes.Buffer
+				command := []string{"cat", "/shared/index.html"}
+				if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, "sidecar", command, &stdout, &stderr); err != nil {
+					t.Logf("Sidecar file not readable yet: %v: %s", err, stderr.String())
+					return false, nil
+				}
+
+				timestamp = strings.TrimSpace(stdout.String())
+				return timestamp != "", nil
+			})
+			if err != nil {
+				t.Fatalf("Sidecar never wrote a non-empty file to the shared volume: %v", err)
+			}
+			t.Logf("✓ Sidecar wrote timestamp %s to the shared volume", timestamp)
+
+			return ctx
+		}).
+		Assess("sidecar reaches nginx over localhost", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			// nginx serves the file the sidecar wrote, and containers share the pod network namespace,
+			// which ambassador containers rely on to proxy traffic over the loopback interface
+			var stdout, stderr bytes.Buffer
+			command := []string{"wget", "-qO-", "http://127.0.0.1:8080/"}
+			if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, "sidecar", command, &stdout, &stderr); err != nil {
+				t.Fatalf("Sidecar failed to reach nginx over localhost: %v: %s", err, stderr.String())
+			}
+
+			if strings.TrimSpace(stdout.String()) == "" {
+				t.Fatal("nginx served an empty page to the sidecar")
+			}
+			t.Logf("✓ Sidecar fetched %q from nginx over localhost", strings.TrimSpace(stdout.String()))
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+					return ctx
+				}
+				if err := waitForDeleted(ctx, cfg.Client().Resources(), pod); err != nil {
+					t.Errorf("Pod %s not deleted: %v", pod.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, multiContainerFeature)
+}
+
+// newMultiContainerPod creates a pod whose nginx container serves a shared emptyDir, in which a
+// sidecar container writes the current timestamp every few seconds
+func newMultiContainerPod(namespace, name string) *corev1.Pod {
+	securityContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &[]bool{false}[0],
+		RunAsNonRoot:             &[]bool{true}[0],
+		RunAsUser:                &[]int64{65534}[0],
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "multi-container-test"},
+		},
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "nginx",
+					Image: "cgr.dev/chainguard/nginx",
+					Ports: []corev1.ContainerPort{
+						{
+							ContainerPort: 8080,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					SecurityContext: securityContext,
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "shared",
+							MountPath: "/usr/share/nginx/html",
+							ReadOnly:  true,
+						},
+					},
+				},
+				{
+					Name:  "sidecar",
+					Image: "alpine:latest",
+					Command: []string{
+						"sh", "-c",
+						"while true; do date -u +%Y-%m-%dT%H:%M:%SZ > /shared/index.html; sleep 5; done",
+					},
+					SecurityContext: securityContext,
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "shared",
+							MountPath: "/shared",
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "shared",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+}
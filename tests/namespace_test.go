@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const namespaceTestFinalizer = "e2e-tests.clementnuss.github.io/hold"
+
+func TestNamespaceTerminating(t *testing.T) {
+	namespaceKey := any("namespace-key")
+	holderKey := any("holder-key")
+
+	trackTest(t)
+
+	terminatingFeature := features.New("namespace/terminating").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create a throwaway namespace, separate from the test namespace
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   names.randomName("terminating-test", 24),
+					Labels: map[string]string{"app": "namespace-test"},
+				},
+			}
+			if err := cfg.Client().Resources().Create(ctx, namespace); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, namespaceKey, namespace)
+
+			// A finalizer on a ConfigMap keeps the namespace in Terminating until teardown
+			holder := newConfigMap(namespace.Name, "terminating-holder", configTestKey, configTestValue)
+			holder.Finalizers = []string{namespaceTestFinalizer}
+			if err := cfg.Client().Resources().Create(ctx, holder); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, holderKey, holder)
+
+			if err := cfg.Client().Resources().Delete(ctx, namespace); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := waitForNamespacePhase(ctx, cfg.Client().Resources(), namespace.Name, corev1.NamespaceTerminating); err != nil {
+				t.Fatalf("Namespace %s did not enter Terminating: %v", namespace.Name, err)
+			}
+
+			return ctx
+		}).
+		Assess("creation rejected in terminating namespace", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			namespace := ctx.Value(namespaceKey).(*corev1.Namespace)
+
+			cm := newConfigMap(namespace.Name, "terminating-test", configTestKey, configTestValue)
+			err := cfg.Client().Resources().Create(ctx, cm)
+			if err == nil {
+				t.Fatalf("ConfigMap was created in terminating namespace %s", namespace.Name)
+			}
+
+			if !apierrors.IsForbidden(err) || !apierrors.HasStatusCause(err, corev1.NamespaceTerminatingCause) {
+				t.Fatalf("Expected a forbidden %s error, got: %v", corev1.NamespaceTerminatingCause, err)
+			}
+			t.Logf("✓ Creation rejected in terminating namespace: %v", err)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Release the finalizer so the namespace deletion can complete
+			if holder := ctx.Value(holderKey).(*corev1.ConfigMap); holder != nil {
+				var currentHolder corev1.ConfigMap
+				if err := cfg.Client().Resources().Get(ctx, holder.Name, holder.Namespace, &currentHolder); err != nil {
+					t.Logf("Failed to get holder ConfigMap: %v", err)
+					return ctx
+				}
+				currentHolder.Finalizers = nil
+				if err := cfg.Client().Resources().Update(ctx, &currentHolder); err != nil {
+					t.Logf("Failed to remove finalizer from holder ConfigMap: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, terminatingFeature)
+}
+
+// waitForNamespacePhase waits for a namespace to reach the given phase
+func waitForNamespacePhase(ctx context.Context, client *resources.Resources, name string, phase corev1.NamespacePhase) error {
+	return wait.PollUntilContextTimeout(ctx, 1*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var namespace corev1.Namespace
+		if err := client.Get(ctx, name, "", &namespace); err != nil {
+			return false, err
+		}
+
+		return namespace.Status.Phase == phase, nil
+	})
+}
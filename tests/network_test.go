@@ -1,40 +1,50 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
 	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 	"sigs.k8s.io/e2e-framework/pkg/features"
 )
 
 func TestNetworkConnectivity(t *testing.T) {
-	start := time.Now()
 	deploymentKey := any("deployment-key")
 	serviceKey := any("service-key")
 
-	t.Cleanup(func() {
-		metricsCollector.RecordTestExecution(testContext, t, time.Since(start))
-	})
+	trackTest(t)
 
 	networkFeature := features.New("network/connectivity").
 		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
 			// Create nginx deployment
 			deployment := newNetworkDeployment(cfg.Namespace(), "network-test-nginx")
+			createdAt := time.Now()
 			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
 				t.Fatal(err)
 			}
 			ctx = context.WithValue(ctx, deploymentKey, deployment)
 
 			// Wait for deployment to be ready
-			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment); err != nil {
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
 				t.Fatalf("Deployment not ready: %v", err)
 			}
 
@@ -52,12 +62,9 @@ func TestNetworkConnectivity(t *testing.T) {
 
 			// Create a temporary client pod to test connectivity
 			clientPod := newClientPod(cfg.Namespace(), "network-test-client", service.Name)
-			if err := cfg.Client().Resources().Create(ctx, clientPod); err != nil {
-				t.Fatal(err)
-			}
 
-			// Wait for client pod to complete
-			if err := waitForPodCompletion(ctx, cfg.Client().Resources(), clientPod); err != nil {
+			// Run client pod to completion
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 5*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
 				t.Fatalf("Client pod did not complete: %v", err)
 			}
 
@@ -112,6 +119,766 @@ func TestNetworkConnectivity(t *testing.T) {
 	testenv.Test(t, networkFeature)
 }
 
+func TestExternalIPs(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	externalIP := os.Getenv("EXTERNAL_IP")
+
+	trackTest(t)
+
+	if externalIP == "" {
+		skipTest(t, skipReasonNotConfigured, "EXTERNAL_IP not set, skipping externalIPs routing test")
+	}
+
+	externalIPFeature := features.New("network/external-ips").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create nginx deployment with its own app label
+			deployment := newNetworkDeployment(cfg.Namespace(), "external-ip-test-nginx")
+			setDeploymentAppLabel(deployment, "external-ip-test")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			// Wait for deployment to be ready
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			// Create service exposed on the external IP
+			service := newNetworkService(cfg.Namespace(), "external-ip-test-service")
+			service.Spec.Selector = map[string]string{"app": "external-ip-test"}
+			service.Spec.ExternalIPs = []string{externalIP}
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatalf("Failed to create service with externalIPs (is the DenyServiceExternalIPs admission plugin enabled?): %v", err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			return ctx
+		}).
+		Assess("service carries the external IP", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			var currentService corev1.Service
+			if err := cfg.Client().Resources().Get(ctx, service.Name, service.Namespace, &currentService); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Contains(currentService.Spec.ExternalIPs, externalIP) {
+				t.Fatalf("Service %s externalIPs %v do not contain %s", service.Name, currentService.Spec.ExternalIPs, externalIP)
+			}
+			t.Logf("✓ Service %s exposes external IP %s", service.Name, externalIP)
+
+			return ctx
+		}).
+		Assess("external IP routes to the backend", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			clientPod := newClientPod(cfg.Namespace(), "external-ip-test-client", externalIP)
+			defer func() {
+				if err := cfg.Client().Resources().Delete(ctx, clientPod); err != nil {
+					t.Logf("Failed to delete client pod: %v", err)
+				}
+			}()
+
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 5*time.Minute); err != nil {
+				t.Fatalf("Request to %s:80 did not reach the backend: %v", externalIP, err)
+			}
+			t.Logf("✓ Request to %s:80 reached the backend", externalIP)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				// Release the external IP before deleting the service
+				patch := k8s.Patch{PatchType: types.MergePatchType, Data: []byte(`{"spec":{"externalIPs":null}}`)}
+				if err := cfg.Client().Resources().Patch(ctx, service, patch); err != nil {
+					t.Logf("Failed to remove externalIPs from service: %v", err)
+				}
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, externalIPFeature)
+}
+
+func TestLoadBalancerService(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	if os.Getenv("SKIP_LB_TESTS") == "true" {
+		skipTest(t, skipReasonDisabled, "SKIP_LB_TESTS set to true, skipping LoadBalancer test")
+	}
+
+	loadBalancerFeature := features.New("network/loadbalancer").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create nginx deployment with its own app label
+			deployment := newNetworkDeployment(cfg.Namespace(), "lb-test-nginx")
+			setDeploymentAppLabel(deployment, "lb-test")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			service := newNetworkService(cfg.Namespace(), "lb-test-service")
+			service.Spec.Selector = map[string]string{"app": "lb-test"}
+			service.Spec.Type = corev1.ServiceTypeLoadBalancer
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			return ctx
+		}).
+		Assess("load balancer address is assigned", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			address, err := waitForLoadBalancerAddress(ctx, cfg.Client().Resources(), service)
+			if err != nil {
+				t.Fatalf("Service %s was not assigned a load balancer address: %v", service.Name, err)
+			}
+			t.Logf("✓ Service %s is exposed at %s", service.Name, address)
+
+			return ctx
+		}).
+		Assess("service is reachable through the load balancer", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			// The address was published by the previous assessment
+			address, err := waitForLoadBalancerAddress(ctx, cfg.Client().Resources(), service)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addr, err := netip.ParseAddr(address); err == nil && addr.Is6() {
+				address = "[" + address + "]"
+			}
+
+			pod, err := curlUntilSuccess(ctx, t, cfg, "lb-test-client", "http://"+address+"/")
+			ctx = context.WithValue(ctx, podKey, pod)
+			if err != nil {
+				t.Fatalf("HTTP request to load balancer %s did not return 200: %v", address, err)
+			}
+			t.Logf("✓ HTTP request to load balancer %s returned 200", address)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete client pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete client pod: %v", err)
+				}
+			}
+
+			// Delete service, releasing the load balancer
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, loadBalancerFeature)
+}
+
+func TestPodNetworkStatus(t *testing.T) {
+	podKey := any("pod-key")
+	podCIDRs := os.Getenv("POD_CIDR")
+
+	trackTest(t)
+
+	networkStatusFeature := features.New("network/pod-network-status").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newSchedulingPod(cfg.Namespace(), "network-status-test")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("pod and host IPs are valid and distinct", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+			status := currentPod.Status
+
+			if _, err := netip.ParseAddr(status.PodIP); err != nil {
+				t.Fatalf("Pod %s reports an invalid podIP %q: %v", pod.Name, status.PodIP, err)
+			}
+			if len(status.PodIPs) == 0 || status.PodIPs[0].IP != status.PodIP {
+				t.Fatalf("Expected podIPs %v to start with podIP %s", status.PodIPs, status.PodIP)
+			}
+			seen := map[netip.Addr]bool{}
+			for _, ip := range status.PodIPs {
+				addr, err := netip.ParseAddr(ip.IP)
+				if err != nil {
+					t.Fatalf("Pod %s reports an invalid podIPs entry %q: %v", pod.Name, ip.IP, err)
+				}
+				if seen[addr] {
+					t.Fatalf("Pod %s reports podIP %s twice", pod.Name, addr)
+				}
+				seen[addr] = true
+			}
+
+			hostIP, err := netip.ParseAddr(status.HostIP)
+			if err != nil {
+				t.Fatalf("Pod %s reports an invalid hostIP %q: %v", pod.Name, status.HostIP, err)
+			}
+			if len(status.HostIPs) > 0 && status.HostIPs[0].IP != status.HostIP {
+				t.Fatalf("Expected hostIPs %v to start with hostIP %s", status.HostIPs, status.HostIP)
+			}
+			if seen[hostIP] {
+				t.Fatalf("Pod %s without host networking shares IP %s with its host", pod.Name, hostIP)
+			}
+			t.Logf("✓ Pod %s has podIPs %v and hostIP %s", pod.Name, status.PodIPs, hostIP)
+
+			return ctx
+		}).
+		Assess("hostIP matches the node internal IP", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+			var node corev1.Node
+			if err := cfg.Client().Resources().Get(ctx, currentPod.Spec.NodeName, "", &node); err != nil {
+				t.Fatal(err)
+			}
+
+			var internalIPs []string
+			for _, address := range node.Status.Addresses {
+				if address.Type == corev1.NodeInternalIP {
+					internalIPs = append(internalIPs, address.Address)
+				}
+			}
+			if !slices.Contains(internalIPs, currentPod.Status.HostIP) {
+				t.Fatalf("HostIP %s of pod %s is not an internal IP of node %s: %v",
+					currentPod.Status.HostIP, pod.Name, node.Name, internalIPs)
+			}
+			t.Logf("✓ HostIP %s is an internal IP of node %s", currentPod.Status.HostIP, node.Name)
+
+			return ctx
+		}).
+		Assess("podIPs are within the pod CIDR", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+
+			// Prefer the configured cluster CIDR, as some CNI plugins allocate pod IPs outside the node podCIDRs
+			var cidrs []string
+			if podCIDRs != "" {
+				cidrs = strings.Split(podCIDRs, ",")
+			} else {
+				var node corev1.Node
+				if err := cfg.Client().Resources().Get(ctx, currentPod.Spec.NodeName, "", &node); err != nil {
+					t.Fatal(err)
+				}
+				cidrs = node.Spec.PodCIDRs
+			}
+			if len(cidrs) == 0 {
+				skipTest(t, skipReasonNotConfigured, "POD_CIDR not set and node has no podCIDRs, skipping pod CIDR check")
+			}
+
+			for _, ip := range currentPod.Status.PodIPs {
+				within, err := ipInCIDRs(ip.IP, cidrs)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !within {
+					t.Fatalf("PodIP %s of pod %s is outside the pod CIDRs %v", ip.IP, pod.Name, cidrs)
+				}
+			}
+			t.Logf("✓ PodIPs %v are within the pod CIDRs %v", currentPod.Status.PodIPs, cidrs)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, networkStatusFeature)
+}
+
+func TestPodCIDRCompliance(t *testing.T) {
+	podCIDRs := os.Getenv("POD_CIDR")
+
+	trackTest(t)
+
+	complianceFeature := features.New("network/pod-cidr-compliance").
+		Assess("all pod IPs are within the cluster pod CIDR", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			cidrs, source, err := clusterPodCIDRs(ctx, cfg, podCIDRs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cidrs) == 0 {
+				skipTest(t, skipReasonNotConfigured, "Pod CIDR not found in POD_CIDR, kubeadm-config or node podCIDRs, skipping")
+			}
+			t.Logf("Using pod CIDRs %v from %s", cidrs, source)
+
+			var pods corev1.PodList
+			if err := cfg.Client().Resources().List(ctx, &pods); err != nil {
+				t.Fatalf("Failed to list pods: %v", err)
+			}
+
+			checked := 0
+			var violations []string
+			for _, pod := range pods.Items {
+				// Host network pods report the node IP
+				if pod.Status.Phase != corev1.PodRunning || pod.Spec.HostNetwork {
+					continue
+				}
+				for _, ip := range pod.Status.PodIPs {
+					within, err := ipInCIDRs(ip.IP, cidrs)
+					if err != nil {
+						t.Fatalf("Invalid pod CIDR or pod IP of %s/%s: %v", pod.Namespace, pod.Name, err)
+					}
+					if !within {
+						violations = append(violations, fmt.Sprintf("%s/%s (%s)", pod.Namespace, pod.Name, ip.IP))
+					}
+				}
+				checked++
+			}
+			metricsCollector.RecordPodCIDRViolations(ctx, len(violations))
+
+			if len(violations) > 0 {
+				t.Fatalf("%d pod IPs are outside the pod CIDRs %v:\n%s", len(violations), cidrs, strings.Join(violations, "\n"))
+			}
+			t.Logf("✓ IPs of %d running pods are within the pod CIDRs %v", checked, cidrs)
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, complianceFeature)
+}
+
+func TestCNIPluginIdentity(t *testing.T) {
+	expectedPlugin := os.Getenv("EXPECTED_CNI_PLUGIN")
+	expectedVersion := os.Getenv("EXPECTED_CNI_VERSION")
+
+	trackTest(t)
+
+	if expectedPlugin == "" && expectedVersion == "" {
+		skipTest(t, skipReasonNotConfigured, "Neither EXPECTED_CNI_PLUGIN nor EXPECTED_CNI_VERSION set, skipping CNI identity test")
+	}
+
+	cniFeature := features.New("network/cni-identity").
+		Assess("nodes run the expected CNI plugin", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes.Items) == 0 {
+				t.Fatal("No nodes found")
+			}
+
+			var plugin *cniPlugin
+			for _, node := range nodes.Items {
+				detected := detectCNIPlugin(node.Annotations)
+				if detected == nil {
+					t.Fatalf("Could not detect the CNI plugin of node %s from its annotations", node.Name)
+				}
+				if plugin != nil && detected.name != plugin.name {
+					t.Fatalf("Node %s runs CNI plugin %s while other nodes run %s", node.Name, detected.name, plugin.name)
+				}
+				plugin = detected
+			}
+			t.Logf("Detected CNI plugin %s on %d nodes", plugin.name, len(nodes.Items))
+
+			if expectedPlugin != "" && plugin.name != expectedPlugin {
+				t.Fatalf("Expected CNI plugin %s, detected %s", expectedPlugin, plugin.name)
+			}
+
+			if expectedVersion != "" {
+				// Nodes carry no version annotation, the plugin DaemonSet image tag is the version
+				var daemonSets appsv1.DaemonSetList
+				if err := cfg.Client().Resources().List(ctx, &daemonSets); err != nil {
+					t.Fatal(err)
+				}
+				version, ok := cniPluginVersion(plugin, daemonSets.Items)
+				if !ok {
+					t.Fatalf("No %s DaemonSet (%v) found to read the CNI version from", plugin.name, plugin.daemonSets)
+				}
+				if version != expectedVersion {
+					t.Fatalf("Expected %s version %s, found %s", plugin.name, expectedVersion, version)
+				}
+				t.Logf("✓ CNI plugin %s runs version %s", plugin.name, version)
+			}
+			t.Logf("✓ CNI plugin %s matches the expected configuration", plugin.name)
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, cniFeature)
+}
+
+// Packet loss simulated by TestPacketLossResilience and the share of requests that must succeed despite it
+const (
+	packetLossPercent      = 50
+	packetLossRequests     = 100
+	packetLossMinSuccesses = 95
+	// packetLossClientImage provides tc and curl to the client pod
+	packetLossClientImage = "nicolaka/netshoot:v0.13"
+)
+
+func TestPacketLossResilience(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	// The client needs NET_ADMIN, which restricted clusters do not grant
+	if os.Getenv("PACKET_LOSS_TEST") != "true" {
+		skipTest(t, skipReasonOptIn, "PACKET_LOSS_TEST not set to true, skipping packet loss resilience test")
+	}
+
+	packetLossFeature := features.New("network/packet-loss").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			deployment := newNetworkDeployment(cfg.Namespace(), "packet-loss-nginx")
+			setDeploymentAppLabel(deployment, "packet-loss-test")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			service := newNetworkService(cfg.Namespace(), "packet-loss-service")
+			service.Spec.Selector = map[string]string{"app": "packet-loss-test"}
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			pod := newPacketLossClientPod(cfg.Namespace(), "packet-loss-client", packetLossPercent)
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Client pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("netem drops packets of the client", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var stdout, stderr bytes.Buffer
+			command := []string{"tc", "qdisc", "show", "dev", "eth0"}
+			if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, "client", command, &stdout, &stderr); err != nil {
+				t.Fatalf("Failed to show the qdisc of pod %s: %v: %s", pod.Name, err, stderr.String())
+			}
+			if expected := fmt.Sprintf("loss %d%%", packetLossPercent); !strings.Contains(stdout.String(), expected) {
+				t.Fatalf("Expected the eth0 qdisc of pod %s to contain %q, got %q", pod.Name, expected, stdout.String())
+			}
+			t.Logf("✓ Pod %s drops %d%% of its outgoing packets", pod.Name, packetLossPercent)
+
+			return ctx
+		}).
+		Assess("requests with retries succeed despite packet loss", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			// curl retries failed connections and transfers, printing one status line per request
+			script := fmt.Sprintf("for i in $(seq %d); do "+
+				"curl -s -o /dev/null --retry 5 --retry-all-errors --retry-delay 1 --connect-timeout 3 --max-time 30 "+
+				"-w '%%{http_code} %%{time_total}\\n' http://%s/ || true; done", packetLossRequests, service.Name)
+
+			execCtx, cancel := context.WithTimeout(ctx, 15*time.Minute)
+			defer cancel()
+			var stdout, stderr bytes.Buffer
+			command := []string{"sh", "-c", script}
+			if err := cfg.Client().Resources().ExecInPod(execCtx, pod.Namespace, pod.Name, "client", command, &stdout, &stderr); err != nil {
+				t.Fatalf("Failed to run requests from pod %s: %v: %s", pod.Name, err, stderr.String())
+			}
+
+			latencies := parseCurlTimings(stdout.String())
+			for _, latency := range latencies {
+				metricsCollector.RecordNetworkRTTWithLoss(ctx, packetLossPercent, latency)
+			}
+			if len(latencies) < packetLossMinSuccesses {
+				t.Fatalf("Only %d of %d requests succeeded under %d%% packet loss, expected at least %d:\n%s",
+					len(latencies), packetLossRequests, packetLossPercent, packetLossMinSuccesses, stdout.String())
+			}
+			t.Logf("✓ %d of %d requests succeeded under %d%% packet loss, slowest took %s",
+				len(latencies), packetLossRequests, packetLossPercent, slices.Max(latencies))
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				// Remove the netem qdisc before the pod goes away, so a stuck deletion keeps no lossy link
+				var stdout, stderr bytes.Buffer
+				command := []string{"tc", "qdisc", "del", "dev", "eth0", "root"}
+				if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, "client", command, &stdout, &stderr); err != nil {
+					t.Logf("Failed to remove the netem qdisc of pod %s: %v: %s", pod.Name, err, stderr.String())
+				}
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete client pod: %v", err)
+				}
+			}
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, packetLossFeature)
+}
+
+// newPacketLossClientPod creates a pod adding a netem qdisc dropping lossPercent of the packets
+// leaving its eth0 interface, then idling so that requests can be run from it
+func newPacketLossClientPod(namespace, name string, lossPercent int) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "packet-loss-client"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "client",
+					Image: packetLossClientImage,
+					Command: []string{
+						"sh", "-c",
+						fmt.Sprintf("tc qdisc add dev eth0 root netem loss %d%% && sleep 3600", lossPercent),
+					},
+					// tc needs NET_ADMIN on the pod network namespace
+					SecurityContext: &corev1.SecurityContext{
+						Capabilities: &corev1.Capabilities{
+							Add: []corev1.Capability{"NET_ADMIN"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// parseCurlTimings returns the total time of the successful requests in curl output written with
+// -w '%{http_code} %{time_total}\n'
+func parseCurlTimings(output string) []time.Duration {
+	var latencies []time.Duration
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "200" {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		latencies = append(latencies, time.Duration(seconds*float64(time.Second)))
+	}
+
+	return latencies
+}
+
+func TestParseCurlTimings(t *testing.T) {
+	unitTest(t)
+
+	output := "200 0.25\n000 30.001\n503 0.5\n200 1.5\n\ngarbage\n"
+	expected := []time.Duration{250 * time.Millisecond, 1500 * time.Millisecond}
+
+	if latencies := parseCurlTimings(output); !slices.Equal(latencies, expected) {
+		t.Errorf("parseCurlTimings(%q) = %v, expected %v", output, latencies, expected)
+	}
+}
+
+// serviceUpdatePropagationSLO is the time within which kube-proxy must route a service to the
+// backends of its updated selector
+const serviceUpdatePropagationSLO = 5 * time.Second
+
+func TestServiceUpdatePropagation(t *testing.T) {
+	deploymentsKey := any("deployments-key")
+	serviceKey := any("service-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	propagationFeature := features.New("network/service-update-propagation").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Backend A answers backend-8081, backend B backend-8082
+			var deployments []*appsv1.Deployment
+			createdAt := time.Now()
+			for _, backend := range []struct {
+				app  string
+				port int32
+			}{{"propagation-a", 8081}, {"propagation-b", 8082}} {
+				deployment := newNamedPortDeployment(cfg.Namespace(), backend.app, backend.app, backend.port)
+				if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+					t.Fatal(err)
+				}
+				deployments = append(deployments, deployment)
+			}
+			ctx = context.WithValue(ctx, deploymentsKey, deployments)
+
+			for _, deployment := range deployments {
+				if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+					t.Fatalf("Deployment %s not ready: %v", deployment.Name, err)
+				}
+			}
+
+			service := newNetworkService(cfg.Namespace(), "propagation-service")
+			service.Spec.Selector = map[string]string{"app": "propagation-a"}
+			service.Spec.Ports[0].Name = "http"
+			service.Spec.Ports[0].TargetPort = intstr.FromString("http")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			pod := newClientPod(cfg.Namespace(), "propagation-client", service.Name)
+			pod.Spec.Containers[0].Command = []string{"sleep", "3600"}
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Client pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("service routes to backend A", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var stdout, stderr bytes.Buffer
+			command := []string{"curl", "-s", "--max-time", "5", "--retry", "5", "--retry-all-errors", "http://" + service.Name}
+			if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, "curl-test", command, &stdout, &stderr); err != nil {
+				t.Fatalf("Failed to reach service %s: %v: %s", service.Name, err, stderr.String())
+			}
+			if response := strings.TrimSpace(stdout.String()); response != "backend-8081" {
+				t.Fatalf("Expected service %s to route to backend A (backend-8081), got %q", service.Name, response)
+			}
+			t.Logf("✓ Service %s routes to backend A", service.Name)
+
+			return ctx
+		}).
+		Assess("selector update reaches kube-proxy within SLO", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			updatedAt := time.Now()
+			patch := k8s.Patch{PatchType: types.MergePatchType, Data: []byte(`{"spec":{"selector":{"app":"propagation-b"}}}`)}
+			if err := cfg.Client().Resources().Patch(ctx, service, patch); err != nil {
+				t.Fatalf("Failed to update the selector of service %s: %v", service.Name, err)
+			}
+
+			// Request the service in a tight loop, each request being timestamped when its exec returns
+			var switchedAt time.Time
+			err := wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				var stdout, stderr bytes.Buffer
+				command := []string{"curl", "-s", "--max-time", "1", "http://" + service.Name}
+				if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, "curl-test", command, &stdout, &stderr); err != nil {
+					return false, nil
+				}
+				if strings.TrimSpace(stdout.String()) != "backend-8082" {
+					return false, nil
+				}
+
+				switchedAt = time.Now()
+				return true, nil
+			})
+			if err != nil {
+				t.Fatalf("Service %s never routed to backend B after the selector update: %v", service.Name, err)
+			}
+
+			latency := switchedAt.Sub(updatedAt)
+			metricsCollector.RecordServiceUpdatePropagation(ctx, latency)
+			if latency > serviceUpdatePropagationSLO {
+				t.Fatalf("Service %s switched to backend B %s after the selector update, SLO is %s",
+					service.Name, latency.Round(time.Millisecond), serviceUpdatePropagationSLO)
+			}
+			t.Logf("✓ Service %s switched to backend B %s after the selector update", service.Name, latency.Round(time.Millisecond))
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete client pod: %v", err)
+				}
+			}
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+			deployments, _ := ctx.Value(deploymentsKey).([]*appsv1.Deployment)
+			for _, deployment := range deployments {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment %s: %v", deployment.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, propagationFeature)
+}
+
 // newNetworkDeployment creates an nginx deployment for network testing
 func newNetworkDeployment(namespace, name string) *appsv1.Deployment {
 	replicas := int32(1)
@@ -235,9 +1002,35 @@ func newClientPod(namespace, name, serviceName string) *corev1.Pod {
 	}
 }
 
-// waitForDeploymentReady waits for a deployment to be ready
-func waitForDeploymentReady(ctx context.Context, client *resources.Resources, deployment *appsv1.Deployment) error {
-	return wait.PollUntilContextTimeout(ctx, 5*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+// waitForLoadBalancerAddress waits up to 5 minutes for a LoadBalancer service to be assigned an
+// address and returns its IP, or hostname when the load balancer has no IP
+func waitForLoadBalancerAddress(ctx context.Context, client *resources.Resources, service *corev1.Service) (string, error) {
+	var address string
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var currentService corev1.Service
+		if err := client.Get(ctx, service.Name, service.Namespace, &currentService); err != nil {
+			return false, err
+		}
+
+		for _, lb := range currentService.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				address = lb.IP
+				return true, nil
+			}
+			if lb.Hostname != "" {
+				address = lb.Hostname
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+
+	return address, err
+}
+
+// waitForDeploymentReady waits for a deployment created at createdAt to be ready
+func waitForDeploymentReady(ctx context.Context, client *resources.Resources, deployment *appsv1.Deployment, createdAt time.Time) error {
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
 		var currentDeployment appsv1.Deployment
 		if err := client.Get(ctx, deployment.Name, deployment.Namespace, &currentDeployment); err != nil {
 			return false, err
@@ -246,4 +1039,222 @@ func waitForDeploymentReady(ctx context.Context, client *resources.Resources, de
 		// Check if all replicas are ready
 		return currentDeployment.Status.ReadyReplicas == *currentDeployment.Spec.Replicas, nil
 	})
+	if err == nil {
+		recordResourceCreation(ctx, "Deployment", deployment.Name, createdAt)
+	}
+
+	return err
+}
+
+// cniPlugin identifies a CNI plugin by the annotations it sets on nodes and the DaemonSets it runs
+type cniPlugin struct {
+	name              string
+	annotationPrefix  string
+	daemonSets        []string
+	versionContainers []string
+}
+
+// cniPlugins are the CNI plugins detectCNIPlugin knows about
+var cniPlugins = []cniPlugin{
+	{name: "calico", annotationPrefix: "projectcalico.org/", daemonSets: []string{"calico-node"}, versionContainers: []string{"calico-node"}},
+	{name: "cilium", annotationPrefix: "network.cilium.io/", daemonSets: []string{"cilium"}, versionContainers: []string{"cilium-agent"}},
+	{name: "ovn-kubernetes", annotationPrefix: "k8s.ovn.org/", daemonSets: []string{"ovnkube-node"}, versionContainers: []string{"ovnkube-controller", "ovnkube-node"}},
+	{name: "flannel", annotationPrefix: "flannel.alpha.coreos.com/", daemonSets: []string{"kube-flannel-ds"}, versionContainers: []string{"kube-flannel"}},
+}
+
+// detectCNIPlugin returns the CNI plugin whose annotations a node carries, or nil if none matches
+func detectCNIPlugin(annotations map[string]string) *cniPlugin {
+	for i := range cniPlugins {
+		for key := range annotations {
+			if strings.HasPrefix(key, cniPlugins[i].annotationPrefix) {
+				return &cniPlugins[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// cniPluginVersion returns the image tag of the plugin's DaemonSet container
+func cniPluginVersion(plugin *cniPlugin, daemonSets []appsv1.DaemonSet) (string, bool) {
+	for _, daemonSet := range daemonSets {
+		if !slices.Contains(plugin.daemonSets, daemonSet.Name) {
+			continue
+		}
+		for _, container := range daemonSet.Spec.Template.Spec.Containers {
+			if !slices.Contains(plugin.versionContainers, container.Name) {
+				continue
+			}
+			// Strip any digest, then keep what follows the last colon after the last slash
+			image, _, _ := strings.Cut(container.Image, "@")
+			if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+				return image[i+1:], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func TestCNIPluginVersion(t *testing.T) {
+	unitTest(t)
+
+	tests := []struct {
+		name            string
+		image           string
+		expectedVersion string
+		expectedFound   bool
+	}{
+		{name: "tagged image", image: "quay.io/calico/node:v3.28.1", expectedVersion: "v3.28.1", expectedFound: true},
+		{name: "tag and digest", image: "quay.io/calico/node:v3.28.1@sha256:0123abcd", expectedVersion: "v3.28.1", expectedFound: true},
+		{name: "registry port without tag", image: "registry.local:5000/calico/node", expectedFound: false},
+	}
+
+	plugin := detectCNIPlugin(map[string]string{"projectcalico.org/IPv4Address": "10.0.0.1/24"})
+	if plugin == nil || plugin.name != "calico" {
+		t.Fatalf("expected calico to be detected, got %v", plugin)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemonSet := appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "calico-node"}}
+			daemonSet.Spec.Template.Spec.Containers = []corev1.Container{{Name: "calico-node", Image: tt.image}}
+
+			version, found := cniPluginVersion(plugin, []appsv1.DaemonSet{daemonSet})
+			if found != tt.expectedFound || version != tt.expectedVersion {
+				t.Errorf("expected (%q, %t), got (%q, %t)", tt.expectedVersion, tt.expectedFound, version, found)
+			}
+		})
+	}
+}
+
+// ipInCIDRs reports whether ip is within any of the given CIDRs
+func ipInCIDRs(ip string, cidrs []string) (bool, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, err
+	}
+
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return false, err
+		}
+		if prefix.Contains(addr) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func TestIPInCIDRs(t *testing.T) {
+	unitTest(t)
+
+	tests := []struct {
+		name     string
+		ip       string
+		cidrs    []string
+		expected bool
+		wantErr  bool
+	}{
+		{name: "ipv4 within", ip: "10.244.1.5", cidrs: []string{"10.244.0.0/16"}, expected: true},
+		{name: "ipv4 outside", ip: "10.96.0.10", cidrs: []string{"10.244.0.0/16"}, expected: false},
+		{name: "dual stack ipv6", ip: "fd00:10:244::5", cidrs: []string{"10.244.0.0/16", " fd00:10:244::/56"}, expected: true},
+		{name: "invalid ip", ip: "not-an-ip", cidrs: []string{"10.244.0.0/16"}, wantErr: true},
+		{name: "invalid cidr", ip: "10.244.1.5", cidrs: []string{"10.244.0.0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			within, err := ipInCIDRs(tt.ip, tt.cidrs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if within != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, within)
+			}
+		})
+	}
+}
+
+// kubeadmPodSubnetPattern matches the pod subnet of a kubeadm ClusterConfiguration
+var kubeadmPodSubnetPattern = regexp.MustCompile(`(?m)^\s*podSubnet:\s*"?([^"\s]+)"?\s*$`)
+
+// clusterPodCIDRs returns the cluster pod CIDRs and where they were found: the configured value when
+// set, otherwise the kubeadm-config ConfigMap, otherwise the podCIDRs of all nodes
+func clusterPodCIDRs(ctx context.Context, cfg *envconf.Config, configured string) ([]string, string, error) {
+	if configured != "" {
+		return strings.Split(configured, ","), "POD_CIDR", nil
+	}
+
+	var kubeadmConfig corev1.ConfigMap
+	err := cfg.Client().Resources().Get(ctx, "kubeadm-config", "kube-system", &kubeadmConfig)
+	switch {
+	case err == nil:
+		if subnet := parseKubeadmPodSubnet(kubeadmConfig.Data["ClusterConfiguration"]); len(subnet) > 0 {
+			return subnet, "kubeadm-config", nil
+		}
+	case !apierrors.IsNotFound(err) && !apierrors.IsForbidden(err):
+		return nil, "", fmt.Errorf("failed to get kubeadm-config: %w", err)
+	}
+
+	var nodes corev1.NodeList
+	if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+		return nil, "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var cidrs []string
+	for _, node := range nodes.Items {
+		for _, cidr := range node.Spec.PodCIDRs {
+			if !slices.Contains(cidrs, cidr) {
+				cidrs = append(cidrs, cidr)
+			}
+		}
+	}
+
+	return cidrs, "node podCIDRs", nil
+}
+
+// parseKubeadmPodSubnet returns the pod subnets of a kubeadm ClusterConfiguration, comma-separated when dual stack
+func parseKubeadmPodSubnet(clusterConfiguration string) []string {
+	match := kubeadmPodSubnetPattern.FindStringSubmatch(clusterConfiguration)
+	if match == nil {
+		return nil
+	}
+
+	return strings.Split(match[1], ",")
+}
+
+func TestParseKubeadmPodSubnet(t *testing.T) {
+	unitTest(t)
+
+	tests := []struct {
+		name     string
+		config   string
+		expected []string
+	}{
+		{
+			name:     "single stack",
+			config:   "apiVersion: kubeadm.k8s.io/v1beta3\nnetworking:\n  dnsDomain: cluster.local\n  podSubnet: 10.244.0.0/16\n  serviceSubnet: 10.96.0.0/12\n",
+			expected: []string{"10.244.0.0/16"},
+		},
+		{
+			name:     "dual stack quoted",
+			config:   "networking:\n  podSubnet: \"10.244.0.0/16,fd00:10:244::/56\"\n",
+			expected: []string{"10.244.0.0/16", "fd00:10:244::/56"},
+		},
+		{
+			name:     "no pod subnet",
+			config:   "networking:\n  serviceSubnet: 10.96.0.0/12\n",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKubeadmPodSubnet(tt.config); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
 }
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestNetworkPolicyEnforcement(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	denyPolicyKey := any("deny-policy-key")
+	allowPolicyKey := any("allow-policy-key")
+
+	trackTest(t)
+
+	networkPolicyFeature := features.New("network/policy-enforcement").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			available, err := apiResourceAvailable(cfg, networkingv1.SchemeGroupVersion.String(), "networkpolicies")
+			if err != nil {
+				t.Fatalf("Failed to discover networking.k8s.io API: %v", err)
+			}
+			if !available {
+				skipTest(t, skipReasonAPIUnavailable, "networking.k8s.io/v1 NetworkPolicy API not available, skipping")
+			}
+
+			// Create nginx deployment
+			deployment := newNetworkDeployment(cfg.Namespace(), "netpol-test-nginx")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			// Wait for deployment to be ready
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			// Create service
+			service := newNetworkService(cfg.Namespace(), "netpol-test-service")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			// Deny all ingress traffic in the namespace
+			denyPolicy := newDenyAllIngressPolicy(cfg.Namespace(), "netpol-test-deny-all")
+			if err := cfg.Client().Resources().Create(ctx, denyPolicy); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, denyPolicyKey, denyPolicy)
+
+			return ctx
+		}).
+		Assess("default deny blocks traffic", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			clientPod := newClientPod(cfg.Namespace(), "netpol-test-client-denied", service.Name)
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatal(err)
+			}
+
+			if !podFailedAsExpected(ctx, cfg.Client().Resources(), clientPod) {
+				t.Fatal("Client pod reached the service despite the default-deny ingress policy")
+			}
+			t.Log("✓ Default-deny ingress policy blocked traffic to the service")
+
+			if err := cfg.Client().Resources().Delete(ctx, clientPod); err != nil {
+				t.Logf("Failed to delete client pod: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("allow policy restores traffic", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			allowPolicy := newAllowIngressPolicy(cfg.Namespace(), "netpol-test-allow-client",
+				map[string]string{"app": "network-test"},
+				map[string]string{"app": "network-test-client"})
+			if err := cfg.Client().Resources().Create(ctx, allowPolicy); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, allowPolicyKey, allowPolicy)
+
+			clientPod := newClientPod(cfg.Namespace(), "netpol-test-client-allowed", service.Name)
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatal(err)
+			}
+
+			if podFailedAsExpected(ctx, cfg.Client().Resources(), clientPod) {
+				t.Fatal("Client pod could not reach the service despite the allow policy")
+			}
+			t.Log("✓ Allow policy restored traffic from the client to the service")
+
+			if err := cfg.Client().Resources().Delete(ctx, clientPod); err != nil {
+				t.Logf("Failed to delete client pod: %v", err)
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete network policies
+			for _, key := range []any{allowPolicyKey, denyPolicyKey} {
+				if policy, ok := ctx.Value(key).(*networkingv1.NetworkPolicy); ok && policy != nil {
+					if err := cfg.Client().Resources().Delete(ctx, policy); err != nil {
+						t.Logf("Failed to delete network policy %s: %v", policy.Name, err)
+					}
+				}
+			}
+
+			// Delete service
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, networkPolicyFeature)
+}
+
+func TestDefaultNetworkPolicy(t *testing.T) {
+	namespaceKey := any("namespace-key")
+	expectDenyKey := any("expect-deny-key")
+	expectDefaultPolicy := os.Getenv("DEFAULT_NETWORK_POLICY") == "true"
+
+	trackTest(t)
+
+	defaultPolicyFeature := features.New("network/default-policy").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   names.randomName("default-netpol", 24),
+					Labels: map[string]string{"app": "network-test"},
+				},
+			}
+			if err := cfg.Client().Resources().Create(ctx, namespace); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, namespaceKey, namespace)
+
+			return ctx
+		}).
+		Assess("default policy presence", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			namespace := ctx.Value(namespaceKey).(*corev1.Namespace)
+
+			// Policy controllers install default policies asynchronously after namespace creation
+			var policies networkingv1.NetworkPolicyList
+			timeout := 5 * time.Second
+			if expectDefaultPolicy {
+				timeout = 1 * time.Minute
+			}
+			_ = wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+				if err := cfg.Client().Resources(namespace.Name).List(ctx, &policies); err != nil {
+					return false, err
+				}
+				return len(policies.Items) > 0, nil
+			})
+
+			if expectDefaultPolicy && len(policies.Items) == 0 {
+				t.Fatalf("Expected a default NetworkPolicy in namespace %s, found none", namespace.Name)
+			}
+			for _, policy := range policies.Items {
+				t.Logf("Found default NetworkPolicy %s in namespace %s", policy.Name, namespace.Name)
+			}
+
+			expectDeny := deniesAllIngress(policies.Items)
+			t.Logf("Default posture for namespace %s: deny-all ingress=%t", namespace.Name, expectDeny)
+
+			return context.WithValue(ctx, expectDenyKey, expectDeny)
+		}).
+		Assess("default policy effect", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			namespace := ctx.Value(namespaceKey).(*corev1.Namespace)
+			expectDeny, _ := ctx.Value(expectDenyKey).(bool)
+
+			deployment := newNetworkDeployment(namespace.Name, "default-netpol-nginx")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			service := newNetworkService(namespace.Name, "default-netpol-service")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+
+			clientPod := newClientPod(namespace.Name, "default-netpol-client", service.Name)
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatal(err)
+			}
+
+			blocked := podFailedAsExpected(ctx, cfg.Client().Resources(), clientPod)
+			switch {
+			case expectDeny && !blocked:
+				t.Fatal("Client pod reached the service although the default policy denies all ingress")
+			case !expectDeny && blocked:
+				t.Fatal("Client pod could not reach the service although no default policy denies ingress")
+			case expectDeny:
+				t.Log("✓ Default policy denied pod-to-pod traffic")
+			default:
+				t.Log("✓ Pod-to-pod traffic allowed by default")
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Deleting the namespace removes everything created inside it
+			if namespace := ctx.Value(namespaceKey).(*corev1.Namespace); namespace != nil {
+				if err := cfg.Client().Resources().Delete(ctx, namespace); err != nil {
+					t.Logf("Failed to delete namespace %s: %v", namespace.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, defaultPolicyFeature)
+}
+
+func TestGlobalNamespaceIsolation(t *testing.T) {
+	namespacesKey := any("namespaces-key")
+	globalDefaultDeny := os.Getenv("GLOBAL_DEFAULT_DENY") == "true"
+
+	trackTest(t)
+
+	isolationFeature := features.New("network/global-namespace-isolation").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			available, err := apiResourceAvailable(cfg, networkingv1.SchemeGroupVersion.String(), "networkpolicies")
+			if err != nil {
+				t.Fatalf("Failed to discover networking.k8s.io API: %v", err)
+			}
+			if !available {
+				skipTest(t, skipReasonAPIUnavailable, "networking.k8s.io/v1 NetworkPolicy API not available, skipping")
+			}
+
+			// Namespaces A and B, each running nginx behind a service
+			var namespaces []string
+			for _, prefix := range []string{"isolation-a", "isolation-b"} {
+				namespace := names.randomName(prefix, 24)
+				ctx, err = createAuxNamespace(ctx, cfg, namespace)
+				if err != nil {
+					t.Fatalf("Failed to create namespace %s: %v", namespace, err)
+				}
+				namespaces = append(namespaces, namespace)
+				ctx = context.WithValue(ctx, namespacesKey, namespaces)
+
+				deployment := newNetworkDeployment(namespace, "isolation-nginx")
+				createdAt := time.Now()
+				if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+					t.Fatal(err)
+				}
+				if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+					t.Fatalf("Deployment not ready in namespace %s: %v", namespace, err)
+				}
+
+				service := newNetworkService(namespace, "isolation-service")
+				if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+					t.Fatal(err)
+				}
+
+				// Without a cluster-wide default deny, enforce the same posture namespace by namespace
+				if !globalDefaultDeny {
+					denyPolicy := newDenyAllIngressPolicy(namespace, "isolation-deny-all")
+					if err := cfg.Client().Resources().Create(ctx, denyPolicy); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if globalDefaultDeny {
+				t.Log("Relying on the cluster-wide default-deny policy")
+			}
+
+			return ctx
+		}).
+		Assess("cross-namespace traffic is denied", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			namespaces := ctx.Value(namespacesKey).([]string)
+			a, b := namespaces[0], namespaces[1]
+
+			assertCrossNamespaceRequestDenied(ctx, t, cfg, a, b, "isolation-client-a-denied")
+			assertCrossNamespaceRequestDenied(ctx, t, cfg, b, a, "isolation-client-b-denied")
+			t.Logf("✓ Traffic between namespaces %s and %s denied in both directions", a, b)
+
+			return ctx
+		}).
+		Assess("allow policy opens A to B only", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			namespaces := ctx.Value(namespacesKey).([]string)
+			a, b := namespaces[0], namespaces[1]
+
+			allowPolicy := newAllowNamespaceIngressPolicy(b, "isolation-allow-from-a",
+				map[string]string{"app": "network-test"}, a)
+			if err := cfg.Client().Resources().Create(ctx, allowPolicy); err != nil {
+				t.Fatal(err)
+			}
+
+			if exitCode := crossNamespaceRequest(ctx, t, cfg, a, b, "isolation-client-a-allowed"); exitCode != 0 {
+				t.Fatalf("Client in namespace %s could not reach namespace %s despite the allow policy, curl exited with code %d", a, b, exitCode)
+			}
+			t.Logf("✓ Allow policy opened traffic from namespace %s to %s", a, b)
+
+			assertCrossNamespaceRequestDenied(ctx, t, cfg, b, a, "isolation-client-b-still-denied")
+			t.Logf("✓ Traffic from namespace %s to %s still denied", b, a)
+
+			return ctx
+		}).
+		// Deleting the namespaces removes everything created inside them
+		Teardown(deleteAuxNamespaces).Feature()
+
+	testenv.Test(t, isolationFeature)
+}
+
+// curlExitTimeout is the exit code of curl when a connection or the whole transfer times out, as it
+// does when a policy drops the traffic
+const curlExitTimeout = 28
+
+// crossNamespaceRequest runs a client pod in namespace from requesting the isolation service of
+// namespace to, and returns the exit code of its curl request
+func crossNamespaceRequest(ctx context.Context, t *testing.T, cfg *envconf.Config, from, to, name string) int32 {
+	clientPod := newClientPod(from, name, "isolation-service."+to)
+	exitCode, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 2*time.Minute)
+	if err != nil && !errors.Is(err, errPodFailed) {
+		t.Fatal(err)
+	}
+
+	return exitCode
+}
+
+// assertCrossNamespaceRequestDenied fails the test unless a request from namespace from to the
+// isolation service of namespace to times out, telling a dropped request apart from other failures
+func assertCrossNamespaceRequestDenied(ctx context.Context, t *testing.T, cfg *envconf.Config, from, to, name string) {
+	switch exitCode := crossNamespaceRequest(ctx, t, cfg, from, to, name); exitCode {
+	case curlExitTimeout:
+	case 0:
+		t.Fatalf("Client in namespace %s reached namespace %s without an allow policy", from, to)
+	default:
+		t.Fatalf("Request from namespace %s to namespace %s failed with curl exit code %d, expected a timeout (%d)", from, to, exitCode, curlExitTimeout)
+	}
+}
+
+// deniesAllIngress reports whether a policy selects every pod for ingress without allowing any peer
+func deniesAllIngress(policies []networkingv1.NetworkPolicy) bool {
+	for _, policy := range policies {
+		selectsAll := len(policy.Spec.PodSelector.MatchLabels) == 0 && len(policy.Spec.PodSelector.MatchExpressions) == 0
+		coversIngress := len(policy.Spec.PolicyTypes) == 0 || slices.Contains(policy.Spec.PolicyTypes, networkingv1.PolicyTypeIngress)
+		if selectsAll && coversIngress && len(policy.Spec.Ingress) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// apiResourceAvailable checks whether the API server serves a resource in the given group version
+func apiResourceAvailable(cfg *envconf.Config, groupVersion, resource string) (bool, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return false, err
+	}
+
+	resourceList, err := clientset.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return false, nil
+	}
+
+	for _, apiResource := range resourceList.APIResources {
+		if apiResource.Name == resource {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// newDenyAllIngressPolicy creates a NetworkPolicy denying all ingress traffic to pods in the namespace
+func newDenyAllIngressPolicy(namespace, name string) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "network-test"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+}
+
+// newAllowIngressPolicy creates a NetworkPolicy allowing ingress to podLabels from pods matching fromLabels
+func newAllowIngressPolicy(namespace, name string, podLabels, fromLabels map[string]string) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "network-test"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{
+							PodSelector: &metav1.LabelSelector{MatchLabels: fromLabels},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newAllowNamespaceIngressPolicy creates a NetworkPolicy allowing ingress to podLabels from client
+// pods of another namespace
+func newAllowNamespaceIngressPolicy(namespace, name string, podLabels map[string]string, fromNamespace string) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "network-test"},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{corev1.LabelMetadataName: fromNamespace},
+							},
+							PodSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"app": "network-test-client"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
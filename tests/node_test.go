@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const defaultNodeReservationMaxPercent = 25.0
+
+func TestNodeAllocatable(t *testing.T) {
+	maxReservedPercent := getEnvFloat("NODE_RESERVATION_MAX_PERCENT", defaultNodeReservationMaxPercent)
+
+	trackTest(t)
+
+	allocatableFeature := features.New("node/allocatable").
+		Assess("allocatable is below capacity", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes.Items) == 0 {
+				t.Fatal("No nodes found in the cluster")
+			}
+
+			for _, node := range nodes.Items {
+				for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+					capacity := node.Status.Capacity[resourceName]
+					allocatable := node.Status.Allocatable[resourceName]
+
+					if capacity.IsZero() {
+						t.Errorf("Node %s reports no %s capacity", node.Name, resourceName)
+						continue
+					}
+
+					if allocatable.Cmp(capacity) >= 0 {
+						t.Errorf("Node %s has no %s reservation: allocatable %s, capacity %s",
+							node.Name, resourceName, allocatable.String(), capacity.String())
+						continue
+					}
+
+					reservedPercent := 100 * (1 - allocatable.AsApproximateFloat64()/capacity.AsApproximateFloat64())
+					metricsCollector.RecordNodeReservation(ctx, node.Name, string(resourceName), reservedPercent)
+
+					if reservedPercent > maxReservedPercent {
+						t.Errorf("Node %s reserves %.1f%% of %s, above the %.1f%% limit",
+							node.Name, reservedPercent, resourceName, maxReservedPercent)
+						continue
+					}
+
+					t.Logf("✓ Node %s reserves %.1f%% of %s (allocatable %s, capacity %s)",
+						node.Name, reservedPercent, resourceName, allocatable.String(), capacity.String())
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, allocatableFeature)
+}
+
+func TestWellKnownNodeLabels(t *testing.T) {
+	podKey := any("pod-key")
+	requiredLabels := []string{corev1.LabelHostname, corev1.LabelOSStable, corev1.LabelArchStable}
+	// Cloud providers set the instance type, bare-metal and local clusters usually do not
+	if os.Getenv("CLOUD_NODE_LABELS") == "true" {
+		requiredLabels = append(requiredLabels, corev1.LabelInstanceTypeStable)
+	}
+
+	trackTest(t)
+
+	labelsFeature := features.New("node/well-known-labels").
+		Assess("every node carries the well-known labels", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes.Items) == 0 {
+				t.Fatal("No nodes found in the cluster")
+			}
+
+			for _, node := range nodes.Items {
+				missing := slices.DeleteFunc(slices.Clone(requiredLabels), func(label string) bool {
+					return node.Labels[label] != ""
+				})
+				if len(missing) > 0 {
+					t.Errorf("Node %s is missing the well-known labels %v", node.Name, missing)
+					continue
+				}
+				t.Logf("✓ Node %s carries %s=%s, %s=%s", node.Name,
+					corev1.LabelOSStable, node.Labels[corev1.LabelOSStable], corev1.LabelArchStable, node.Labels[corev1.LabelArchStable])
+			}
+
+			return ctx
+		}).
+		Assess("pod lands on the node selected by hostname", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+			node := firstSchedulableNode(nodes.Items)
+			if node == nil {
+				skipTest(t, skipReasonNoEligibleNode, "No schedulable node found")
+			}
+			hostname := node.Labels[corev1.LabelHostname]
+			if hostname == "" {
+				t.Fatalf("Node %s has no %s label to select it by", node.Name, corev1.LabelHostname)
+			}
+
+			pod := newSchedulingPod(cfg.Namespace(), "hostname-selector-test")
+			pod.Spec.NodeSelector = map[string]string{corev1.LabelHostname: hostname}
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			nodeName, err := waitForPodScheduled(ctx, cfg.Client().Resources(), pod, time.Minute)
+			if err != nil {
+				t.Fatalf("Pod %s selecting %s=%s was not scheduled: %v", pod.Name, corev1.LabelHostname, hostname, err)
+			}
+			if nodeName != node.Name {
+				t.Fatalf("Pod %s selecting %s=%s landed on node %s instead of %s", pod.Name, corev1.LabelHostname, hostname, nodeName, node.Name)
+			}
+			t.Logf("✓ Pod %s selecting %s=%s landed on node %s", pod.Name, corev1.LabelHostname, hostname, nodeName)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, labelsFeature)
+}
+
+func TestKubeletStats(t *testing.T) {
+	trackTest(t)
+
+	statsFeature := features.New("node/kubelet-stats").
+		Assess("stats summary reports plausible node and pod stats", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+			if len(nodes.Items) == 0 {
+				t.Fatal("No nodes found in the cluster")
+			}
+
+			for _, node := range nodes.Items {
+				summary, err := getNodeStatsSummary(ctx, cfg, node.Name)
+				if apierrors.IsForbidden(err) {
+					skipTest(t, skipReasonInaccessible, "Stats summary of node %s not accessible: %v", node.Name, err)
+				}
+				if err != nil {
+					t.Fatalf("Failed to get the stats summary of node %s: %v", node.Name, err)
+				}
+
+				if err := checkNodeStats(&node, &summary.Node); err != nil {
+					t.Errorf("Node %s: %v", node.Name, err)
+					continue
+				}
+
+				// Cross-check the memory the kubelet sees as available against the allocatable memory
+				allocatable := node.Status.Allocatable[corev1.ResourceMemory]
+				t.Logf("✓ Node %s reports %s of memory available (allocatable %s), %.0fm CPU in use",
+					node.Name, bytesQuantity(*summary.Node.Memory.AvailableBytes), allocatable.String(),
+					float64(*summary.Node.CPU.UsageNanoCores)/1e6)
+
+				podsWithStats := 0
+				for _, pod := range summary.Pods {
+					if pod.CPU.UsageNanoCores != nil && pod.Memory.WorkingSetBytes != nil && pod.EphemeralStorage.UsedBytes != nil {
+						podsWithStats++
+					}
+				}
+				if len(summary.Pods) > 0 && podsWithStats == 0 {
+					t.Errorf("Node %s reports none of its %d pods with CPU, memory and filesystem stats", node.Name, len(summary.Pods))
+					continue
+				}
+				t.Logf("✓ Node %s reports stats for %d of its %d pods", node.Name, podsWithStats, len(summary.Pods))
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, statsFeature)
+}
+
+// kubeletStatsSummary is the subset of the kubelet /stats/summary response checked by TestKubeletStats
+type kubeletStatsSummary struct {
+	Node kubeletNodeStats  `json:"node"`
+	Pods []kubeletPodStats `json:"pods"`
+}
+
+type kubeletNodeStats struct {
+	NodeName string             `json:"nodeName"`
+	CPU      kubeletCPUStats    `json:"cpu"`
+	Memory   kubeletMemoryStats `json:"memory"`
+	Fs       kubeletFsStats     `json:"fs"`
+}
+
+type kubeletPodStats struct {
+	CPU              kubeletCPUStats    `json:"cpu"`
+	Memory           kubeletMemoryStats `json:"memory"`
+	EphemeralStorage kubeletFsStats     `json:"ephemeral-storage"`
+}
+
+type kubeletCPUStats struct {
+	UsageNanoCores *uint64 `json:"usageNanoCores"`
+}
+
+type kubeletMemoryStats struct {
+	AvailableBytes  *uint64 `json:"availableBytes"`
+	WorkingSetBytes *uint64 `json:"workingSetBytes"`
+}
+
+type kubeletFsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes"`
+	CapacityBytes  *uint64 `json:"capacityBytes"`
+	UsedBytes      *uint64 `json:"usedBytes"`
+}
+
+// getNodeStatsSummary fetches the kubelet stats summary of a node through the API server proxy
+func getNodeStatsSummary(ctx context.Context, cfg *envconf.Config, nodeName string) (*kubeletStatsSummary, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", nodeName, "proxy", "stats", "summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary kubeletStatsSummary
+	if err := json.Unmarshal(raw, &summary); err != nil {
+		return nil, fmt.Errorf("failed to decode stats summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// checkNodeStats returns an error when the node stats are missing or do not fit the node capacity
+func checkNodeStats(node *corev1.Node, stats *kubeletNodeStats) error {
+	if stats.NodeName != node.Name {
+		return fmt.Errorf("stats summary is for node %q", stats.NodeName)
+	}
+	if stats.CPU.UsageNanoCores == nil || stats.Memory.AvailableBytes == nil || stats.Memory.WorkingSetBytes == nil ||
+		stats.Fs.AvailableBytes == nil || stats.Fs.CapacityBytes == nil || stats.Fs.UsedBytes == nil {
+		return fmt.Errorf("stats summary is missing CPU, memory or filesystem stats")
+	}
+
+	cpuCapacity := node.Status.Capacity[corev1.ResourceCPU]
+	if cores := float64(*stats.CPU.UsageNanoCores) / 1e9; cores <= 0 || cores > cpuCapacity.AsApproximateFloat64() {
+		return fmt.Errorf("CPU usage of %.3f cores is not within the capacity of %s cores", cores, cpuCapacity.String())
+	}
+
+	memoryCapacity := node.Status.Capacity[corev1.ResourceMemory]
+	for name, value := range map[string]uint64{"available": *stats.Memory.AvailableBytes, "working set": *stats.Memory.WorkingSetBytes} {
+		if value == 0 || value > uint64(memoryCapacity.Value()) {
+			return fmt.Errorf("%s memory of %s is not within the capacity of %s", name, bytesQuantity(value), memoryCapacity.String())
+		}
+	}
+
+	if *stats.Fs.CapacityBytes == 0 || *stats.Fs.UsedBytes+*stats.Fs.AvailableBytes > *stats.Fs.CapacityBytes {
+		return fmt.Errorf("filesystem usage of %s and %s available do not fit its capacity of %s",
+			bytesQuantity(*stats.Fs.UsedBytes), bytesQuantity(*stats.Fs.AvailableBytes), bytesQuantity(*stats.Fs.CapacityBytes))
+	}
+
+	return nil
+}
+
+// bytesQuantity formats a byte count reported by the kubelet as a binary quantity, e.g. 512Mi
+func bytesQuantity(bytes uint64) *resource.Quantity {
+	return resource.NewQuantity(int64(bytes), resource.BinarySI)
+}
@@ -0,0 +1,657 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+
+	"github.com/clementnuss/e2e-tests/tests/metrics"
+)
+
+func TestCPUThrottling(t *testing.T) {
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	throttlingFeature := features.New("observability/cpu-throttling").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create a busy-looping pod with a tight CPU limit
+			pod := newCPUBurnPod(cfg.Namespace(), "cpu-throttling-test", "50m")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			// Let the CFS quota throttle the container for a while
+			time.Sleep(60 * time.Second)
+
+			return ctx
+		}).
+		Assess("container is throttled", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := getNodeCadvisorMetrics(ctx, cfg, currentPod.Spec.NodeName)
+			if err != nil {
+				skipTest(t, skipReasonInaccessible, "Node metrics of %s not accessible: %v", currentPod.Spec.NodeName, err)
+			}
+
+			container := currentPod.Spec.Containers[0].Name
+			throttled, ok := parseContainerMetric(body, "container_cpu_cfs_throttled_seconds_total", pod.Namespace, pod.Name, container)
+			if !ok {
+				skipTest(t, skipReasonMissingPrerequisite, "container_cpu_cfs_throttled_seconds_total not reported for %s/%s", pod.Name, container)
+			}
+			usage, _ := parseContainerMetric(body, "container_cpu_usage_seconds_total", pod.Namespace, pod.Name, container)
+
+			if throttled <= 0 {
+				t.Fatalf("Expected container %s to be throttled, throttled seconds: %f", container, throttled)
+			}
+
+			// Share of the time the container wanted CPU but was held back by its quota
+			ratio := throttled / (throttled + usage)
+			metricsCollector.RecordCPUThrottleRatio(ctx, pod.Name, container, ratio)
+			t.Logf("✓ Container %s throttled for %.2fs (usage %.2fs, ratio %.2f)", container, throttled, usage, ratio)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod := ctx.Value(podKey).(*corev1.Pod); pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, throttlingFeature)
+}
+
+const (
+	// fluentBitImage is the Fluent Bit image of the sidecar of TestFluentBitSidecar
+	fluentBitImage = "cr.fluentbit.io/fluent/fluent-bit:3.2"
+	// fluentBitLogCount is the number of JSON log entries the main container writes
+	fluentBitLogCount = 5
+)
+
+// fluentBitConfig tails the application log from the shared volume, parsing each line as JSON,
+// and prints every record to stdout as a JSON line
+const fluentBitConfig = `[SERVICE]
+    Flush        1
+    Log_Level    warn
+    Parsers_File parsers.conf
+
+[INPUT]
+    Name             tail
+    Path             /logs/app.log
+    Parser           json
+    Read_from_Head   On
+    Refresh_Interval 1
+
+[OUTPUT]
+    Name   stdout
+    Match  *
+    Format json_lines
+`
+
+const fluentBitParsers = `[PARSER]
+    Name   json
+    Format json
+`
+
+// fluentBitRecord is a structured log entry written by the main container of TestFluentBitSidecar
+type fluentBitRecord struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Message   string `json:"message"`
+}
+
+func TestFluentBitSidecar(t *testing.T) {
+	podKey := any("pod-key")
+	configMapKey := any("configmap-key")
+
+	trackTest(t)
+
+	fluentBitFeature := features.New("observability/fluent-bit-sidecar").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod, configMap := newFluentBitSidecarPod(cfg.Namespace(), "fluent-bit-sidecar-test")
+			if err := cfg.Client().Resources().Create(ctx, configMap); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, configMapKey, configMap)
+
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("sidecar forwards the application logs", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			// The original entries, as the application printed them next to writing them to the file
+			var original map[string]fluentBitRecord
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+				logs, err := getPodLogs(ctx, cfg, pod, "app")
+				if err != nil {
+					t.Logf("Application logs not readable yet: %v", err)
+					return false, nil
+				}
+
+				original, err = parseFluentBitRecords(logs)
+				if err != nil {
+					return false, err
+				}
+				return len(original) == fluentBitLogCount, nil
+			})
+			if err != nil {
+				t.Fatalf("Application did not write %d log entries: %v", fluentBitLogCount, err)
+			}
+
+			var forwarded map[string]fluentBitRecord
+			err = wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+				logs, err := getPodLogs(ctx, cfg, pod, "fluent-bit")
+				if err != nil {
+					t.Logf("Fluent Bit logs not readable yet: %v", err)
+					return false, nil
+				}
+
+				// Every record Fluent Bit prints must be valid JSON
+				forwarded, err = parseFluentBitRecords(logs)
+				if err != nil {
+					return false, err
+				}
+				return len(forwarded) == fluentBitLogCount, nil
+			})
+			if err != nil {
+				t.Fatalf("Fluent Bit forwarded %d of %d log entries: %v", len(forwarded), fluentBitLogCount, err)
+			}
+			t.Logf("✓ Fluent Bit forwarded %d JSON log entries", len(forwarded))
+
+			for message, record := range original {
+				forwardedRecord, ok := forwarded[message]
+				if !ok {
+					t.Fatalf("Log entry %q was not forwarded", message)
+				}
+				if forwardedRecord.Timestamp != record.Timestamp {
+					t.Fatalf("Log entry %q forwarded with timestamp %q, written with %q", message, forwardedRecord.Timestamp, record.Timestamp)
+				}
+			}
+			t.Logf("✓ Forwarded log entries kept their original timestamp")
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete pod: %v", err)
+				}
+			}
+			if configMap, ok := ctx.Value(configMapKey).(*corev1.ConfigMap); ok && configMap != nil {
+				if err := cfg.Client().Resources().Delete(ctx, configMap); err != nil {
+					t.Logf("Failed to delete ConfigMap: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, fluentBitFeature)
+}
+
+// newFluentBitSidecarPod creates a pod whose main container writes JSON log entries to a shared
+// emptyDir, tailed by a Fluent Bit sidecar printing them to its stdout, along with the ConfigMap
+// holding the Fluent Bit configuration
+func newFluentBitSidecarPod(namespace, name string) (*corev1.Pod, *corev1.ConfigMap) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-config",
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "observability-test"},
+		},
+		Data: map[string]string{
+			"fluent-bit.conf": fluentBitConfig,
+			"parsers.conf":    fluentBitParsers,
+		},
+	}
+
+	// Each entry is printed to stdout as well, to compare the forwarded entries with the originals
+	command := fmt.Sprintf(`for i in $(seq 1 %d); do
+  line="{\"timestamp\":\"$(date -u +%%Y-%%m-%%dT%%H:%%M:%%SZ)\",\"level\":\"info\",\"message\":\"fluent-bit-e2e-$i\"}"
+  echo "$line" | tee -a /logs/app.log
+  sleep 1
+done
+sleep 3600`, fluentBitLogCount)
+
+	securityContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &[]bool{false}[0],
+		RunAsNonRoot:             &[]bool{true}[0],
+		RunAsUser:                &[]int64{65534}[0],
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "observability-test"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            "app",
+					Image:           "alpine:latest",
+					Command:         []string{"sh", "-c", command},
+					SecurityContext: securityContext,
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "logs", MountPath: "/logs"},
+					},
+				},
+				{
+					Name:            "fluent-bit",
+					Image:           fluentBitImage,
+					Args:            []string{"-c", "/fluent-bit/etc/fluent-bit.conf"},
+					SecurityContext: securityContext,
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "logs", MountPath: "/logs", ReadOnly: true},
+						{Name: "config", MountPath: "/fluent-bit/etc"},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name:         "logs",
+					VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				},
+				{
+					Name: "config",
+					VolumeSource: corev1.VolumeSource{
+						ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: configMap.Name},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return pod, configMap
+}
+
+// parseFluentBitRecords parses the JSON log entries of container logs, indexed by message. Lines
+// not starting with a brace, such as the Fluent Bit banner, are ignored; other lines must be valid JSON
+func parseFluentBitRecords(logs string) (map[string]fluentBitRecord, error) {
+	records := map[string]fluentBitRecord{}
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var record fluentBitRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("invalid JSON log entry %q: %w", line, err)
+		}
+		if record.Message != "" {
+			records[record.Message] = record
+		}
+	}
+
+	return records, nil
+}
+
+const (
+	// otelCollectorImage is the OpenTelemetry Collector image deployed by TestOtelCollector
+	otelCollectorImage = "otel/opentelemetry-collector:0.115.0"
+	// otelCollectorAttribute is the resource attribute the collector adds to the metrics it receives
+	otelCollectorAttribute = "otelcol.service.name"
+	otelCollectorName      = "e2e-otel-collector"
+)
+
+// otelCollectorConfig receives OTLP metrics over gRPC, tags them with the collector name and prints
+// them through the debug exporter, which stands in for a metrics backend
+var otelCollectorConfig = fmt.Sprintf(`receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+processors:
+  resource:
+    attributes:
+      - key: %s
+        value: %s
+        action: upsert
+exporters:
+  debug:
+    verbosity: detailed
+service:
+  pipelines:
+    metrics:
+      receivers: [otlp]
+      processors: [resource]
+      exporters: [debug]
+`, otelCollectorAttribute, otelCollectorName)
+
+func TestOtelCollector(t *testing.T) {
+	configMapKey := any("configmap-key")
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	collectorLabels := map[string]string{"app": "otel-collector"}
+
+	trackTest(t)
+
+	// The test process exports to the collector Service, which is only reachable from inside the cluster
+	if os.Getenv("OTEL_COLLECTOR_ENABLED") != "true" {
+		skipTest(t, skipReasonOptIn, "OTEL_COLLECTOR_ENABLED not set to true, skipping OpenTelemetry Collector test")
+	}
+
+	otelCollectorFeature := features.New("observability/otel-collector").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			configMap := newConfigMap(cfg.Namespace(), "otel-collector-config", "config.yaml", otelCollectorConfig)
+			if err := cfg.Client().Resources().Create(ctx, configMap); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, configMapKey, configMap)
+
+			deployment := newOtelCollectorDeployment(cfg.Namespace(), "otel-collector", configMap.Name)
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			service := newNetworkService(cfg.Namespace(), "otel-collector")
+			service.Spec.Selector = collectorLabels
+			service.Spec.Ports = []corev1.ServicePort{
+				{Name: "otlp-grpc", Port: 4317, TargetPort: intstr.FromInt32(4317), Protocol: corev1.ProtocolTCP},
+			}
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("OpenTelemetry Collector not ready: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("collector re-exports the test metrics", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			// A pipeline of its own, so that the suite keeps exporting to its configured endpoint
+			mp, err := metrics.NewMeterProvider(&metrics.Config{
+				ServiceName: "e2e-tests",
+				Endpoint:    fmt.Sprintf("%s.%s.svc:4317", service.Name, service.Namespace),
+				Insecure:    true,
+			})
+			if err != nil {
+				t.Fatalf("Failed to create the collector metrics pipeline: %v", err)
+			}
+			defer func() {
+				if err := mp.Shutdown(context.WithoutCancel(ctx)); err != nil {
+					t.Logf("Failed to shut down the collector metrics pipeline: %v", err)
+				}
+			}()
+
+			collector, err := metrics.NewCollectorForProvider(mp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range 3 {
+				t.Run(fmt.Sprintf("sample-%d", i), func(t *testing.T) {
+					t.Cleanup(func() { collector.RecordTestExecution(ctx, t, time.Second) })
+				})
+			}
+
+			flushCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			if err := mp.ForceFlush(flushCtx); err != nil {
+				t.Fatalf("Failed to export metrics to the collector at %s.%s.svc:4317: %v", service.Name, service.Namespace, err)
+			}
+			t.Logf("✓ Exported test metrics to the collector")
+
+			// The debug exporter prints the resource attributes and metric names of every batch
+			expected := []string{
+				"-> Name: test_executed_total",
+				"-> service.name: Str(e2e-tests)",
+				fmt.Sprintf("-> %s: Str(%s)", otelCollectorAttribute, otelCollectorName),
+			}
+			var logs string
+			err = wait.PollUntilContextTimeout(ctx, 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+				pod := firstPodWithLabels(ctx, t, cfg, collectorLabels)
+				if logs, err = getPodLogs(ctx, cfg, pod, "otel-collector"); err != nil {
+					t.Logf("Collector logs not readable yet: %v", err)
+					return false, nil
+				}
+
+				for _, line := range expected {
+					if !strings.Contains(logs, line) {
+						return false, nil
+					}
+				}
+				return true, nil
+			})
+			if err != nil {
+				t.Fatalf("Collector did not re-export the test metrics with %v: %v\n%s", expected, err, logs)
+			}
+			t.Logf("✓ Collector re-exported test_executed_total with %s=%s", otelCollectorAttribute, otelCollectorName)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete Service: %v", err)
+				}
+			}
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete Deployment: %v", err)
+				}
+			}
+			if configMap, ok := ctx.Value(configMapKey).(*corev1.ConfigMap); ok && configMap != nil {
+				if err := cfg.Client().Resources().Delete(ctx, configMap); err != nil {
+					t.Logf("Failed to delete ConfigMap: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, otelCollectorFeature)
+}
+
+// newOtelCollectorDeployment creates an OpenTelemetry Collector deployment running the
+// configuration held by a ConfigMap under the config.yaml key
+func newOtelCollectorDeployment(namespace, name, configMapName string) *appsv1.Deployment {
+	deployment := newNetworkDeployment(namespace, name)
+	setDeploymentAppLabel(deployment, "otel-collector")
+
+	podSpec := &deployment.Spec.Template.Spec
+	container := &podSpec.Containers[0]
+	container.Name = "otel-collector"
+	container.Image = otelCollectorImage
+	container.Args = []string{"--config=/conf/config.yaml"}
+	container.Ports = []corev1.ContainerPort{{Name: "otlp-grpc", ContainerPort: 4317, Protocol: corev1.ProtocolTCP}}
+	container.VolumeMounts = []corev1.VolumeMount{{Name: "config", MountPath: "/conf", ReadOnly: true}}
+	podSpec.Volumes = []corev1.Volume{
+		{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+				},
+			},
+		},
+	}
+
+	return deployment
+}
+
+// newCPUBurnPod creates a pod running a busy loop under the given CPU limit
+func newCPUBurnPod(namespace, name, cpuLimit string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "observability-test"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    "cpu-burn",
+					Image:   "alpine:latest",
+					Command: []string{"sh", "-c", "while :; do :; done"},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse(cpuLimit),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse(cpuLimit),
+						},
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// getNodeCadvisorMetrics fetches the kubelet cAdvisor metrics of a node through the API server proxy
+func getNodeCadvisorMetrics(ctx context.Context, cfg *envconf.Config, nodeName string) (string, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", nodeName, "proxy", "metrics", "cadvisor").
+		DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw), nil
+}
+
+// parseContainerMetric extracts a sample of a Prometheus text metric for a specific container
+func parseContainerMetric(body, name, namespace, pod, container string) (float64, bool) {
+	labels := []string{
+		`namespace="` + namespace + `"`,
+		`pod="` + pod + `"`,
+		`container="` + container + `"`,
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, name+"{") {
+			continue
+		}
+
+		end := strings.LastIndex(line, "}")
+		if end < 0 {
+			continue
+		}
+
+		matches := true
+		for _, label := range labels {
+			if !strings.Contains(line[:end], label) {
+				matches = false
+				break
+			}
+		}
+		if !matches {
+			continue
+		}
+
+		fields := strings.Fields(line[end+1:])
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		return value, true
+	}
+
+	return 0, false
+}
+
+// waitForPodRunning waits for a pod to reach the Running phase
+func waitForPodRunning(ctx context.Context, client *resources.Resources, pod *corev1.Pod) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var currentPod corev1.Pod
+		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+			return false, err
+		}
+
+		return currentPod.Status.Phase == corev1.PodRunning, nil
+	})
+}
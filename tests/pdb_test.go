@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestPodDisruptionBudget(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	pdbKey := any("pdb-key")
+	pdbLabels := map[string]string{"app": "pdb-test"}
+	budgetDeploymentKey := any("budget-deployment-key")
+	budgetPDBKey := any("budget-pdb-key")
+	budgetLabels := map[string]string{"app": "pdb-budget-test"}
+
+	trackTest(t)
+
+	pdbFeature := features.New("policy/pdb").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			skipWithoutPDB(t, cfg)
+
+			// Create 2-replica nginx deployment
+			deployment := newNetworkDeployment(cfg.Namespace(), "pdb-test-nginx")
+			deployment.Spec.Replicas = &[]int32{2}[0]
+			setDeploymentAppLabel(deployment, "pdb-test")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			// Wait for deployment to be ready
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			// Create PDB requiring both replicas
+			pdb := newPDB(cfg.Namespace(), "pdb-test", pdbLabels, 2)
+			if err := cfg.Client().Resources().Create(ctx, pdb); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pdbKey, pdb)
+
+			if err := waitForPDBObserved(ctx, cfg.Client().Resources(), pdb); err != nil {
+				t.Fatalf("PDB status not observed: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("eviction blocked below minAvailable", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := firstPodWithLabels(ctx, t, cfg, pdbLabels)
+
+			status, err := evictPod(ctx, cfg, pod)
+			if status != http.StatusTooManyRequests {
+				t.Fatalf("Expected eviction of %s to return %d, got %d (%v)", pod.Name, http.StatusTooManyRequests, status, err)
+			}
+			t.Logf("✓ Eviction of %s blocked by PDB with HTTP %d", pod.Name, status)
+
+			return ctx
+		}).
+		Assess("eviction allowed after lowering minAvailable", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pdb := ctx.Value(pdbKey).(*policyv1.PodDisruptionBudget)
+
+			var currentPDB policyv1.PodDisruptionBudget
+			if err := cfg.Client().Resources().Get(ctx, pdb.Name, pdb.Namespace, &currentPDB); err != nil {
+				t.Fatal(err)
+			}
+			minAvailable := intstr.FromInt32(1)
+			currentPDB.Spec.MinAvailable = &minAvailable
+			if err := cfg.Client().Resources().Update(ctx, &currentPDB); err != nil {
+				t.Fatalf("Failed to lower PDB minAvailable: %v", err)
+			}
+
+			if err := waitForPDBObserved(ctx, cfg.Client().Resources(), &currentPDB); err != nil {
+				t.Fatalf("Updated PDB status not observed: %v", err)
+			}
+
+			pod := firstPodWithLabels(ctx, t, cfg, pdbLabels)
+			status, err := evictPod(ctx, cfg, pod)
+			if err != nil {
+				t.Fatalf("Eviction of %s failed with HTTP %d: %v", pod.Name, status, err)
+			}
+			t.Logf("✓ Eviction of %s allowed with HTTP %d after lowering minAvailable", pod.Name, status)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete PDB
+			if pdb := ctx.Value(pdbKey).(*policyv1.PodDisruptionBudget); pdb != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pdb); err != nil {
+					t.Logf("Failed to delete PDB: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment := ctx.Value(deploymentKey).(*appsv1.Deployment); deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	budgetFeature := features.New("policy/pdb-concurrent-eviction").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			skipWithoutPDB(t, cfg)
+
+			// Create 3-replica nginx deployment, leaving a single disruption to the PDB
+			deployment := newNetworkDeployment(cfg.Namespace(), "pdb-budget-nginx")
+			deployment.Spec.Replicas = &[]int32{3}[0]
+			setDeploymentAppLabel(deployment, "pdb-budget-test")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, budgetDeploymentKey, deployment)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			pdb := newPDB(cfg.Namespace(), "pdb-budget-test", budgetLabels, 2)
+			if err := cfg.Client().Resources().Create(ctx, pdb); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, budgetPDBKey, pdb)
+
+			// The first eviction is only allowed once the controller computed disruptionsAllowed
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var currentPDB policyv1.PodDisruptionBudget
+				if err := cfg.Client().Resources().Get(ctx, pdb.Name, pdb.Namespace, &currentPDB); err != nil {
+					return false, err
+				}
+
+				return currentPDB.Status.ObservedGeneration >= currentPDB.Generation && currentPDB.Status.DisruptionsAllowed == 1, nil
+			})
+			if err != nil {
+				t.Fatalf("PDB never allowed a single disruption: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("only one of two concurrent evictions is allowed", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var pods corev1.PodList
+			selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: budgetLabels})
+			if err := cfg.Client().Resources(cfg.Namespace()).List(ctx, &pods, resources.WithLabelSelector(selector)); err != nil {
+				t.Fatal(err)
+			}
+			if len(pods.Items) < 2 {
+				t.Fatalf("Expected at least 2 pods matching %s, got %d", selector, len(pods.Items))
+			}
+
+			// The API server serializes evictions through the PDB status, so exactly one of them
+			// consumes the only allowed disruption
+			statuses := make([]int, 2)
+			errs := make([]error, 2)
+			var wg sync.WaitGroup
+			for i := range 2 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					statuses[i], errs[i] = evictPod(ctx, cfg, &pods.Items[i])
+				}()
+			}
+			wg.Wait()
+
+			allowed, rejected := 0, 0
+			for i, status := range statuses {
+				switch {
+				case errs[i] == nil:
+					allowed++
+					t.Logf("✓ Eviction of %s allowed with HTTP %d", pods.Items[i].Name, status)
+				case status == http.StatusTooManyRequests:
+					rejected++
+					t.Logf("✓ Eviction of %s rejected by PDB with HTTP %d", pods.Items[i].Name, status)
+				default:
+					t.Errorf("Eviction of %s failed with unexpected HTTP %d: %v", pods.Items[i].Name, status, errs[i])
+				}
+			}
+			if allowed != 1 || rejected != 1 {
+				t.Fatalf("Expected one allowed and one rejected eviction, got %d allowed and %d rejected", allowed, rejected)
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pdb, ok := ctx.Value(budgetPDBKey).(*policyv1.PodDisruptionBudget); ok && pdb != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pdb); err != nil {
+					t.Logf("Failed to delete PDB: %v", err)
+				}
+			}
+			if deployment, ok := ctx.Value(budgetDeploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, pdbFeature, budgetFeature)
+}
+
+// skipWithoutPDB skips a feature when the API server does not serve policy/v1 PodDisruptionBudgets
+func skipWithoutPDB(t *testing.T, cfg *envconf.Config) {
+	t.Helper()
+
+	available, err := apiResourceAvailable(cfg, "policy/v1", "poddisruptionbudgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !available {
+		skipTest(t, skipReasonAPIUnavailable, "policy/v1 PodDisruptionBudget API not served, skipping PDB test")
+	}
+}
+
+// newPDB creates a PodDisruptionBudget with an absolute minAvailable
+func newPDB(namespace, name string, selector map[string]string, minAvailable int) *policyv1.PodDisruptionBudget {
+	minAvailableValue := intstr.FromInt(minAvailable)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    selector,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailableValue,
+			Selector:     &metav1.LabelSelector{MatchLabels: selector},
+		},
+	}
+}
+
+// waitForPDBObserved waits until the disruption controller has processed the latest PDB generation
+func waitForPDBObserved(ctx context.Context, client *resources.Resources, pdb *policyv1.PodDisruptionBudget) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var currentPDB policyv1.PodDisruptionBudget
+		if err := client.Get(ctx, pdb.Name, pdb.Namespace, &currentPDB); err != nil {
+			return false, err
+		}
+
+		return currentPDB.Status.ObservedGeneration >= currentPDB.Generation, nil
+	})
+}
+
+// firstPodWithLabels returns the first running pod in the test namespace matching the labels
+func firstPodWithLabels(ctx context.Context, t *testing.T, cfg *envconf.Config, labels map[string]string) *corev1.Pod {
+	t.Helper()
+
+	var pods corev1.PodList
+	selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: labels})
+	if err := cfg.Client().Resources(cfg.Namespace()).List(ctx, &pods, resources.WithLabelSelector(selector)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning && pods.Items[i].DeletionTimestamp == nil {
+			return &pods.Items[i]
+		}
+	}
+
+	t.Fatalf("No running pod matches %s", selector)
+	return nil
+}
+
+// evictPod posts a policy/v1 Eviction to the pod's eviction subresource and returns the HTTP status code
+func evictPod(ctx context.Context, cfg *envconf.Config, pod *corev1.Pod) (int, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return 0, err
+	}
+
+	eviction := policyv1.Eviction{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "policy/v1", Kind: "Eviction"},
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	}
+	body, err := json.Marshal(eviction)
+	if err != nil {
+		return 0, err
+	}
+
+	var statusCode int
+	err = clientset.CoreV1().RESTClient().Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("eviction").
+		SetHeader("Content-Type", "application/json").
+		Body(body).
+		Do(ctx).
+		StatusCode(&statusCode).
+		Error()
+
+	return statusCode, err
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+// readinessGateDelay is how long the readiness probe of TestProbes keeps failing after the container starts
+const readinessGateDelay = 20 * time.Second
+
+func TestProbes(t *testing.T) {
+	readinessPodKey := any("readiness-pod-key")
+	livenessPodKey := any("liveness-pod-key")
+
+	trackTest(t)
+
+	probesFeature := features.New("workloads/probes").
+		Assess("readiness probe gates the Ready condition", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// The readiness file only appears once the gate delay has passed
+			pod := newProbePod(cfg.Namespace(), "readiness-probe-test",
+				fmt.Sprintf("sleep %d && touch /tmp/ready && sleep 3600", int(readinessGateDelay.Seconds())))
+			pod.Spec.Containers[0].ReadinessProbe = newFileProbe("/tmp/ready")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, readinessPodKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+			runningAt := time.Now()
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+			if podReady(&currentPod) {
+				t.Fatalf("Pod %s is Ready before its readiness gate opened", pod.Name)
+			}
+			t.Logf("✓ Pod %s is Running but not Ready", pod.Name)
+
+			if err := waitForPodReady(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil {
+				t.Fatalf("Pod %s did not become Ready after its readiness gate opened: %v", pod.Name, err)
+			}
+			t.Logf("✓ Pod %s became Ready %s after it started running", pod.Name, time.Since(runningAt).Round(time.Second))
+
+			return ctx
+		}).
+		Assess("failing liveness probe restarts the container", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newProbePod(cfg.Namespace(), "liveness-probe-test", "sleep 3600")
+			pod.Spec.Containers[0].LivenessProbe = newFileProbe("/tmp/healthy")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, livenessPodKey, pod)
+
+			restarts, err := waitForRestartCount(ctx, cfg.Client().Resources(), pod, 1, 3*time.Minute)
+			if err != nil {
+				t.Fatalf("Container of pod %s was not restarted by its failing liveness probe: %v", pod.Name, err)
+			}
+			t.Logf("✓ Failing liveness probe restarted the container of pod %s %d time(s)", pod.Name, restarts)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			for _, key := range []any{readinessPodKey, livenessPodKey} {
+				if pod, ok := ctx.Value(key).(*corev1.Pod); ok && pod != nil {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete Pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, probesFeature)
+}
+
+// newProbePod creates a long-running pod restarting its container when it exits
+func newProbePod(namespace, name, command string) *corev1.Pod {
+	pod := newSchedulingPod(namespace, name)
+	pod.Labels = map[string]string{"app": "probe-test"}
+	pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	pod.Spec.Containers[0].Command = []string{"sh", "-c", command}
+
+	return pod
+}
+
+// newFileProbe creates an exec probe succeeding once a file exists, checked every 2 seconds
+func newFileProbe(path string) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: []string{"cat", path}},
+		},
+		PeriodSeconds:    2,
+		FailureThreshold: 1,
+	}
+}
+
+// podReady reports whether a pod has the Ready condition set to True
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// waitForPodReady waits for a pod to have the Ready condition set to True
+func waitForPodReady(ctx context.Context, client *resources.Resources, pod *corev1.Pod, timeout time.Duration) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var currentPod corev1.Pod
+		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+			return false, err
+		}
+
+		return podReady(&currentPod), nil
+	})
+}
+
+// waitForRestartCount waits for the first container of a pod to be restarted at least minRestarts
+// times and returns its restart count
+func waitForRestartCount(ctx context.Context, client *resources.Resources, pod *corev1.Pod, minRestarts int32, timeout time.Duration) (int32, error) {
+	var restarts int32
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var currentPod corev1.Pod
+		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+			return false, err
+		}
+		if len(currentPod.Status.ContainerStatuses) == 0 {
+			return false, nil
+		}
+
+		restarts = currentPod.Status.ContainerStatuses[0].RestartCount
+		return restarts >= minRestarts, nil
+	})
+
+	return restarts, err
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestResourceQuota(t *testing.T) {
+	namespaceKey := any("namespace-key")
+	quotaKey := any("quota-key")
+	podsKey := any("pods-key")
+
+	trackTest(t)
+
+	quotaFeature := features.New("quota/pods").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Use a dedicated namespace so that pods from other tests do not consume the quota
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   names.randomName("quota-test", 20),
+					Labels: map[string]string{"app": "quota-test"},
+				},
+			}
+			if err := cfg.Client().Resources().Create(ctx, namespace); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, namespaceKey, namespace)
+
+			// Create quota capping the namespace at two pods
+			quota := newResourceQuota(namespace.Name, "quota-test", corev1.ResourceList{
+				corev1.ResourcePods: resource.MustParse("2"),
+			})
+			if err := cfg.Client().Resources().Create(ctx, quota); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, quotaKey, quota)
+
+			// Admission rejects creations until the quota controller has computed the status
+			if err := waitForQuotaStatus(ctx, cfg.Client().Resources(), quota); err != nil {
+				t.Fatalf("ResourceQuota status not populated: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("pod creation rejected when quota is exhausted", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			namespace := ctx.Value(namespaceKey).(*corev1.Namespace)
+
+			// The rejection happens at admission, so no pod phase needs to be awaited
+			pods, err := createPodsUntilRejected(ctx, cfg.Client().Resources(), namespace.Name, "quota-test", 3)
+			ctx = context.WithValue(ctx, podsKey, pods)
+			if err == nil {
+				t.Fatal("Third pod was created although the quota only allows two")
+			}
+			if len(pods) != 2 {
+				t.Fatalf("Pod %d of 3 was rejected although the quota allows two: %v", len(pods)+1, err)
+			}
+
+			if !isQuotaExceeded(err) {
+				t.Fatalf("Third pod was rejected with an unexpected error: %v", err)
+			}
+			t.Logf("✓ Third pod rejected by quota: %v", err)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete pods before the quota so no pod is left unaccounted
+			if pods, ok := ctx.Value(podsKey).([]*corev1.Pod); ok {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			// Delete quota
+			if quota, ok := ctx.Value(quotaKey).(*corev1.ResourceQuota); ok && quota != nil {
+				if err := cfg.Client().Resources().Delete(ctx, quota); err != nil {
+					t.Logf("Failed to delete ResourceQuota: %v", err)
+				}
+			}
+
+			// Delete namespace
+			if namespace := ctx.Value(namespaceKey).(*corev1.Namespace); namespace != nil {
+				if err := cfg.Client().Resources().Delete(ctx, namespace); err != nil {
+					t.Logf("Failed to delete namespace %s: %v", namespace.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, quotaFeature)
+}
+
+func TestMultiDimensionalQuota(t *testing.T) {
+	quotaKey := any("quota-key")
+	setupNamespace, teardownNamespace := withIsolatedNamespace("multi-quota")
+
+	trackTest(t)
+
+	multiQuotaFeature := features.New("quota/multi-dimensional").
+		WithSetup("create namespace", setupNamespace).
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			quota := newResourceQuota(cfg.Namespace(), "multi-quota-test", corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("2"),
+				corev1.ResourceRequestsMemory: resource.MustParse("1Gi"),
+				"count/pods":                  resource.MustParse("5"),
+			})
+			if err := cfg.Client().Resources().Create(ctx, quota); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, quotaKey, quota)
+
+			if err := waitForQuotaStatus(ctx, cfg.Client().Resources(), quota); err != nil {
+				t.Fatalf("ResourceQuota status not populated: %v", err)
+			}
+
+			// Consume half the CPU, half the memory and 3 of the 5 pods
+			for i, requests := range [][2]string{{"500m", "256Mi"}, {"250m", "128Mi"}, {"250m", "128Mi"}} {
+				pod := newQuotaPod(cfg.Namespace(), fmt.Sprintf("multi-quota-test-%d", i), requests[0], requests[1])
+				if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+					t.Fatalf("Failed to create pod %s within quota: %v", pod.Name, err)
+				}
+			}
+
+			return ctx
+		}).
+		Assess("pod within every dimension is admitted", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newQuotaPod(cfg.Namespace(), "multi-quota-test-3", "250m", "128Mi")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatalf("Fourth pod rejected although every quota dimension has room: %v", err)
+			}
+			t.Logf("✓ Fourth pod admitted, using 4/5 pods, 1250m/2 CPU and 640Mi/1Gi memory")
+
+			return ctx
+		}).
+		Assess("pod exceeding the CPU quota is rejected", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// The pods count and memory still have room, only the CPU requests exceed the quota
+			pod := newQuotaPod(cfg.Namespace(), "multi-quota-test-4", "1", "64Mi")
+			err := cfg.Client().Resources().Create(ctx, pod)
+			if err == nil {
+				t.Fatal("Pod exceeding requests.cpu was created although the quota only has 750m left")
+			}
+			if !isQuotaExceeded(err) {
+				t.Fatalf("Pod was rejected with an unexpected error: %v", err)
+			}
+
+			message := err.Error()
+			if !strings.Contains(message, "requested: "+string(corev1.ResourceRequestsCPU)) {
+				t.Fatalf("Quota rejection does not report requests.cpu as exceeded: %v", err)
+			}
+			for _, dimension := range []string{string(corev1.ResourceRequestsMemory), "count/pods"} {
+				if strings.Contains(message, "requested: "+dimension) || strings.Contains(message, ","+dimension+"=") {
+					t.Fatalf("Quota rejection reports %s as exceeded although it has room: %v", dimension, err)
+				}
+			}
+			t.Logf("✓ Pod exceeding only requests.cpu rejected by quota: %v", err)
+
+			return ctx
+		}).
+		Assess("quota usage accounts every dimension", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			quota := ctx.Value(quotaKey).(*corev1.ResourceQuota)
+
+			expected := corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("1250m"),
+				corev1.ResourceRequestsMemory: resource.MustParse("640Mi"),
+				"count/pods":                  resource.MustParse("4"),
+			}
+			var used corev1.ResourceList
+			err := wait.PollUntilContextTimeout(ctx, 1*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var currentQuota corev1.ResourceQuota
+				if err := cfg.Client().Resources().Get(ctx, quota.Name, quota.Namespace, &currentQuota); err != nil {
+					return false, err
+				}
+
+				used = currentQuota.Status.Used
+				for name, quantity := range expected {
+					if usedQuantity, ok := used[name]; !ok || usedQuantity.Cmp(quantity) != 0 {
+						return false, nil
+					}
+				}
+				return true, nil
+			})
+			if err != nil {
+				t.Fatalf("ResourceQuota usage %v never matched the admitted pods %v: %v", used, expected, err)
+			}
+			t.Logf("✓ ResourceQuota usage matches the admitted pods: %v", used)
+
+			return ctx
+		}).
+		WithTeardown("delete namespace", teardownNamespace).
+		Feature()
+
+	testenv.Test(t, multiQuotaFeature)
+}
+
+// newQuotaPod creates a pod requesting the given CPU and memory, as a quota on requests requires
+func newQuotaPod(namespace, name, cpu, memory string) *corev1.Pod {
+	pod := newSchedulingPod(namespace, name)
+	pod.Labels = map[string]string{"app": "quota-test"}
+	pod.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+
+	return pod
+}
+
+// newResourceQuota creates a ResourceQuota with the given hard limits
+func newResourceQuota(namespace, name string, hard corev1.ResourceList) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "quota-test"},
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}
+}
+
+// waitForQuotaStatus waits until the quota controller has populated the hard limits in the status
+func waitForQuotaStatus(ctx context.Context, client *resources.Resources, quota *corev1.ResourceQuota) error {
+	return wait.PollUntilContextTimeout(ctx, 1*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var currentQuota corev1.ResourceQuota
+		if err := client.Get(ctx, quota.Name, quota.Namespace, &currentQuota); err != nil {
+			return false, err
+		}
+
+		return len(currentQuota.Status.Hard) == len(quota.Spec.Hard), nil
+	})
+}
+
+// createPodsUntilRejected creates up to count pods named prefix-0, prefix-1, ... and returns the pods
+// created along with the first admission error, which stops the creations
+func createPodsUntilRejected(ctx context.Context, client *resources.Resources, namespace, prefix string, count int) ([]*corev1.Pod, error) {
+	var pods []*corev1.Pod
+	for i := range count {
+		pod := newSchedulingPod(namespace, fmt.Sprintf("%s-%d", prefix, i))
+		if err := client.Create(ctx, pod); err != nil {
+			return pods, err
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+// isQuotaExceeded reports whether an API error is a quota admission rejection
+func isQuotaExceeded(err error) bool {
+	return apierrors.IsForbidden(err) && strings.Contains(err.Error(), "exceeded quota")
+}
@@ -2,24 +2,31 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 	"sigs.k8s.io/e2e-framework/pkg/features"
 )
 
 func TestRBACPermissions(t *testing.T) {
-	start := time.Now()
 	serviceAccountKey := any("serviceaccount-key")
 
-	t.Cleanup(func() {
-		metricsCollector.RecordTestExecution(testContext, t, time.Since(start))
-	})
+	trackTest(t)
 
 	rbacFeature := features.New("rbac/permissions").
 		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
@@ -40,7 +47,7 @@ func TestRBACPermissions(t *testing.T) {
 			namespacePod := newRBACTestPod(cfg.Namespace(), "rbac-test-namespaces", sa.Name,
 				"kubectl get namespaces")
 
-			if err := runRBACTestPod(ctx, cfg.Client().Resources(), namespacePod); err != nil {
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), namespacePod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
 				t.Fatal(err)
 			}
 
@@ -54,7 +61,7 @@ func TestRBACPermissions(t *testing.T) {
 			secretPod := newRBACTestPod(cfg.Namespace(), "rbac-test-secret", sa.Name,
 				"kubectl create secret generic test-secret --from-literal=key=value -n kube-system")
 
-			if err := runRBACTestPod(ctx, cfg.Client().Resources(), secretPod); err != nil {
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), secretPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
 				t.Fatal(err)
 			}
 
@@ -68,7 +75,7 @@ func TestRBACPermissions(t *testing.T) {
 			nodesPod := newRBACTestPod(cfg.Namespace(), "rbac-test-nodes", sa.Name,
 				"kubectl get nodes")
 
-			if err := runRBACTestPod(ctx, cfg.Client().Resources(), nodesPod); err != nil {
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), nodesPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
 				t.Fatal(err)
 			}
 
@@ -79,15 +86,34 @@ func TestRBACPermissions(t *testing.T) {
 
 			// Test 4: Get API server version (should succeed - basic discovery)
 			t.Log("Testing: ServiceAccount should be able to get API server version")
-			versionPod := newRBACTestPod(cfg.Namespace(), "rbac-test-version", sa.Name,
-				"kubectl get --raw /version")
-
-			if err := runRBACTestPod(ctx, cfg.Client().Resources(), versionPod); err != nil {
-				t.Fatal(err)
+			// A transient API error fails kubectl just like a denial would: kubectl failures are retried
+			// unless its output reports a denial, pod creation errors only when transient
+			retriable := func(err error) bool {
+				if errors.Is(err, errPodFailed) {
+					return !strings.Contains(err.Error(), "Forbidden")
+				}
+				return isTransientAPIError(err)
 			}
+			var versionPods []*corev1.Pod
+			err := retryWithBackoff(ctx, t, 3, 5*time.Second, retriable, func() (bool, error) {
+				versionPod := newRBACTestPod(cfg.Namespace(), fmt.Sprintf("rbac-test-version-%d", len(versionPods)), sa.Name,
+					"kubectl get --raw /version")
+				versionPods = append(versionPods, versionPod)
+
+				_, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), versionPod, 2*time.Minute)
+				if errors.Is(err, errPodFailed) {
+					if logs, logErr := getPodLogs(ctx, cfg, versionPod, ""); logErr == nil {
+						return false, fmt.Errorf("%w: %s", err, strings.TrimSpace(logs))
+					}
+				}
+				if err != nil {
+					return false, err
+				}
 
-			if podFailedAsExpected(ctx, cfg.Client().Resources(), versionPod) {
-				t.Fatal("ServiceAccount should be able to get API server version, but it failed")
+				return true, nil
+			})
+			if err != nil {
+				t.Fatalf("ServiceAccount should be able to get API server version, but it failed: %v", err)
 			}
 			t.Log("✓ ServiceAccount can get API server version")
 
@@ -96,7 +122,7 @@ func TestRBACPermissions(t *testing.T) {
 			selfPod := newRBACTestPod(cfg.Namespace(), "rbac-test-self", sa.Name,
 				"kubectl get serviceaccount/"+sa.Name)
 
-			if err := runRBACTestPod(ctx, cfg.Client().Resources(), selfPod); err != nil {
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), selfPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
 				t.Fatal(err)
 			}
 
@@ -107,7 +133,7 @@ func TestRBACPermissions(t *testing.T) {
 			}
 
 			// Clean up test pods
-			cleanupPods := []*corev1.Pod{namespacePod, secretPod, nodesPod, versionPod, selfPod}
+			cleanupPods := append([]*corev1.Pod{namespacePod, secretPod, nodesPod, selfPod}, versionPods...)
 			for _, pod := range cleanupPods {
 				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
 					t.Logf("Failed to delete test pod %s: %v", pod.Name, err)
@@ -130,6 +156,622 @@ func TestRBACPermissions(t *testing.T) {
 	testenv.Test(t, rbacFeature)
 }
 
+func TestTokenReview(t *testing.T) {
+	serviceAccountKey := any("serviceaccount-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	tokenReviewFeature := features.New("rbac/tokenreview").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := newRBACServiceAccount(cfg.Namespace(), "tokenreview-test-sa")
+			if err := cfg.Client().Resources().Create(ctx, sa); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceAccountKey, sa)
+
+			// Run a pod that prints its projected ServiceAccount token
+			pod := newTokenPod(cfg.Namespace(), "tokenreview-test-pod", sa.Name)
+			ctx = context.WithValue(ctx, podKey, pod)
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil {
+				t.Fatalf("Token pod did not complete: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("token is authenticated", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := ctx.Value(serviceAccountKey).(*corev1.ServiceAccount)
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			logs, err := getPodLogs(ctx, cfg, pod, "")
+			if err != nil {
+				t.Fatalf("Failed to read token pod logs: %v", err)
+			}
+			token := strings.TrimSpace(logs)
+			if token == "" {
+				t.Fatal("Token pod did not print a ServiceAccount token")
+			}
+
+			review := &authenticationv1.TokenReview{
+				ObjectMeta: metav1.ObjectMeta{Name: "tokenreview-test"},
+				Spec:       authenticationv1.TokenReviewSpec{Token: token},
+			}
+			err = cfg.Client().Resources().Create(ctx, review)
+
+			// The token is printed in the pod logs and stays valid as long as its pod exists, so the
+			// pod is deleted as soon as the token was reviewed
+			if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+				t.Logf("Failed to delete token pod: %v", err)
+			}
+			ctx = context.WithValue(ctx, podKey, (*corev1.Pod)(nil))
+
+			if err != nil {
+				t.Fatalf("Failed to submit TokenReview: %v", err)
+			}
+
+			if !review.Status.Authenticated {
+				t.Fatalf("Token was not authenticated: %s", review.Status.Error)
+			}
+
+			expectedUsername := "system:serviceaccount:" + sa.Namespace + ":" + sa.Name
+			if review.Status.User.Username != expectedUsername {
+				t.Fatalf("Unexpected username: expected %s, got %s", expectedUsername, review.Status.User.Username)
+			}
+
+			if !slices.Contains(review.Status.User.Groups, "system:serviceaccounts") {
+				t.Fatalf("Groups %v do not contain system:serviceaccounts", review.Status.User.Groups)
+			}
+
+			t.Logf("✓ TokenReview authenticated %s", review.Status.User.Username)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete token pod
+			if pod := ctx.Value(podKey).(*corev1.Pod); pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete token pod: %v", err)
+				}
+			}
+
+			// Delete ServiceAccount
+			if sa := ctx.Value(serviceAccountKey).(*corev1.ServiceAccount); sa != nil {
+				if err := cfg.Client().Resources().Delete(ctx, sa); err != nil {
+					t.Logf("Failed to delete ServiceAccount: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, tokenReviewFeature)
+}
+
+func TestSubjectAccessReview(t *testing.T) {
+	roleKey := any("role-key")
+	roleBindingKey := any("rolebinding-key")
+
+	trackTest(t)
+
+	sarFeature := features.New("rbac/subjectaccessreview").
+		Assess("default ServiceAccount cannot get pods", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			user := "system:serviceaccount:" + cfg.Namespace() + ":default"
+
+			allowed, err := canI(ctx, cfg, user, cfg.Namespace(), "get", "pods")
+			if err != nil {
+				t.Fatalf("Failed to submit SubjectAccessReview: %v", err)
+			}
+			if allowed {
+				t.Fatalf("%s should not be allowed to get pods before the RoleBinding exists", user)
+			}
+			t.Logf("✓ SubjectAccessReview denied %s to get pods", user)
+
+			return ctx
+		}).
+		Assess("RoleBinding grants get pods", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			user := "system:serviceaccount:" + cfg.Namespace() + ":default"
+
+			role := newPodReaderRole(cfg.Namespace(), "sar-test-pod-reader")
+			if err := cfg.Client().Resources().Create(ctx, role); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, roleKey, role)
+
+			binding := newServiceAccountRoleBinding(cfg.Namespace(), "sar-test-pod-reader", "Role", role.Name, "default")
+			if err := cfg.Client().Resources().Create(ctx, binding); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, roleBindingKey, binding)
+
+			// The authorizer cache picks up new bindings asynchronously
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				return canI(ctx, cfg, user, cfg.Namespace(), "get", "pods")
+			})
+			if err != nil {
+				t.Fatalf("%s was not allowed to get pods after the RoleBinding was created: %v", user, err)
+			}
+			t.Logf("✓ SubjectAccessReview allowed %s to get pods", user)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete RoleBinding
+			if binding, ok := ctx.Value(roleBindingKey).(*rbacv1.RoleBinding); ok && binding != nil {
+				if err := cfg.Client().Resources().Delete(ctx, binding); err != nil {
+					t.Logf("Failed to delete RoleBinding: %v", err)
+				}
+			}
+
+			// Delete Role
+			if role, ok := ctx.Value(roleKey).(*rbacv1.Role); ok && role != nil {
+				if err := cfg.Client().Resources().Delete(ctx, role); err != nil {
+					t.Logf("Failed to delete Role: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, sarFeature)
+}
+
+func TestSelfSubjectAccessReview(t *testing.T) {
+	deniedSAKey := any("denied-serviceaccount-key")
+	allowedSAKey := any("allowed-serviceaccount-key")
+	roleKey := any("role-key")
+	roleBindingKey := any("rolebinding-key")
+
+	trackTest(t)
+
+	ssarFeature := features.New("rbac/selfsubjectaccessreview").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// ServiceAccount without any grant
+			deniedSA := newRBACServiceAccount(cfg.Namespace(), "ssar-test-denied")
+			if err := cfg.Client().Resources().Create(ctx, deniedSA); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deniedSAKey, deniedSA)
+
+			// ServiceAccount allowed to get pods
+			allowedSA := newRBACServiceAccount(cfg.Namespace(), "ssar-test-allowed")
+			if err := cfg.Client().Resources().Create(ctx, allowedSA); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, allowedSAKey, allowedSA)
+
+			role := newPodReaderRole(cfg.Namespace(), "ssar-test-pod-reader")
+			if err := cfg.Client().Resources().Create(ctx, role); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, roleKey, role)
+
+			binding := newServiceAccountRoleBinding(cfg.Namespace(), "ssar-test-pod-reader", "Role", role.Name, allowedSA.Name)
+			if err := cfg.Client().Resources().Create(ctx, binding); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, roleBindingKey, binding)
+
+			// Wait for the authorizer to pick up the binding
+			user := "system:serviceaccount:" + cfg.Namespace() + ":" + allowedSA.Name
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				return canI(ctx, cfg, user, cfg.Namespace(), "get", "pods")
+			})
+			if err != nil {
+				t.Fatalf("RoleBinding for %s not effective: %v", user, err)
+			}
+
+			return ctx
+		}).
+		Assess("denied ServiceAccount", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := ctx.Value(deniedSAKey).(*corev1.ServiceAccount)
+			assertSelfAccessReview(ctx, t, cfg, sa, false)
+			return ctx
+		}).
+		Assess("allowed ServiceAccount", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := ctx.Value(allowedSAKey).(*corev1.ServiceAccount)
+			assertSelfAccessReview(ctx, t, cfg, sa, true)
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete RoleBinding and Role
+			if binding := ctx.Value(roleBindingKey).(*rbacv1.RoleBinding); binding != nil {
+				if err := cfg.Client().Resources().Delete(ctx, binding); err != nil {
+					t.Logf("Failed to delete RoleBinding: %v", err)
+				}
+			}
+			if role := ctx.Value(roleKey).(*rbacv1.Role); role != nil {
+				if err := cfg.Client().Resources().Delete(ctx, role); err != nil {
+					t.Logf("Failed to delete Role: %v", err)
+				}
+			}
+
+			// Delete ServiceAccounts
+			for _, key := range []any{deniedSAKey, allowedSAKey} {
+				if sa := ctx.Value(key).(*corev1.ServiceAccount); sa != nil {
+					if err := cfg.Client().Resources().Delete(ctx, sa); err != nil {
+						t.Logf("Failed to delete ServiceAccount %s: %v", sa.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, ssarFeature)
+}
+
+func TestMultiSAIsolation(t *testing.T) {
+	readerSAKey := any("reader-serviceaccount-key")
+	restrictedSAKey := any("restricted-serviceaccount-key")
+	roleKey := any("role-key")
+	roleBindingKey := any("rolebinding-key")
+
+	trackTest(t)
+
+	isolationFeature := features.New("rbac/serviceaccount-isolation").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// ServiceAccount allowed to list pods
+			readerSA := newRBACServiceAccount(cfg.Namespace(), "isolation-test-reader")
+			if err := cfg.Client().Resources().Create(ctx, readerSA); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, readerSAKey, readerSA)
+
+			// ServiceAccount without any grant
+			restrictedSA := newRBACServiceAccount(cfg.Namespace(), "isolation-test-restricted")
+			if err := cfg.Client().Resources().Create(ctx, restrictedSA); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, restrictedSAKey, restrictedSA)
+
+			role := newPodReaderRole(cfg.Namespace(), "isolation-test-pod-reader")
+			if err := cfg.Client().Resources().Create(ctx, role); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, roleKey, role)
+
+			binding := newServiceAccountRoleBinding(cfg.Namespace(), "isolation-test-pod-reader", "Role", role.Name, readerSA.Name)
+			if err := cfg.Client().Resources().Create(ctx, binding); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, roleBindingKey, binding)
+
+			// Wait for the authorizer to pick up the binding
+			user := "system:serviceaccount:" + cfg.Namespace() + ":" + readerSA.Name
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				return canI(ctx, cfg, user, cfg.Namespace(), "list", "pods")
+			})
+			if err != nil {
+				t.Fatalf("RoleBinding for %s not effective: %v", user, err)
+			}
+
+			return ctx
+		}).
+		Assess("each ServiceAccount gets only its own grants", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			readerSA := ctx.Value(readerSAKey).(*corev1.ServiceAccount)
+			restrictedSA := ctx.Value(restrictedSAKey).(*corev1.ServiceAccount)
+
+			// Run both pods before checking either, so that they coexist in the namespace
+			pods := map[*corev1.ServiceAccount]*corev1.Pod{}
+			for _, sa := range []*corev1.ServiceAccount{readerSA, restrictedSA} {
+				pod := newRBACTestPod(cfg.Namespace(), sa.Name+"-list-pods", sa.Name, "kubectl get pods -n "+cfg.Namespace())
+				if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+					t.Fatal(err)
+				}
+				pods[sa] = pod
+			}
+			defer func() {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete test pod %s: %v", pod.Name, err)
+					}
+				}
+			}()
+
+			if podFailedAsExpected(ctx, cfg.Client().Resources(), pods[readerSA]) {
+				t.Fatalf("ServiceAccount %s should be able to list pods, but it was denied", readerSA.Name)
+			}
+			t.Logf("✓ ServiceAccount %s can list pods from its pod", readerSA.Name)
+
+			if !podFailedAsExpected(ctx, cfg.Client().Resources(), pods[restrictedSA]) {
+				t.Fatalf("ServiceAccount %s should not be able to list pods, but it succeeded", restrictedSA.Name)
+			}
+			t.Logf("✓ ServiceAccount %s is denied listing pods from its pod", restrictedSA.Name)
+
+			// The authorizer must agree, ruling out a token of the other ServiceAccount being used
+			restrictedUser := "system:serviceaccount:" + cfg.Namespace() + ":" + restrictedSA.Name
+			allowed, err := canI(ctx, cfg, restrictedUser, cfg.Namespace(), "list", "pods")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed {
+				t.Fatalf("SubjectAccessReview allows %s to list pods, grants leaked across ServiceAccounts", restrictedUser)
+			}
+			t.Logf("✓ SubjectAccessReview denies %s listing pods", restrictedUser)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete RoleBinding and Role
+			if binding := ctx.Value(roleBindingKey).(*rbacv1.RoleBinding); binding != nil {
+				if err := cfg.Client().Resources().Delete(ctx, binding); err != nil {
+					t.Logf("Failed to delete RoleBinding: %v", err)
+				}
+			}
+			if role := ctx.Value(roleKey).(*rbacv1.Role); role != nil {
+				if err := cfg.Client().Resources().Delete(ctx, role); err != nil {
+					t.Logf("Failed to delete Role: %v", err)
+				}
+			}
+
+			// Delete ServiceAccounts
+			for _, key := range []any{readerSAKey, restrictedSAKey} {
+				if sa := ctx.Value(key).(*corev1.ServiceAccount); sa != nil {
+					if err := cfg.Client().Resources().Delete(ctx, sa); err != nil {
+						t.Logf("Failed to delete ServiceAccount %s: %v", sa.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, isolationFeature)
+}
+
+func TestRoleBindingToClusterRole(t *testing.T) {
+	saKey := any("serviceaccount-key")
+	roleBindingKey := any("rolebinding-key")
+
+	trackTest(t)
+
+	clusterRoleBindingFeature := features.New("rbac/rolebinding-to-clusterrole").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := newRBACServiceAccount(cfg.Namespace(), "clusterrole-binding-test")
+			if err := cfg.Client().Resources().Create(ctx, sa); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, saKey, sa)
+
+			// A RoleBinding grants the ClusterRole's rules in its own namespace only
+			binding := newServiceAccountRoleBinding(cfg.Namespace(), "clusterrole-binding-test-view", "ClusterRole", "view", sa.Name)
+			if err := cfg.Client().Resources().Create(ctx, binding); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, roleBindingKey, binding)
+
+			// Wait for the authorizer to pick up the binding
+			user := "system:serviceaccount:" + cfg.Namespace() + ":" + sa.Name
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+				return canI(ctx, cfg, user, cfg.Namespace(), "list", "pods")
+			})
+			if err != nil {
+				t.Fatalf("RoleBinding for %s not effective: %v", user, err)
+			}
+
+			return ctx
+		}).
+		Assess("ClusterRole verbs are granted in the namespace", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := ctx.Value(saKey).(*corev1.ServiceAccount)
+			user := "system:serviceaccount:" + sa.Namespace + ":" + sa.Name
+
+			for _, resource := range []string{"pods", "services", "configmaps"} {
+				allowed, err := canI(ctx, cfg, user, cfg.Namespace(), "list", resource)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !allowed {
+					t.Fatalf("%s should be allowed to list %s in %s through the view ClusterRole", user, resource, cfg.Namespace())
+				}
+			}
+			t.Logf("✓ %s can list pods, services and configmaps in %s", user, cfg.Namespace())
+
+			// view is read-only
+			allowed, err := canI(ctx, cfg, user, cfg.Namespace(), "create", "pods")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed {
+				t.Fatalf("%s should not be allowed to create pods through the view ClusterRole", user)
+			}
+			t.Logf("✓ %s is denied creating pods in %s", user, cfg.Namespace())
+
+			return ctx
+		}).
+		Assess("ClusterRole verbs are denied in other namespaces", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := ctx.Value(saKey).(*corev1.ServiceAccount)
+			user := "system:serviceaccount:" + sa.Namespace + ":" + sa.Name
+
+			for _, namespace := range []string{metav1.NamespaceDefault, metav1.NamespaceSystem} {
+				allowed, err := canI(ctx, cfg, user, namespace, "list", "pods")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if allowed {
+					t.Fatalf("%s should not be allowed to list pods in %s, the RoleBinding leaked outside %s", user, namespace, cfg.Namespace())
+				}
+				t.Logf("✓ %s is denied listing pods in %s", user, namespace)
+			}
+
+			// Cluster-wide list requests are not covered by a namespaced binding either
+			allowed, err := canI(ctx, cfg, user, "", "list", "pods")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed {
+				t.Fatalf("%s should not be allowed to list pods across all namespaces", user)
+			}
+			t.Logf("✓ %s is denied listing pods across all namespaces", user)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if binding, ok := ctx.Value(roleBindingKey).(*rbacv1.RoleBinding); ok && binding != nil {
+				if err := cfg.Client().Resources().Delete(ctx, binding); err != nil {
+					t.Logf("Failed to delete RoleBinding: %v", err)
+				}
+			}
+			if sa, ok := ctx.Value(saKey).(*corev1.ServiceAccount); ok && sa != nil {
+				if err := cfg.Client().Resources().Delete(ctx, sa); err != nil {
+					t.Logf("Failed to delete ServiceAccount: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, clusterRoleBindingFeature)
+}
+
+func TestAggregatedClusterRole(t *testing.T) {
+	aggregateKey := any("aggregate-clusterrole-key")
+	contributorKey := any("contributor-clusterrole-key")
+	aggregateName := names.randomName("aggregate-test", 24)
+	// A label unique to this run, so that the aggregate only selects the contributor of this test
+	aggregationLabel := "e2e-tests.clementnuss.github.io/aggregate-to-" + aggregateName
+
+	trackTest(t)
+
+	aggregationFeature := features.New("rbac/clusterrole-aggregation").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			aggregate := &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   aggregateName,
+					Labels: map[string]string{"app": "rbac-test"},
+				},
+				AggregationRule: &rbacv1.AggregationRule{
+					ClusterRoleSelectors: []metav1.LabelSelector{
+						{MatchLabels: map[string]string{aggregationLabel: "true"}},
+					},
+				},
+			}
+			// Setting an aggregationRule requires the escalate verb on clusterroles
+			if err := cfg.Client().Resources().Create(ctx, aggregate); err != nil {
+				if apierrors.IsForbidden(err) {
+					skipTest(t, skipReasonForbidden, "Creating an aggregated ClusterRole is forbidden, escalate on clusterroles not granted: %v", err)
+				}
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, aggregateKey, aggregate)
+
+			return ctx
+		}).
+		Assess("contributor rules are aggregated", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			contributor := &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   aggregateName + "-contributor",
+					Labels: map[string]string{"app": "rbac-test", aggregationLabel: "true"},
+				},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list"}},
+				},
+			}
+			if err := cfg.Client().Resources().Create(ctx, contributor); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, contributorKey, contributor)
+
+			if err := waitForAggregatedRules(ctx, cfg.Client().Resources(), aggregateName, contributor.Rules, true); err != nil {
+				t.Fatalf("Rules of %s never aggregated into %s: %v", contributor.Name, aggregateName, err)
+			}
+			t.Logf("✓ Rules of %s aggregated into %s", contributor.Name, aggregateName)
+
+			return ctx
+		}).
+		Assess("contributor rules are removed with the contributor", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			contributor := ctx.Value(contributorKey).(*rbacv1.ClusterRole)
+
+			if err := cfg.Client().Resources().Delete(ctx, contributor); err != nil {
+				t.Fatalf("Failed to delete ClusterRole %s: %v", contributor.Name, err)
+			}
+			ctx = context.WithValue(ctx, contributorKey, (*rbacv1.ClusterRole)(nil))
+
+			if err := waitForAggregatedRules(ctx, cfg.Client().Resources(), aggregateName, contributor.Rules, false); err != nil {
+				t.Fatalf("Rules of %s still aggregated into %s after its deletion: %v", contributor.Name, aggregateName, err)
+			}
+			t.Logf("✓ Rules of %s removed from %s after its deletion", contributor.Name, aggregateName)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			for _, key := range []any{contributorKey, aggregateKey} {
+				if role, ok := ctx.Value(key).(*rbacv1.ClusterRole); ok && role != nil {
+					if err := cfg.Client().Resources().Delete(ctx, role); err != nil && !apierrors.IsNotFound(err) {
+						t.Logf("Failed to delete ClusterRole %s: %v", role.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, aggregationFeature)
+}
+
+// waitForAggregatedRules waits until the ClusterRole aggregation controller has merged rules into
+// the named aggregated ClusterRole, or removed them from it when present is false
+func waitForAggregatedRules(ctx context.Context, client *resources.Resources, name string, rules []rbacv1.PolicyRule, present bool) error {
+	return wait.PollUntilContextTimeout(ctx, 1*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var aggregate rbacv1.ClusterRole
+		if err := client.Get(ctx, name, "", &aggregate); err != nil {
+			return false, err
+		}
+
+		for _, rule := range rules {
+			contains := slices.ContainsFunc(aggregate.Rules, func(aggregated rbacv1.PolicyRule) bool {
+				return reflect.DeepEqual(aggregated, rule)
+			})
+			if contains != present {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// assertSelfAccessReview runs `kubectl auth can-i get pods` and a SelfSubjectAccessReview as the
+// ServiceAccount and checks both report the expected permission
+func assertSelfAccessReview(ctx context.Context, t *testing.T, cfg *envconf.Config, sa *corev1.ServiceAccount, expectAllowed bool) {
+	t.Helper()
+
+	ssar := fmt.Sprintf(`{"apiVersion":"authorization.k8s.io/v1","kind":"SelfSubjectAccessReview",`+
+		`"spec":{"resourceAttributes":{"namespace":"%s","verb":"get","resource":"pods"}}}`, sa.Namespace)
+	command := "kubectl auth can-i get pods -n " + sa.Namespace + "; rc=$?; " +
+		"echo \"ssar=$(echo '" + ssar + "' | kubectl create -f - -o jsonpath='{.status.allowed}')\"; " +
+		"exit $rc"
+
+	pod := newRBACTestPod(sa.Namespace, sa.Name+"-can-i", sa.Name, command)
+	exitCode, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute)
+	defer func() {
+		if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+			t.Logf("Failed to delete test pod %s: %v", pod.Name, err)
+		}
+	}()
+	if err != nil && !errors.Is(err, errPodFailed) {
+		t.Fatal(err)
+	}
+
+	expectedExitCode := int32(1)
+	if expectAllowed {
+		expectedExitCode = 0
+	}
+	if exitCode != expectedExitCode {
+		t.Fatalf("kubectl auth can-i for %s exited with %d, expected %d", sa.Name, exitCode, expectedExitCode)
+	}
+
+	logs, err := getPodLogs(ctx, cfg, pod, "")
+	if err != nil {
+		t.Fatalf("Failed to read pod logs: %v", err)
+	}
+
+	expectedSSAR := fmt.Sprintf("ssar=%t", expectAllowed)
+	if !strings.Contains(logs, expectedSSAR) {
+		t.Fatalf("SelfSubjectAccessReview for %s does not match kubectl auth can-i: expected %q in logs:\n%s", sa.Name, expectedSSAR, logs)
+	}
+
+	t.Logf("✓ %s: kubectl auth can-i exit code %d matches SelfSubjectAccessReview %s", sa.Name, exitCode, expectedSSAR)
+}
+
 // newRBACServiceAccount creates a basic ServiceAccount with no special permissions
 func newRBACServiceAccount(namespace, name string) *corev1.ServiceAccount {
 	return &corev1.ServiceAccount{
@@ -185,27 +827,6 @@ func newRBACTestPod(namespace, name, serviceAccountName, command string) *corev1
 	}
 }
 
-// runRBACTestPod runs a test pod and waits for completion
-func runRBACTestPod(ctx context.Context, client *resources.Resources, pod *corev1.Pod) error {
-	if err := client.Create(ctx, pod); err != nil {
-		return err
-	}
-
-	return wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
-		var currentPod corev1.Pod
-		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
-			return false, err
-		}
-
-		switch currentPod.Status.Phase {
-		case corev1.PodSucceeded, corev1.PodFailed:
-			return true, nil
-		default:
-			return false, nil
-		}
-	})
-}
-
 // podFailedAsExpected checks if a pod failed (which is expected for RBAC denial tests)
 func podFailedAsExpected(ctx context.Context, client *resources.Resources, pod *corev1.Pod) bool {
 	var currentPod corev1.Pod
@@ -229,3 +850,147 @@ func podFailedAsExpected(ctx context.Context, client *resources.Resources, pod *
 	return false
 }
 
+// newTokenPod creates a pod that prints a projected ServiceAccount token to its logs
+func newTokenPod(namespace, name, serviceAccountName string) *corev1.Pod {
+	// The shortest expiration the API server accepts
+	expirationSeconds := int64(600)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "rbac-test"},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName:           serviceAccountName,
+			AutomountServiceAccountToken: &[]bool{false}[0],
+			RestartPolicy:                corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    "token",
+					Image:   "alpine:latest",
+					Command: []string{"cat", "/var/run/secrets/tokens/token"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "token",
+							MountPath: "/var/run/secrets/tokens",
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "token",
+					VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{
+							Sources: []corev1.VolumeProjection{
+								{
+									ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+										Path:              "token",
+										ExpirationSeconds: &expirationSeconds,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// getPodLogs returns the logs of a container of a pod, or of its only container when container is
+// empty
+func getPodLogs(ctx context.Context, cfg *envconf.Config, pod *corev1.Pod, container string) (string, error) {
+	clientset, err := kubernetes.NewForConfig(cfg.Client().RESTConfig())
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container}).DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw), nil
+}
+
+// newPodReaderRole creates a Role allowing get and list on pods
+func newPodReaderRole(namespace, name string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "rbac-test"},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get", "list"},
+			},
+		},
+	}
+}
+
+// newServiceAccountRoleBinding creates a RoleBinding of a Role or ClusterRole to a ServiceAccount in the same namespace
+func newServiceAccountRoleBinding(namespace, name, roleKind, roleName, serviceAccountName string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "rbac-test"},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     roleKind,
+			Name:     roleName,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      serviceAccountName,
+				Namespace: namespace,
+			},
+		},
+	}
+}
+
+// canI asks the API server through a SubjectAccessReview whether user may perform verb on resource in namespace
+func canI(ctx context.Context, cfg *envconf.Config, user, namespace, verb, resource string) (bool, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		ObjectMeta: metav1.ObjectMeta{Name: "sar-test"},
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User: user,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Resource:  resource,
+			},
+		},
+	}
+	if err := cfg.Client().Resources().Create(ctx, review); err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
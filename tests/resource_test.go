@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	// pressurePodMemory is the memory each BestEffort pod of TestMemoryPressureEviction fills
+	pressurePodMemory = "500Mi"
+	// pressurePodsMax caps the number of pressure pods, to stay below the kubelet pod limit
+	pressurePodsMax = 100
+)
+
+func TestMemoryPressureEviction(t *testing.T) {
+	nodeKey := any("node-key")
+	guaranteedPodKey := any("guaranteed-pod-key")
+	pressurePodsKey := any("pressure-pods-key")
+	bestEffortLabels := map[string]string{"app": "memory-pressure-besteffort"}
+
+	trackTest(t)
+
+	// Filling a node's memory disrupts every workload running on it
+	if os.Getenv("MEMORY_PRESSURE_TEST") != "true" {
+		skipTest(t, skipReasonOptIn, "MEMORY_PRESSURE_TEST not set to true, skipping memory pressure eviction test")
+	}
+
+	pressureFeature := features.New("node/memory-pressure-eviction").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+			node := firstSchedulableNode(nodes.Items)
+			if node == nil {
+				skipTest(t, skipReasonNoEligibleNode, "No schedulable node found")
+			}
+			ctx = context.WithValue(ctx, nodeKey, node)
+
+			// The Guaranteed pod starts first, so that it is not the one failing to fit in memory
+			guaranteedPod := newPressurePod(cfg.Namespace(), "memory-pressure-guaranteed", node, "sleep 3600")
+			guaranteedPod.Labels = map[string]string{"app": "memory-pressure-guaranteed"}
+			guaranteedPod.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+			}
+			if err := cfg.Client().Resources().Create(ctx, guaranteedPod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, guaranteedPodKey, guaranteedPod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), guaranteedPod); err != nil {
+				t.Fatalf("Guaranteed pod not running: %v", err)
+			}
+
+			// Request a bit more than the allocatable memory in total to cross the eviction threshold
+			podMemory := resource.MustParse(pressurePodMemory)
+			allocatable := node.Status.Allocatable[corev1.ResourceMemory]
+			count := min(int(allocatable.Value()/podMemory.Value())+2, pressurePodsMax)
+			t.Logf("Creating %d BestEffort pods filling %s each on node %s (allocatable %s)",
+				count, pressurePodMemory, node.Name, allocatable.String())
+
+			var pressurePods []*corev1.Pod
+			for i := range count {
+				pod := newPressurePod(cfg.Namespace(), fmt.Sprintf("memory-pressure-%d", i), node, fmt.Sprintf(
+					"dd if=/dev/zero of=/pressure/fill bs=1M count=%d && sleep 3600", podMemory.Value()/(1024*1024)))
+				pod.Labels = bestEffortLabels
+				if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+					t.Errorf("Failed to create pressure pod %s: %v", pod.Name, err)
+					continue
+				}
+				pressurePods = append(pressurePods, pod)
+			}
+			ctx = context.WithValue(ctx, pressurePodsKey, pressurePods)
+
+			return ctx
+		}).
+		Assess("node reports memory pressure", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			node := ctx.Value(nodeKey).(*corev1.Node)
+
+			// The condition may clear as soon as evictions relieve the pressure, so it is polled often
+			err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var currentNode corev1.Node
+				if err := cfg.Client().Resources().Get(ctx, node.Name, "", &currentNode); err != nil {
+					return false, err
+				}
+
+				return nodeConditionTrue(&currentNode, corev1.NodeMemoryPressure), nil
+			})
+			if err != nil {
+				t.Fatalf("Node %s never reported %s: %v", node.Name, corev1.NodeMemoryPressure, err)
+			}
+			t.Logf("✓ Node %s reported %s", node.Name, corev1.NodeMemoryPressure)
+
+			return ctx
+		}).
+		Assess("BestEffort pods are evicted", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var evicted []string
+			selector := metav1.FormatLabelSelector(&metav1.LabelSelector{MatchLabels: bestEffortLabels})
+			err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var pods corev1.PodList
+				if err := cfg.Client().Resources(cfg.Namespace()).List(ctx, &pods, resources.WithLabelSelector(selector)); err != nil {
+					return false, err
+				}
+
+				evicted = evicted[:0]
+				for _, pod := range pods.Items {
+					if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == evictedReason {
+						evicted = append(evicted, pod.Name)
+					}
+				}
+				return len(evicted) > 0, nil
+			})
+			if err != nil {
+				t.Fatalf("No BestEffort pod was evicted: %v", err)
+			}
+			t.Logf("✓ Kubelet evicted %d BestEffort pods: %v", len(evicted), evicted)
+
+			return ctx
+		}).
+		Assess("Guaranteed pod is not evicted", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(guaranteedPodKey).(*corev1.Pod)
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+			if currentPod.Status.Phase != corev1.PodRunning || currentPod.Status.Reason == evictedReason {
+				t.Fatalf("Guaranteed pod %s was disrupted: phase %s, reason %q: %s",
+					pod.Name, currentPod.Status.Phase, currentPod.Status.Reason, currentPod.Status.Message)
+			}
+			t.Logf("✓ Guaranteed pod %s kept running through the memory pressure", pod.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Relieve the node first
+			pressurePods, _ := ctx.Value(pressurePodsKey).([]*corev1.Pod)
+			for _, pod := range pressurePods {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete pressure pod %s: %v", pod.Name, err)
+				}
+			}
+			if pod, ok := ctx.Value(guaranteedPodKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Guaranteed pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, pressureFeature)
+}
+
+// newPressurePod creates a pod running a shell command on a specific node, with a memory-backed
+// emptyDir mounted on /pressure whose content is charged to the pod's memory
+func newPressurePod(namespace, name string, node *corev1.Node, command string) *corev1.Pod {
+	pod := newSchedulingPod(namespace, name)
+	pod.Spec.NodeSelector = map[string]string{corev1.LabelHostname: node.Labels[corev1.LabelHostname]}
+	container := &pod.Spec.Containers[0]
+	container.Command = []string{"sh", "-c", command}
+	container.VolumeMounts = []corev1.VolumeMount{{Name: "pressure", MountPath: "/pressure"}}
+	pod.Spec.Volumes = []corev1.Volume{
+		{
+			Name: "pressure",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+			},
+		},
+	}
+
+	return pod
+}
+
+// nodeConditionTrue reports whether a node has the given condition set to True
+func nodeConditionTrue(node *corev1.Node, conditionType corev1.NodeConditionType) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
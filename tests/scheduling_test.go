@@ -0,0 +1,533 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+// pressureTaintKeys are the taints the node lifecycle controller applies for node pressure conditions
+var pressureTaintKeys = []string{
+	corev1.TaintNodeMemoryPressure,
+	corev1.TaintNodeDiskPressure,
+	corev1.TaintNodePIDPressure,
+}
+
+func TestPressureTaintAvoidance(t *testing.T) {
+	pressuredNodesKey := any("pressured-nodes-key")
+	podsKey := any("pods-key")
+
+	trackTest(t)
+
+	pressureFeature := features.New("scheduling/pressure-taints").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+
+			pressured := map[string][]string{}
+			for _, node := range nodes.Items {
+				for _, taint := range node.Spec.Taints {
+					for _, key := range pressureTaintKeys {
+						if taint.Key == key && taint.Effect == corev1.TaintEffectNoSchedule {
+							pressured[node.Name] = append(pressured[node.Name], key)
+						}
+					}
+				}
+			}
+
+			if len(pressured) == 0 {
+				skipTest(t, skipReasonNotApplicable, "No node carries a pressure taint, nothing to validate on a healthy cluster")
+			}
+			for node, taints := range pressured {
+				t.Logf("Node %s carries pressure taints: %s", node, strings.Join(taints, ", "))
+			}
+
+			return context.WithValue(ctx, pressuredNodesKey, pressured)
+		}).
+		Assess("pods avoid pressured nodes", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pressured := ctx.Value(pressuredNodesKey).(map[string][]string)
+
+			var pods []*corev1.Pod
+			for i := 0; i < 3; i++ {
+				pod := newSchedulingPod(cfg.Namespace(), fmt.Sprintf("pressure-test-%d", i))
+				if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+					t.Fatal(err)
+				}
+				pods = append(pods, pod)
+			}
+			ctx = context.WithValue(ctx, podsKey, pods)
+
+			for _, pod := range pods {
+				nodeName, err := waitForPodScheduled(ctx, cfg.Client().Resources(), pod, 1*time.Minute)
+				if err != nil {
+					// All schedulable nodes may be under pressure, in which case the pod must stay pending
+					t.Logf("Pod %s was not scheduled: %v", pod.Name, err)
+					continue
+				}
+
+				if taints, ok := pressured[nodeName]; ok {
+					t.Fatalf("Pod %s was scheduled on node %s despite pressure taints %v", pod.Name, nodeName, taints)
+				}
+				t.Logf("✓ Pod %s avoided pressured nodes and landed on %s", pod.Name, nodeName)
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pods, ok := ctx.Value(podsKey).([]*corev1.Pod); ok {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, pressureFeature)
+}
+
+func TestNodeAffinity(t *testing.T) {
+	nodeKey := any("node-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	matchingFeature := features.New("scheduling/node-affinity-matching").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+
+			node := firstSchedulableNode(nodes.Items)
+			if node == nil {
+				skipTest(t, skipReasonNoEligibleNode, "No schedulable node without NoSchedule taints found")
+			}
+			t.Logf("Targeting node %s with labels %v", node.Name, node.Labels)
+
+			return context.WithValue(ctx, nodeKey, node)
+		}).
+		Assess("pod lands on the node matching its affinity", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			node := ctx.Value(nodeKey).(*corev1.Node)
+
+			hostname, ok := node.Labels[corev1.LabelHostname]
+			if !ok {
+				t.Fatalf("Node %s has no %s label", node.Name, corev1.LabelHostname)
+			}
+
+			pod := newSchedulingPod(cfg.Namespace(), "affinity-test-matching")
+			setRequiredNodeAffinity(pod, corev1.LabelHostname, hostname)
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			nodeName, err := waitForPodScheduled(ctx, cfg.Client().Resources(), pod, 1*time.Minute)
+			if err != nil {
+				t.Fatalf("Pod %s was not scheduled: %v", pod.Name, err)
+			}
+			if nodeName != node.Name {
+				t.Fatalf("Pod %s was scheduled on node %s, expected %s", pod.Name, nodeName, node.Name)
+			}
+			t.Logf("✓ Pod %s scheduled on node %s matching %s=%s", pod.Name, nodeName, corev1.LabelHostname, hostname)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	impossibleFeature := features.New("scheduling/node-affinity-impossible").
+		Assess("pod with unsatisfiable affinity stays pending", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newSchedulingPod(cfg.Namespace(), "affinity-test-impossible")
+			setRequiredNodeAffinity(pod, corev1.LabelArchStable, "riscv999")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			nodeName, err := waitForPodScheduled(ctx, cfg.Client().Resources(), pod, 30*time.Second)
+			if err == nil {
+				t.Fatalf("Pod %s should stay pending, but it was scheduled on node %s", pod.Name, nodeName)
+			}
+			if !wait.Interrupted(err) {
+				t.Fatal(err)
+			}
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+			if currentPod.Status.Phase != corev1.PodPending {
+				t.Fatalf("Pod %s should be %s, got %s", pod.Name, corev1.PodPending, currentPod.Status.Phase)
+			}
+			t.Logf("✓ Pod %s with %s=riscv999 affinity stayed pending", pod.Name, corev1.LabelArchStable)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, matchingFeature, impossibleFeature)
+}
+
+func TestTaintsAndTolerations(t *testing.T) {
+	nodeKey := any("node-key")
+	podsKey := any("pods-key")
+	taint := corev1.Taint{Key: "e2e-test", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+	trackTest(t)
+
+	// Tainting a live node needs the opt-in grant and leaves the node tainted if the run is killed
+	if os.Getenv("NODE_TAINT_TEST") != "true" {
+		skipTest(t, skipReasonOptIn, "NODE_TAINT_TEST not set to true, skipping taints and tolerations test")
+	}
+
+	taintFeature := features.New("scheduling/taints-and-tolerations").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var nodes corev1.NodeList
+			if err := cfg.Client().Resources().List(ctx, &nodes); err != nil {
+				t.Fatal(err)
+			}
+
+			node := firstSchedulableNode(nodes.Items)
+			if node == nil {
+				skipTest(t, skipReasonNoEligibleNode, "No schedulable node without NoSchedule taints found")
+			}
+
+			err := setNodeTaint(ctx, cfg.Client().Resources(), node.Name, taint, true)
+			if apierrors.IsForbidden(err) {
+				skipTest(t, skipReasonForbidden, "Updating nodes is forbidden, apply k8s/optional/node-taint.yaml: %v", err)
+			}
+			if err != nil {
+				t.Fatalf("Failed to taint node %s: %v", node.Name, err)
+			}
+			// Remove the taint even if the setup or an assessment aborts the feature
+			t.Cleanup(func() {
+				if err := setNodeTaint(context.Background(), cfg.Client().Resources(), node.Name, taint, false); err != nil {
+					t.Logf("Failed to remove taint from node %s: %v", node.Name, err)
+				}
+			})
+			t.Logf("Tainted node %s with %s=%s:%s", node.Name, taint.Key, taint.Value, taint.Effect)
+
+			return context.WithValue(ctx, nodeKey, node)
+		}).
+		Assess("pod without toleration stays pending", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			node := ctx.Value(nodeKey).(*corev1.Node)
+
+			// Pin the pod to the tainted node so that other nodes cannot take it
+			pod := newSchedulingPod(cfg.Namespace(), "taint-test-untolerated")
+			setRequiredNodeAffinity(pod, corev1.LabelHostname, node.Labels[corev1.LabelHostname])
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+			ctx = context.WithValue(ctx, podsKey, append(pods, pod))
+
+			nodeName, err := waitForPodScheduled(ctx, cfg.Client().Resources(), pod, 30*time.Second)
+			if err == nil {
+				t.Fatalf("Pod %s without toleration was scheduled on tainted node %s", pod.Name, nodeName)
+			}
+			if !wait.Interrupted(err) {
+				t.Fatal(err)
+			}
+			t.Logf("✓ Pod %s without toleration stayed pending", pod.Name)
+
+			return ctx
+		}).
+		Assess("pod with toleration runs", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			node := ctx.Value(nodeKey).(*corev1.Node)
+
+			pod := newSchedulingPod(cfg.Namespace(), "taint-test-tolerated")
+			setRequiredNodeAffinity(pod, corev1.LabelHostname, node.Labels[corev1.LabelHostname])
+			pod.Spec.Tolerations = []corev1.Toleration{
+				{
+					Key:      taint.Key,
+					Operator: corev1.TolerationOpEqual,
+					Value:    taint.Value,
+					Effect:   taint.Effect,
+				},
+			}
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+			ctx = context.WithValue(ctx, podsKey, append(pods, pod))
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod %s with toleration did not start on node %s: %v", pod.Name, node.Name, err)
+			}
+			t.Logf("✓ Pod %s with toleration is running on tainted node %s", pod.Name, node.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pods, ok := ctx.Value(podsKey).([]*corev1.Pod); ok {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, taintFeature)
+}
+
+// schedulerName is the component reported by events of the default scheduler
+const schedulerName = "default-scheduler"
+
+func TestSchedulerEvents(t *testing.T) {
+	podsKey := any("pods-key")
+
+	trackTest(t)
+
+	eventsFeature := features.New("scheduling/scheduler-events").
+		Assess("scheduled pod gets a Scheduled event", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newSchedulingPod(cfg.Namespace(), "scheduler-events-scheduled")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+			ctx = context.WithValue(ctx, podsKey, append(pods, pod))
+
+			event, err := waitForPodEvent(ctx, cfg.Client().Resources(), pod.Namespace, pod.Name, "Scheduled")
+			if err != nil {
+				t.Fatalf("No Scheduled event for pod %s: %v", pod.Name, err)
+			}
+			if component := eventComponent(event); component != schedulerName {
+				t.Fatalf("Scheduled event of pod %s was emitted by %q, expected %q", pod.Name, component, schedulerName)
+			}
+			t.Logf("✓ %s emitted Scheduled event for pod %s: %s", schedulerName, pod.Name, event.Message)
+
+			return ctx
+		}).
+		Assess("unschedulable pod gets a FailedScheduling event", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// No node offers a thousand CPUs
+			pod := newSchedulingPod(cfg.Namespace(), "scheduler-events-unschedulable")
+			pod.Spec.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1000")}
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+			ctx = context.WithValue(ctx, podsKey, append(pods, pod))
+
+			event, err := waitForPodEvent(ctx, cfg.Client().Resources(), pod.Namespace, pod.Name, "FailedScheduling")
+			if err != nil {
+				t.Fatalf("No FailedScheduling event for pod %s: %v", pod.Name, err)
+			}
+			if component := eventComponent(event); component != schedulerName {
+				t.Fatalf("FailedScheduling event of pod %s was emitted by %q, expected %q", pod.Name, component, schedulerName)
+			}
+			if !strings.Contains(event.Message, "Insufficient cpu") {
+				t.Fatalf("FailedScheduling event of pod %s does not explain the missing CPU: %s", pod.Name, event.Message)
+			}
+			t.Logf("✓ %s emitted FailedScheduling event for pod %s: %s", schedulerName, pod.Name, event.Message)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pods, ok := ctx.Value(podsKey).([]*corev1.Pod); ok {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, eventsFeature)
+}
+
+// waitForPodEvent waits for an event with the given reason to be recorded for a pod and returns it
+func waitForPodEvent(ctx context.Context, client *resources.Resources, namespace, podName, reason string) (*corev1.Event, error) {
+	var event *corev1.Event
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": podName,
+		"reason":              reason,
+	}.AsSelector().String()
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var events corev1.EventList
+		if err := client.WithNamespace(namespace).List(ctx, &events, resources.WithFieldSelector(selector)); err != nil {
+			return false, err
+		}
+		if len(events.Items) == 0 {
+			return false, nil
+		}
+
+		event = &events.Items[0]
+		return true, nil
+	})
+
+	return event, err
+}
+
+// eventComponent returns the component that emitted an event. Events recorded through the
+// events.k8s.io API only set the reporting controller, not the legacy source.
+func eventComponent(event *corev1.Event) string {
+	if event.Source.Component != "" {
+		return event.Source.Component
+	}
+
+	return event.ReportingController
+}
+
+// firstSchedulableNode returns the first node that is not cordoned and carries no NoSchedule or NoExecute taint
+func firstSchedulableNode(nodes []corev1.Node) *corev1.Node {
+	for i := range nodes {
+		if nodeSchedulable(&nodes[i]) {
+			return &nodes[i]
+		}
+	}
+
+	return nil
+}
+
+// nodeSchedulable reports whether a node is not cordoned and carries no NoSchedule or NoExecute taint
+func nodeSchedulable(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setRequiredNodeAffinity requires a pod to be scheduled on nodes carrying the given label value
+func setRequiredNodeAffinity(pod *corev1.Pod, key, value string) {
+	pod.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{
+								Key:      key,
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{value},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newSchedulingPod creates a long-running pod used to observe scheduling decisions
+func newSchedulingPod(namespace, name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "scheduling-test"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    "sleep",
+					Image:   "alpine:latest",
+					Command: []string{"sleep", "3600"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// waitForPodScheduled waits for a pod to be bound to a node and returns the node name
+func waitForPodScheduled(ctx context.Context, client *resources.Resources, pod *corev1.Pod, timeout time.Duration) (string, error) {
+	var nodeName string
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var currentPod corev1.Pod
+		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+			return false, err
+		}
+
+		nodeName = currentPod.Spec.NodeName
+		return nodeName != "", nil
+	})
+
+	return nodeName, err
+}
+
+// setNodeTaint adds or removes a taint on a node, retrying on update conflicts
+func setNodeTaint(ctx context.Context, client *resources.Resources, nodeName string, taint corev1.Taint, present bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var node corev1.Node
+		if err := client.Get(ctx, nodeName, "", &node); err != nil {
+			return err
+		}
+
+		taints := slices.DeleteFunc(node.Spec.Taints, func(existing corev1.Taint) bool {
+			return existing.MatchTaint(&taint)
+		})
+		if present {
+			taints = append(taints, taint)
+		}
+		node.Spec.Taints = taints
+
+		return client.Update(ctx, &node)
+	})
+}
@@ -0,0 +1,982 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/e2e-framework/klient"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestExpiredToken(t *testing.T) {
+	trackTest(t)
+
+	expiredTokenFeature := features.New("security/expired-token").
+		Assess("expired token is rejected", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			token, err := newExpiredToken(time.Now().Add(-1 * time.Hour))
+			if err != nil {
+				t.Fatalf("Failed to build expired token: %v", err)
+			}
+
+			// Keep the TLS settings of the test client but drop its credentials
+			restConfig := rest.AnonymousClientConfig(cfg.Client().RESTConfig())
+			restConfig.BearerToken = token
+			httpClient, err := rest.HTTPClientFor(restConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, restConfig.Host+"/api", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request to API server failed: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Fatalf("Expected HTTP %d for expired token, got %d", http.StatusUnauthorized, resp.StatusCode)
+			}
+
+			// The API server does not disclose why a token was rejected, so the best signal available
+			// is an Unauthorized Status rather than a fallback to anonymous access
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var status metav1.Status
+			if err := json.Unmarshal(body, &status); err != nil {
+				t.Fatalf("Failed to decode response body %q: %v", body, err)
+			}
+			if status.Reason != metav1.StatusReasonUnauthorized {
+				t.Fatalf("Expected status reason %s, got %s (%s)", metav1.StatusReasonUnauthorized, status.Reason, status.Message)
+			}
+			if header := resp.Header.Get("WWW-Authenticate"); header != "" {
+				t.Logf("WWW-Authenticate: %s", header)
+			}
+			t.Log("✓ API server rejected expired token with 401 Unauthorized")
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, expiredTokenFeature)
+}
+
+// newExpiredToken builds a ServiceAccount-shaped JWT expiring at expiry, signed with a throwaway RSA key
+func newExpiredToken(expiry time.Time) (string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss": "https://kubernetes.default.svc.cluster.local",
+		"sub": "system:serviceaccount:default:expired-token-test",
+		"aud": []string{"https://kubernetes.default.svc.cluster.local"},
+		"iat": expiry.Add(-1 * time.Hour).Unix(),
+		"nbf": expiry.Add(-1 * time.Hour).Unix(),
+		"exp": expiry.Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// Exit codes of the read-only root filesystem pod, distinguishing the expected rejection from
+// an unexpected success
+const (
+	rootWriteSucceededExitCode = 3
+	volumeWriteFailedExitCode  = 4
+)
+
+// evictedReason is the pod status reason set by the kubelet when it evicts a pod
+const evictedReason = "Evicted"
+
+func TestContainerWritableLayer(t *testing.T) {
+	podsKey := any("pods-key")
+	ephemeralLimit := resource.MustParse("16Mi")
+
+	trackTest(t)
+
+	// trackPod records a pod in the context so that the teardown deletes it
+	trackPod := func(ctx context.Context, pod *corev1.Pod) context.Context {
+		pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+		return context.WithValue(ctx, podsKey, append(pods, pod))
+	}
+
+	writableLayerFeature := features.New("security/container-writable-layer").
+		Assess("root filesystem is writable by default", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newWritableLayerPod(cfg.Namespace(), "writable-root-test",
+				"echo 'written to the writable layer' > /tmp/root-write && cat /tmp/root-write")
+			ctx = trackPod(ctx, pod)
+
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil {
+				t.Fatalf("Write to the container root filesystem failed: %v", err)
+			}
+			t.Logf("✓ Pod %s wrote to its root filesystem", pod.Name)
+
+			return ctx
+		}).
+		Assess("ephemeral-storage limit constrains the writable layer", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Write 4 times the limit, then wait for the kubelet to notice and evict the pod
+			pod := newWritableLayerPod(cfg.Namespace(), "ephemeral-limit-test",
+				fmt.Sprintf("dd if=/dev/zero of=/tmp/fill bs=1M count=%d && sleep 600", 4*ephemeralLimit.Value()/(1024*1024)))
+			pod.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: ephemeralLimit},
+				Limits:   corev1.ResourceList{corev1.ResourceEphemeralStorage: ephemeralLimit},
+			}
+			ctx = trackPod(ctx, pod)
+
+			_, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute)
+			if !errors.Is(err, errPodFailed) {
+				t.Fatalf("Pod exceeding its ephemeral-storage limit was not stopped: %v", err)
+			}
+
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+			if currentPod.Status.Reason != evictedReason {
+				t.Fatalf("Expected pod %s to be %s, got reason %q: %s",
+					pod.Name, evictedReason, currentPod.Status.Reason, currentPod.Status.Message)
+			}
+			t.Logf("✓ Pod %s was evicted after exceeding its %s ephemeral-storage limit: %s",
+				pod.Name, ephemeralLimit.String(), currentPod.Status.Message)
+
+			return ctx
+		}).
+		Assess("read-only root filesystem rejects writes but allows volume writes", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newWritableLayerPod(cfg.Namespace(), "readonly-root-test", fmt.Sprintf(
+				"if touch /tmp/root-write; then exit %d; fi; "+
+					"echo 'written to the volume' > /data/volume-write || exit %d; "+
+					"cat /data/volume-write", rootWriteSucceededExitCode, volumeWriteFailedExitCode))
+			pod.Spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem = &[]bool{true}[0]
+			ctx = trackPod(ctx, pod)
+
+			exitCode, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute)
+			switch {
+			case exitCode == rootWriteSucceededExitCode:
+				t.Fatalf("Pod %s wrote to its read-only root filesystem", pod.Name)
+			case exitCode == volumeWriteFailedExitCode:
+				t.Fatalf("Pod %s could not write to its mounted volume", pod.Name)
+			case err != nil:
+				t.Fatalf("Read-only root filesystem pod failed: %v", err)
+			}
+			t.Logf("✓ Pod %s was denied writes to its root filesystem and wrote to its volume", pod.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pods
+			pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+			for _, pod := range pods {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod %s: %v", pod.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, writableLayerFeature)
+}
+
+// newWritableLayerPod creates a pod running a shell command as an unprivileged user, with an
+// emptyDir volume mounted on /data
+func newWritableLayerPod(namespace, name, command string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "writable-layer-test"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    "writer",
+					Image:   "alpine:latest",
+					Command: []string{"sh", "-c", command},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "data",
+							MountPath: "/data",
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPodSecurityAdmission(t *testing.T) {
+	podKey := any("pod-key")
+	setupRejectedNamespace, teardownRejectedNamespace := withIsolatedNamespace("psa-privileged", enforceRestrictedPodSecurity)
+	setupAdmittedNamespace, teardownAdmittedNamespace := withIsolatedNamespace("psa-restricted", enforceRestrictedPodSecurity)
+
+	trackTest(t)
+
+	privilegedFeature := features.New("security/pod-security-admission-privileged").
+		WithSetup("create namespace", setupRejectedNamespace).
+		Assess("privileged pod is rejected", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newWritableLayerPod(cfg.Namespace(), "psa-privileged-test", "true")
+			securityContext := pod.Spec.Containers[0].SecurityContext
+			securityContext.Privileged = &[]bool{true}[0]
+			securityContext.AllowPrivilegeEscalation = &[]bool{true}[0]
+
+			err := cfg.Client().Resources().Create(ctx, pod)
+			if err == nil {
+				ctx = context.WithValue(ctx, podKey, pod)
+				t.Fatalf("Privileged pod %s was admitted in namespace %s enforcing the restricted policy", pod.Name, pod.Namespace)
+			}
+			if !apierrors.IsForbidden(err) || !strings.Contains(err.Error(), "violates PodSecurity") {
+				t.Fatalf("Expected a PodSecurity violation for privileged pod %s, got: %v", pod.Name, err)
+			}
+			t.Logf("✓ Privileged pod %s rejected: %v", pod.Name, err)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).
+		WithTeardown("delete namespace", teardownRejectedNamespace).
+		Feature()
+
+	restrictedFeature := features.New("security/pod-security-admission-restricted").
+		WithSetup("create namespace", setupAdmittedNamespace).
+		Assess("restricted-compliant pod runs", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newWritableLayerPod(cfg.Namespace(), "psa-restricted-test", "true")
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			_, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute)
+			if apierrors.IsForbidden(err) {
+				t.Fatalf("Restricted-compliant pod %s was rejected: %v", pod.Name, err)
+			}
+			if err != nil {
+				t.Fatalf("Restricted-compliant pod %s did not succeed: %v", pod.Name, err)
+			}
+			t.Logf("✓ Restricted-compliant pod %s admitted and succeeded", pod.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).
+		WithTeardown("delete namespace", teardownAdmittedNamespace).
+		Feature()
+
+	testenv.Test(t, privilegedFeature, restrictedFeature)
+}
+
+// enforceRestrictedPodSecurity labels a namespace to enforce the restricted Pod Security Standard
+// when it is created, so the test does not need to patch namespaces
+func enforceRestrictedPodSecurity(_ klient.Client, namespace *corev1.Namespace) {
+	if namespace.Labels == nil {
+		namespace.Labels = map[string]string{}
+	}
+	namespace.Labels["pod-security.kubernetes.io/enforce"] = "restricted"
+}
+
+// Custom seccomp profile of TestSeccompProfile, denying the chmod family of syscalls with EPERM. The
+// profile is installed below the kubelet seccomp root, which localhost profiles are relative to.
+const (
+	seccompProfileDir = "e2e-tests"
+	seccompProfile    = `{
+  "defaultAction": "SCMP_ACT_ALLOW",
+  "syscalls": [
+    {
+      "names": ["chmod", "fchmod", "fchmodat", "fchmodat2"],
+      "action": "SCMP_ACT_ERRNO",
+      "errnoRet": 1
+    }
+  ]
+}`
+)
+
+func TestSeccompProfile(t *testing.T) {
+	configMapKey := any("configmap-key")
+	daemonSetKey := any("daemonset-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	// Installing the profile writes to the kubelet directory of every node
+	if os.Getenv("SECCOMP_PROFILE_TEST") != "true" {
+		skipTest(t, skipReasonOptIn, "SECCOMP_PROFILE_TEST not set to true, skipping seccomp profile test")
+	}
+
+	seccompFeature := features.New("security/seccomp-localhost-profile").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			profileName := seccompProfileName(cfg.Namespace())
+			cm := newConfigMap(cfg.Namespace(), "seccomp-profile", path.Base(profileName), seccompProfile)
+			if err := cfg.Client().Resources().Create(ctx, cm); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, configMapKey, cm)
+
+			// Pods cannot ship their own seccomp profile, a DaemonSet copies it to every node
+			daemonSet := newSeccompInstallerDaemonSet(cfg.Namespace(), "seccomp-profile-installer", cm.Name, profileName)
+			if err := cfg.Client().Resources().Create(ctx, daemonSet); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, daemonSetKey, daemonSet)
+
+			if err := waitForDaemonSetReady(ctx, cfg.Client().Resources(), daemonSet); err != nil {
+				t.Fatalf("Seccomp profile installer not ready: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("localhost profile denies chmod", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			profileName := seccompProfileName(cfg.Namespace())
+			pod := newWritableLayerPod(cfg.Namespace(), "seccomp-chmod-test", "touch /tmp/seccomp && chmod 600 /tmp/seccomp")
+			pod.Spec.Containers[0].SecurityContext.SeccompProfile = &corev1.SeccompProfile{
+				Type:             corev1.SeccompProfileTypeLocalhost,
+				LocalhostProfile: &profileName,
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			exitCode, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute)
+			if errors.Is(err, errPodTimedOut) {
+				// A runtime without localhost profile support leaves the container waiting to be created
+				var currentPod corev1.Pod
+				if getErr := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); getErr == nil {
+					for _, status := range currentPod.Status.ContainerStatuses {
+						if waiting := status.State.Waiting; waiting != nil && waiting.Reason == "CreateContainerError" {
+							skipTest(t, skipReasonUnsupportedNode, "Localhost seccomp profiles not supported by the node of pod %s: %s", pod.Name, waiting.Message)
+						}
+					}
+				}
+			}
+			if !errors.Is(err, errPodFailed) {
+				t.Fatalf("Expected chmod to fail under the localhost seccomp profile: %v", err)
+			}
+
+			logs, err := getPodLogs(ctx, cfg, pod, "")
+			if err != nil {
+				t.Fatalf("Failed to get logs of pod %s: %v", pod.Name, err)
+			}
+			if !strings.Contains(logs, "Operation not permitted") {
+				t.Fatalf("Expected chmod in pod %s to fail with EPERM, exit code %d with logs: %s", pod.Name, exitCode, logs)
+			}
+			t.Logf("✓ Seccomp profile %s denied chmod in pod %s (exit code %d)", profileName, pod.Name, exitCode)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+			// The installer removes the profile from the nodes when it is stopped
+			if daemonSet, ok := ctx.Value(daemonSetKey).(*appsv1.DaemonSet); ok && daemonSet != nil {
+				if err := cfg.Client().Resources().Delete(ctx, daemonSet); err != nil {
+					t.Logf("Failed to delete DaemonSet: %v", err)
+				}
+			}
+			if cm, ok := ctx.Value(configMapKey).(*corev1.ConfigMap); ok && cm != nil {
+				if err := cfg.Client().Resources().Delete(ctx, cm); err != nil {
+					t.Logf("Failed to delete ConfigMap: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, seccompFeature)
+}
+
+// seccompProfileName returns the localhost profile of a test namespace, so that concurrent runs do
+// not overwrite or remove each other's profile
+func seccompProfileName(namespace string) string {
+	return path.Join(seccompProfileDir, namespace+"-block-chmod.json")
+}
+
+// newSeccompInstallerDaemonSet creates a DaemonSet copying the seccomp profile of a ConfigMap to the
+// kubelet seccomp root of every node, and removing it when stopped. Writing to the root-owned
+// directory only requires running as root, without any capability.
+func newSeccompInstallerDaemonSet(namespace, name, configMapName, profileName string) *appsv1.DaemonSet {
+	labels := map[string]string{"app": "seccomp-profile-installer"}
+	profileDir := path.Join("/host", path.Dir(profileName))
+	profilePath := path.Join("/host", profileName)
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					// Install the profile on every node the test pod may land on
+					Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					Containers: []corev1.Container{
+						{
+							Name:  "installer",
+							Image: "alpine:latest",
+							Command: []string{
+								"sh", "-c",
+								"mkdir -p " + profileDir + " && cp /profile/" + path.Base(profileName) + " " + profilePath + " || exit 1; " +
+									"trap 'rm -f " + profilePath + "; exit 0' TERM; sleep 3600 & wait",
+							},
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: &[]bool{false}[0],
+								RunAsUser:                &[]int64{0}[0],
+								Capabilities: &corev1.Capabilities{
+									Drop: []corev1.Capability{"ALL"},
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "profile", MountPath: "/profile", ReadOnly: true},
+								{Name: "seccomp", MountPath: "/host"},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "profile",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+								},
+							},
+						},
+						{
+							Name: "seccomp",
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: "/var/lib/kubelet/seccomp",
+									Type: &[]corev1.HostPathType{corev1.HostPathDirectoryOrCreate}[0],
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// AppArmor profiles of TestAppArmorProfile, set with the beta annotation taking the container name as suffix
+const (
+	appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+	appArmorMissingProfile   = "localhost/e2e-tests-nonexistent"
+)
+
+func TestAppArmorProfile(t *testing.T) {
+	nodeKey := any("node-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	// The AppArmor check runs once, both features pin their pod to the node it ran on
+	var (
+		checkOnce sync.Once
+		node      *corev1.Node
+		enabled   bool
+		checkErr  error
+	)
+	requireAppArmor := func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		checkOnce.Do(func() {
+			var nodes corev1.NodeList
+			if checkErr = cfg.Client().Resources().List(ctx, &nodes); checkErr != nil {
+				return
+			}
+			if node = firstSchedulableNode(nodes.Items); node != nil {
+				enabled, checkErr = appArmorEnabled(ctx, t, cfg, node)
+			}
+		})
+
+		switch {
+		case apierrors.IsForbidden(checkErr):
+			// Pod Security Admission rejects the privileged check pod in baseline or restricted namespaces
+			skipTest(t, skipReasonForbidden, "Privileged AppArmor check pod rejected: %v", checkErr)
+		case checkErr != nil:
+			t.Fatalf("Failed to check AppArmor: %v", checkErr)
+		case node == nil:
+			skipTest(t, skipReasonNoEligibleNode, "No schedulable node found")
+		case !enabled:
+			skipTest(t, skipReasonUnsupportedNode, "AppArmor not enabled in the kernel of node %s", node.Name)
+		}
+
+		return context.WithValue(ctx, nodeKey, node)
+	}
+
+	deletePod := func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+			if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+				t.Logf("Failed to delete Pod: %v", err)
+			}
+		}
+
+		return ctx
+	}
+
+	runtimeDefaultFeature := features.New("security/apparmor-runtime-default").
+		Setup(requireAppArmor).
+		Assess("pod starts with the runtime/default profile", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			node := ctx.Value(nodeKey).(*corev1.Node)
+			pod := newAppArmorPod(cfg.Namespace(), "apparmor-runtime-default", node, "runtime/default")
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil {
+				t.Fatalf("Pod with the runtime/default AppArmor profile did not run: %v", err)
+			}
+
+			// The container reads the profile it is confined by
+			logs, err := getPodLogs(ctx, cfg, pod, "")
+			if err != nil {
+				t.Fatalf("Failed to get logs of pod %s: %v", pod.Name, err)
+			}
+			t.Logf("✓ Pod %s ran confined by AppArmor profile %s", pod.Name, strings.TrimSpace(logs))
+
+			return ctx
+		}).
+		Teardown(deletePod).Feature()
+
+	missingProfileFeature := features.New("security/apparmor-missing-profile").
+		Setup(requireAppArmor).
+		Assess("pod with a missing profile does not start", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			node := ctx.Value(nodeKey).(*corev1.Node)
+			pod := newAppArmorPod(cfg.Namespace(), "apparmor-missing-profile", node, appArmorMissingProfile)
+
+			// Depending on the version, the API server, the kubelet or the container runtime rejects the profile
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				// Only a rejection of the profile itself counts, not one by Pod Security Admission or a quota
+				rejectsProfile := apierrors.IsForbidden(err) &&
+					(strings.Contains(strings.ToLower(err.Error()), "apparmor") || strings.Contains(err.Error(), strings.TrimPrefix(appArmorMissingProfile, "localhost/")))
+				if !apierrors.IsInvalid(err) && !rejectsProfile {
+					t.Fatalf("Unexpected error creating pod %s: %v", pod.Name, err)
+				}
+				t.Logf("✓ API server rejected AppArmor profile %s: %v", appArmorMissingProfile, err)
+				return ctx
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			reason, err := waitForPodStartFailure(ctx, cfg.Client().Resources(), pod, 2*time.Minute)
+			if err != nil {
+				t.Fatalf("Pod %s with AppArmor profile %s: %v", pod.Name, appArmorMissingProfile, err)
+			}
+			t.Logf("✓ Pod %s with AppArmor profile %s failed to start: %s", pod.Name, appArmorMissingProfile, reason)
+
+			return ctx
+		}).
+		Teardown(deletePod).Feature()
+
+	testenv.Test(t, runtimeDefaultFeature, missingProfileFeature)
+}
+
+// appArmorEnabled reports whether AppArmor is enabled in the kernel of a node, reading its module
+// parameters from a privileged pod
+func appArmorEnabled(ctx context.Context, t *testing.T, cfg *envconf.Config, node *corev1.Node) (bool, error) {
+	pod := newWritableLayerPod(cfg.Namespace(), names.randomName("apparmor-check", 24), "cat /sys/module/apparmor/parameters/enabled || true")
+	pod.Spec.NodeSelector = map[string]string{corev1.LabelHostname: node.Labels[corev1.LabelHostname]}
+	pod.Spec.SecurityContext = nil
+	pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+		Privileged: &[]bool{true}[0],
+	}
+	defer func() {
+		if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+			t.Logf("Failed to delete AppArmor check pod %s: %v", pod.Name, err)
+		}
+	}()
+
+	if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute); err != nil {
+		return false, err
+	}
+
+	logs, err := getPodLogs(ctx, cfg, pod, "")
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(logs) == "Y", nil
+}
+
+// newAppArmorPod creates a pod printing its AppArmor confinement on a specific node, with its
+// container confined by the given AppArmor profile
+func newAppArmorPod(namespace, name string, node *corev1.Node, profile string) *corev1.Pod {
+	pod := newWritableLayerPod(namespace, name, "cat /proc/self/attr/current")
+	pod.Labels = map[string]string{"app": "apparmor-test"}
+	pod.Annotations = map[string]string{appArmorAnnotationPrefix + pod.Spec.Containers[0].Name: profile}
+	pod.Spec.NodeSelector = map[string]string{corev1.LabelHostname: node.Labels[corev1.LabelHostname]}
+
+	return pod
+}
+
+// waitForPodStartFailure waits for a pod to be rejected by the kubelet or for its container to fail
+// being created, returning the reason reported in the pod status
+func waitForPodStartFailure(ctx context.Context, client *resources.Resources, pod *corev1.Pod, timeout time.Duration) (string, error) {
+	var reason string
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		var currentPod corev1.Pod
+		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+			return false, err
+		}
+
+		switch currentPod.Status.Phase {
+		case corev1.PodRunning, corev1.PodSucceeded:
+			return false, fmt.Errorf("unexpectedly reached phase %s", currentPod.Status.Phase)
+		case corev1.PodFailed:
+			reason = fmt.Sprintf("%s: %s", currentPod.Status.Reason, currentPod.Status.Message)
+			return true, nil
+		}
+
+		for _, status := range currentPod.Status.ContainerStatuses {
+			if waiting := status.State.Waiting; waiting != nil && waiting.Reason == "CreateContainerError" {
+				reason = fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+
+	return reason, err
+}
+
+// Webhook of TestWebhookAuditAnnotation and the audit annotation it sets. The API server prefixes
+// the annotation key with the webhook name, so the key must not carry a prefix of its own.
+const (
+	auditAnnotationKey   = "decision"
+	auditAnnotationValue = "allow"
+	auditWebhookName     = "audit-annotation.e2etests.example.com"
+	auditWebhookPort     = 8443
+)
+
+func TestWebhookAuditAnnotation(t *testing.T) {
+	webhookKey := any("webhook-key")
+	podKey := any("pod-key")
+	auditLogPath := os.Getenv("AUDIT_LOG_PATH")
+	podIP := os.Getenv("POD_IP")
+
+	trackTest(t)
+
+	if auditLogPath == "" || podIP == "" {
+		skipTest(t, skipReasonNotConfigured, "AUDIT_LOG_PATH or POD_IP not set, skipping webhook audit annotation test")
+	}
+
+	var webhookRequests atomic.Int64
+	auditAnnotationFeature := features.New("security/webhook-audit-annotation").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// The API server calls back into the test pod, which serves the webhook itself
+			caBundle, certificate, err := newServingCertificate(net.ParseIP(podIP))
+			if err != nil {
+				t.Fatalf("Failed to create webhook certificate: %v", err)
+			}
+			// Listen synchronously so that port conflicts fail the setup
+			listener, err := net.Listen("tcp", fmt.Sprintf(":%d", auditWebhookPort))
+			if err != nil {
+				t.Fatalf("Failed to listen on webhook port %d: %v", auditWebhookPort, err)
+			}
+			server := &http.Server{
+				Handler:           newAuditAnnotationWebhook(&webhookRequests),
+				TLSConfig:         &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12},
+				ReadHeaderTimeout: 5 * time.Second,
+			}
+			go func() {
+				if err := server.ServeTLS(listener, "", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					slog.Error("webhook server failed", "error", err)
+				}
+			}()
+			t.Cleanup(func() { _ = server.Close() })
+
+			webhook := newAuditAnnotationWebhookConfiguration(cfg.Namespace(), fmt.Sprintf("https://%s/validate",
+				net.JoinHostPort(podIP, strconv.Itoa(auditWebhookPort))), caBundle)
+			if err := cfg.Client().Resources().Create(ctx, webhook); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, webhookKey, webhook)
+
+			// Webhook configurations take effect asynchronously, dry-run creations call the webhook
+			// without persisting the pod until the API server picks it up
+			probe := newWritableLayerPod(cfg.Namespace(), "audit-webhook-probe", "true")
+			dryRun := func(options *metav1.CreateOptions) { options.DryRun = []string{metav1.DryRunAll} }
+			err = wait.PollUntilContextTimeout(ctx, 2*time.Second, time.Minute, true, func(ctx context.Context) (bool, error) {
+				if err := cfg.Client().Resources().Create(ctx, probe.DeepCopy(), dryRun); err != nil {
+					t.Logf("Dry-run pod creation failed: %v", err)
+				}
+				return webhookRequests.Load() > 0, nil
+			})
+			if err != nil {
+				t.Fatalf("API server never called the webhook: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("pod creation audit event carries the webhook annotation", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := newWritableLayerPod(cfg.Namespace(), "audit-annotation-test", "true")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatalf("Failed to create pod: %v", err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			// Audit backends batch events, so the event may be written a little after the request
+			annotation := auditWebhookName + "/" + auditAnnotationKey
+			var value string
+			err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+				event, err := findPodCreateAuditEvent(auditLogPath, pod)
+				if err != nil || event == nil {
+					return false, err
+				}
+
+				value = event.Annotations[annotation]
+				return true, nil
+			})
+			if err != nil {
+				t.Fatalf("No audit event for the creation of pod %s found in %s: %v", pod.Name, auditLogPath, err)
+			}
+			if value != auditAnnotationValue {
+				t.Fatalf("Expected audit annotation %s=%s on the creation of pod %s, got %q", annotation, auditAnnotationValue, pod.Name, value)
+			}
+			t.Logf("✓ Audit event for the creation of pod %s carries %s=%s", pod.Name, annotation, value)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete the webhook first so that it does not outlive the server
+			if webhook, ok := ctx.Value(webhookKey).(*admissionregistrationv1.ValidatingWebhookConfiguration); ok && webhook != nil {
+				if err := cfg.Client().Resources().Delete(ctx, webhook); err != nil {
+					t.Logf("Failed to delete ValidatingWebhookConfiguration: %v", err)
+				}
+			}
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, auditAnnotationFeature)
+}
+
+// newAuditAnnotationWebhook returns a validating webhook handler allowing every request with an
+// audit annotation, counting the requests it serves
+func newAuditAnnotationWebhook(requests *atomic.Int64) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		var review admissionv1.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+			http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+			return
+		}
+
+		review.Response = &admissionv1.AdmissionResponse{
+			UID:              review.Request.UID,
+			Allowed:          true,
+			AuditAnnotations: map[string]string{auditAnnotationKey: auditAnnotationValue},
+		}
+		review.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	})
+
+	return mux
+}
+
+// newAuditAnnotationWebhookConfiguration creates a ValidatingWebhookConfiguration calling url on pod
+// creations in the given namespace
+func newAuditAnnotationWebhookConfiguration(namespace, url string, caBundle []byte) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	failurePolicy := admissionregistrationv1.Fail
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			// Webhook configurations are cluster-scoped, the namespace keeps concurrent runs apart
+			Name:   "audit-annotation-" + namespace,
+			Labels: map[string]string{"app": "audit-annotation-test"},
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name: auditWebhookName,
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					URL:      &url,
+					CABundle: caBundle,
+				},
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{""},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"pods"},
+						},
+					},
+				},
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{corev1.LabelMetadataName: namespace},
+				},
+				FailurePolicy:           &failurePolicy,
+				SideEffects:             &sideEffects,
+				AdmissionReviewVersions: []string{"v1"},
+				TimeoutSeconds:          &[]int32{5}[0],
+			},
+		},
+	}
+}
+
+// newServingCertificate creates a self-signed serving certificate for an IP address, returning the
+// PEM-encoded certificate to trust along with the certificate to serve
+func newServingCertificate(ip net.IP) ([]byte, tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: ip.String()},
+		IPAddresses:           []net.IP{ip},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+
+	certificate := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), certificate, nil
+}
+
+// auditEvent is the subset of an audit.k8s.io/v1 Event read from the audit log
+type auditEvent struct {
+	Verb      string `json:"verb"`
+	ObjectRef *struct {
+		Resource  string `json:"resource"`
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	} `json:"objectRef"`
+	Stage       string            `json:"stage"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// findPodCreateAuditEvent returns the ResponseComplete audit event of a pod creation from a JSON
+// lines audit log, or nil if the log holds none yet
+func findPodCreateAuditEvent(path string, pod *corev1.Pod) (*auditEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var event auditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+
+		if event.Verb == "create" && event.Stage == "ResponseComplete" && event.ObjectRef != nil &&
+			event.ObjectRef.Resource == "pods" && event.ObjectRef.Namespace == pod.Namespace && event.ObjectRef.Name == pod.Name {
+			return &event, nil
+		}
+	}
+
+	return nil, scanner.Err()
+}
@@ -0,0 +1,613 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestHeadlessWithPorts(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+
+	trackTest(t)
+
+	headlessFeature := features.New("network/headless-with-ports").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create a 2-replica nginx deployment with its own app label
+			deployment := newNetworkDeployment(cfg.Namespace(), "headless-test-nginx")
+			deployment.Spec.Replicas = &[]int32{2}[0]
+			setDeploymentAppLabel(deployment, "headless-test")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			// Wait for deployment to be ready
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+
+			// Create headless service with a named port
+			service := newHeadlessService(cfg.Namespace(), "headless-test-service", "headless-test")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			return ctx
+		}).
+		Assess("endpointslices carry pod addresses and named ports", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			var pods corev1.PodList
+			if err := cfg.Client().Resources(cfg.Namespace()).List(ctx, &pods, resources.WithLabelSelector("app=headless-test")); err != nil {
+				t.Fatal(err)
+			}
+			expectedIPs := map[string]bool{}
+			for _, pod := range pods.Items {
+				if pod.Status.PodIP != "" && pod.DeletionTimestamp == nil {
+					expectedIPs[pod.Status.PodIP] = true
+				}
+			}
+			if len(expectedIPs) != 2 {
+				t.Fatalf("Expected 2 backend pod IPs, got %d", len(expectedIPs))
+			}
+
+			slices, err := waitForEndpointSliceAddresses(ctx, cfg.Client().Resources(cfg.Namespace()), service.Name, len(expectedIPs))
+			if err != nil {
+				t.Fatalf("EndpointSlices not populated: %v", err)
+			}
+
+			seenIPs := map[string]bool{}
+			for _, slice := range slices {
+				foundPort := false
+				for _, port := range slice.Ports {
+					if port.Name != nil && *port.Name == "http" && port.Port != nil && *port.Port == 8080 {
+						foundPort = true
+					}
+				}
+				if !foundPort {
+					t.Fatalf("EndpointSlice %s does not carry named port http/8080", slice.Name)
+				}
+
+				for _, endpoint := range slice.Endpoints {
+					for _, address := range endpoint.Addresses {
+						seenIPs[address] = true
+					}
+				}
+			}
+
+			for ip := range expectedIPs {
+				if !seenIPs[ip] {
+					t.Fatalf("Pod IP %s missing from EndpointSlices of service %s", ip, service.Name)
+				}
+			}
+			t.Logf("✓ EndpointSlices for %s carry %d pod addresses and named port http", service.Name, len(expectedIPs))
+
+			return ctx
+		}).
+		Assess("SRV records resolve named ports", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			srvName := "_http._tcp." + service.Name + "." + cfg.Namespace() + ".svc.cluster.local"
+			srvPod := newSRVLookupPod(cfg.Namespace(), "headless-test-srv", srvName, 8080)
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), srvPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+				t.Fatal(err)
+			}
+
+			if podFailedAsExpected(ctx, cfg.Client().Resources(), srvPod) {
+				t.Fatalf("SRV lookup of %s did not return port 8080", srvName)
+			}
+			t.Logf("✓ SRV record %s resolved to port 8080", srvName)
+
+			if err := cfg.Client().Resources().Delete(ctx, srvPod); err != nil {
+				t.Logf("Failed to delete SRV lookup pod: %v", err)
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete service
+			if service := ctx.Value(serviceKey).(*corev1.Service); service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployment
+			if deployment := ctx.Value(deploymentKey).(*appsv1.Deployment); deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, headlessFeature)
+}
+
+func TestNamedTargetPort(t *testing.T) {
+	deploymentsKey := any("deployments-key")
+	serviceKey := any("service-key")
+
+	trackTest(t)
+
+	namedPortFeature := features.New("network/named-target-port").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Two backends exposing the same port name on different numbers
+			var deployments []*appsv1.Deployment
+			createdAt := time.Now()
+			for _, port := range []int32{8081, 8082} {
+				deployment := newNamedPortDeployment(cfg.Namespace(), fmt.Sprintf("named-port-%d", port), "named-port-test", port)
+				if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+					t.Fatal(err)
+				}
+				deployments = append(deployments, deployment)
+			}
+			ctx = context.WithValue(ctx, deploymentsKey, deployments)
+
+			for _, deployment := range deployments {
+				if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+					t.Fatalf("Deployment %s not ready: %v", deployment.Name, err)
+				}
+			}
+
+			// Service targeting the port by name
+			service := newNetworkService(cfg.Namespace(), "named-port-service")
+			service.Spec.Selector = map[string]string{"app": "named-port-test"}
+			service.Spec.Ports[0].Name = "http"
+			service.Spec.Ports[0].TargetPort = intstr.FromString("http")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			return ctx
+		}).
+		Assess("named port routes to differing numeric ports", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+
+			expected := []string{"backend-8081", "backend-8082"}
+			assertServiceResponses(ctx, t, cfg, "named-port-client-initial", service.Name, expected)
+			t.Logf("✓ Service %s reached backends on ports 8081 and 8082 through targetPort http", service.Name)
+
+			return ctx
+		}).
+		Assess("named port follows container port change", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			deployment := ctx.Value(deploymentsKey).([]*appsv1.Deployment)[0]
+
+			// Move the first backend to a new numeric port, keeping the port name
+			var current appsv1.Deployment
+			if err := cfg.Client().Resources().Get(ctx, deployment.Name, deployment.Namespace, &current); err != nil {
+				t.Fatal(err)
+			}
+			updated := newNamedPortDeployment(cfg.Namespace(), deployment.Name, "named-port-test", 8083)
+			current.Spec.Template = updated.Spec.Template
+			if err := cfg.Client().Resources().Update(ctx, &current); err != nil {
+				t.Fatalf("Failed to update deployment %s: %v", deployment.Name, err)
+			}
+
+			if err := waitForDeploymentRolledOut(ctx, cfg.Client().Resources(), &current); err != nil {
+				t.Fatalf("Deployment %s did not roll out: %v", deployment.Name, err)
+			}
+
+			expected := []string{"backend-8082", "backend-8083"}
+			assertServiceResponses(ctx, t, cfg, "named-port-client-updated", service.Name, expected)
+			t.Logf("✓ Service %s followed the container port change to 8083", service.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete service
+			if service := ctx.Value(serviceKey).(*corev1.Service); service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete deployments
+			deployments, _ := ctx.Value(deploymentsKey).([]*appsv1.Deployment)
+			for _, deployment := range deployments {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment %s: %v", deployment.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, namedPortFeature)
+}
+
+// endpointTerminationDrain is how long the backends of TestEndpointRemovalOnDelete keep serving
+// after their deletion, through a preStop hook
+const endpointTerminationDrain = 20 * time.Second
+
+func TestEndpointRemovalOnDelete(t *testing.T) {
+	deploymentKey := any("deployment-key")
+	serviceKey := any("service-key")
+	podKey := any("pod-key")
+	deletedBackendKey := any("deleted-backend-key")
+
+	trackTest(t)
+
+	removalFeature := features.New("network/endpoint-removal-on-delete").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			supported, err := serverVersionAtLeast(cfg, 1, 26)
+			if err != nil {
+				t.Fatalf("Failed to get server version: %v", err)
+			}
+			if !supported {
+				skipTest(t, skipReasonUnsupportedVersion, "EndpointSlice serving and terminating conditions require Kubernetes 1.26 or later, skipping")
+			}
+
+			// The preStop hook keeps deleted backends serving while their endpoints are terminating
+			deployment := newNamedPortDeployment(cfg.Namespace(), "endpoint-removal", "endpoint-removal", 8080)
+			deployment.Spec.Replicas = &[]int32{2}[0]
+			deployment.Spec.Template.Spec.Containers[0].Lifecycle = &corev1.Lifecycle{
+				PreStop: &corev1.LifecycleHandler{
+					Exec: &corev1.ExecAction{Command: []string{"sleep", strconv.Itoa(int(endpointTerminationDrain.Seconds()))}},
+				},
+			}
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, deployment); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, deploymentKey, deployment)
+
+			service := newNetworkService(cfg.Namespace(), "endpoint-removal")
+			service.Spec.Selector = map[string]string{"app": "endpoint-removal"}
+			service.Spec.Ports[0].Name = "http"
+			service.Spec.Ports[0].TargetPort = intstr.FromString("http")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			pod := newClientPod(cfg.Namespace(), "endpoint-removal-client", service.Name)
+			pod.Spec.Containers[0].Command = []string{"sleep", "3600"}
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForDeploymentReady(ctx, cfg.Client().Resources(), deployment, createdAt); err != nil {
+				t.Fatalf("Deployment not ready: %v", err)
+			}
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Client pod not running: %v", err)
+			}
+			if _, err := waitForEndpointSliceAddresses(ctx, cfg.Client().Resources(cfg.Namespace()), service.Name, 2); err != nil {
+				t.Fatalf("EndpointSlices not populated: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("deleted backend drains without failing requests", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			client := ctx.Value(podKey).(*corev1.Pod)
+			backend := firstPodWithLabels(ctx, t, cfg, map[string]string{"app": "endpoint-removal"})
+
+			// Steady request stream from the client pod, covering the whole termination of the backend
+			streamSeconds := int((endpointTerminationDrain + 10*time.Second).Seconds())
+			command := []string{"sh", "-c", fmt.Sprintf(
+				`end=$(($(date +%%s) + %d)); while [ "$(date +%%s)" -lt "$end" ]; do `+
+					`curl -s -o /dev/null -w '%%{http_code}\n' --max-time 2 http://%s; sleep 0.2; done`,
+				streamSeconds, service.Name)}
+			var stdout, stderr bytes.Buffer
+			streamDone := make(chan error, 1)
+			go func() {
+				streamDone <- cfg.Client().Resources().ExecInPod(ctx, client.Namespace, client.Name, "curl-test", command, &stdout, &stderr)
+			}()
+
+			// Let the stream reach both backends before deleting one
+			time.Sleep(2 * time.Second)
+			deletedAt := time.Now()
+			if err := cfg.Client().Resources().Delete(ctx, backend); err != nil {
+				t.Fatalf("Failed to delete backend pod %s: %v", backend.Name, err)
+			}
+
+			// A terminating endpoint stops receiving new connections but keeps serving in-flight ones
+			var conditions discoveryv1.EndpointConditions
+			err := wait.PollUntilContextTimeout(ctx, 200*time.Millisecond, endpointTerminationDrain, true, func(ctx context.Context) (bool, error) {
+				endpoint, err := endpointForPod(ctx, cfg.Client().Resources(cfg.Namespace()), service.Name, backend.Name)
+				if err != nil || endpoint == nil {
+					return false, err
+				}
+
+				conditions = endpoint.Conditions
+				return conditionFalse(conditions.Ready) && conditionTrue(conditions.Serving) && conditionTrue(conditions.Terminating), nil
+			})
+			if err != nil {
+				t.Fatalf("Endpoint of %s never turned ready=false, serving=true, terminating=true (last %s): %v",
+					backend.Name, formatEndpointConditions(conditions), err)
+			}
+			t.Logf("✓ Endpoint of %s marked %s %s after its deletion",
+				backend.Name, formatEndpointConditions(conditions), time.Since(deletedAt).Round(time.Millisecond))
+
+			if err := <-streamDone; err != nil {
+				t.Fatalf("Request stream failed: %v: %s", err, stderr.String())
+			}
+			codes := strings.Fields(stdout.String())
+			var failed []string
+			for i, code := range codes {
+				if code != "200" {
+					failed = append(failed, fmt.Sprintf("#%d=%s", i, code))
+				}
+			}
+			if len(failed) > 0 {
+				t.Fatalf("%d of %d requests failed while %s was terminating: %v", len(failed), len(codes), backend.Name, failed)
+			}
+			t.Logf("✓ All %d requests succeeded while %s was terminating", len(codes), backend.Name)
+
+			ctx = context.WithValue(ctx, deletedBackendKey, backend)
+			return ctx
+		}).
+		Assess("endpoint is removed once the backend is gone", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			service := ctx.Value(serviceKey).(*corev1.Service)
+			backend := ctx.Value(deletedBackendKey).(*corev1.Pod)
+
+			err := wait.PollUntilContextTimeout(ctx, 1*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+				endpoint, err := endpointForPod(ctx, cfg.Client().Resources(cfg.Namespace()), service.Name, backend.Name)
+				return endpoint == nil, err
+			})
+			if err != nil {
+				t.Fatalf("Endpoint of %s still listed after its termination: %v", backend.Name, err)
+			}
+			t.Logf("✓ Endpoint of %s removed from the EndpointSlices of %s", backend.Name, service.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete client pod: %v", err)
+				}
+			}
+			if service, ok := ctx.Value(serviceKey).(*corev1.Service); ok && service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+			if deployment, ok := ctx.Value(deploymentKey).(*appsv1.Deployment); ok && deployment != nil {
+				if err := cfg.Client().Resources().Delete(ctx, deployment); err != nil {
+					t.Logf("Failed to delete deployment: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, removalFeature)
+}
+
+// assertServiceResponses runs a client pod that curls a service repeatedly and checks every expected body was seen
+func assertServiceResponses(ctx context.Context, t *testing.T, cfg *envconf.Config, podName, serviceName string, expected []string) {
+	t.Helper()
+
+	checks := make([]string, 0, len(expected))
+	for _, body := range expected {
+		checks = append(checks, "grep -q '^"+body+"$' /tmp/responses")
+	}
+	command := "for i in $(seq 1 40); do curl -s --max-time 5 http://" + serviceName + "; echo; done > /tmp/responses; " +
+		"sort /tmp/responses | uniq -c; " + strings.Join(checks, " && ")
+
+	clientPod := newClientPod(cfg.Namespace(), podName, serviceName)
+	clientPod.Spec.Containers[0].Command = []string{"sh", "-c", command}
+	if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), clientPod, 2*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cfg.Client().Resources().Delete(ctx, clientPod); err != nil {
+			t.Logf("Failed to delete client pod: %v", err)
+		}
+	}()
+
+	if podFailedAsExpected(ctx, cfg.Client().Resources(), clientPod) {
+		t.Fatalf("Client pod did not receive responses from all of %v", expected)
+	}
+}
+
+// newNamedPortDeployment creates a busybox httpd deployment serving its port number on a container port named http
+func newNamedPortDeployment(namespace, name, app string, port int32) *appsv1.Deployment {
+	deployment := newNetworkDeployment(namespace, name)
+	setDeploymentAppLabel(deployment, app)
+
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	container.Name = "httpd"
+	container.Image = "busybox:latest"
+	container.Command = []string{
+		"sh", "-c",
+		fmt.Sprintf("mkdir -p /tmp/www && echo backend-%d > /tmp/www/index.html && httpd -f -p %d -h /tmp/www", port, port),
+	}
+	container.Ports = []corev1.ContainerPort{
+		{
+			Name:          "http",
+			ContainerPort: port,
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+	container.ReadinessProbe = &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")},
+		},
+	}
+
+	return deployment
+}
+
+// waitForDeploymentRolledOut waits until every replica of a deployment runs the latest template and is ready
+func waitForDeploymentRolledOut(ctx context.Context, client *resources.Resources, deployment *appsv1.Deployment) error {
+	return wait.PollUntilContextTimeout(ctx, 5*time.Second, 3*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var current appsv1.Deployment
+		if err := client.Get(ctx, deployment.Name, deployment.Namespace, &current); err != nil {
+			return false, err
+		}
+
+		replicas := *current.Spec.Replicas
+		return current.Status.ObservedGeneration >= current.Generation &&
+			current.Status.UpdatedReplicas == replicas &&
+			current.Status.ReadyReplicas == replicas &&
+			current.Status.Replicas == replicas, nil
+	})
+}
+
+// setDeploymentAppLabel replaces the app label of a deployment, its selector and its pod template
+func setDeploymentAppLabel(deployment *appsv1.Deployment, app string) {
+	deployment.Labels = map[string]string{"app": app}
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}
+	deployment.Spec.Template.Labels = map[string]string{"app": app}
+}
+
+// newHeadlessService creates a headless service exposing the named port http
+func newHeadlessService(namespace, name, app string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": app},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  map[string]string{"app": app},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromInt32(8080),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// newSRVLookupPod creates a pod that resolves an SRV record and checks it carries the expected port
+func newSRVLookupPod(namespace, name, srvName string, port int) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "network-test-client"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "srv-lookup",
+					Image: "alpine:latest",
+					Command: []string{
+						"sh", "-c",
+						"nslookup -type=SRV " + srvName + " | tee /dev/stderr | " +
+							"grep -E '[[:space:]]" + strconv.Itoa(port) + "[[:space:]]'",
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// endpointForPod returns the endpoint targeting a pod in the EndpointSlices of a service, or nil
+// when none does
+func endpointForPod(ctx context.Context, client *resources.Resources, serviceName, podName string) (*discoveryv1.Endpoint, error) {
+	var sliceList discoveryv1.EndpointSliceList
+	if err := client.List(ctx, &sliceList, resources.WithLabelSelector(discoveryv1.LabelServiceName+"="+serviceName)); err != nil {
+		return nil, err
+	}
+
+	for _, slice := range sliceList.Items {
+		for i, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" && endpoint.TargetRef.Name == podName {
+				return &slice.Endpoints[i], nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// conditionTrue reports whether an optional EndpointSlice condition is set to true
+func conditionTrue(condition *bool) bool {
+	return condition != nil && *condition
+}
+
+// conditionFalse reports whether an optional EndpointSlice condition is set to false
+func conditionFalse(condition *bool) bool {
+	return condition != nil && !*condition
+}
+
+// formatEndpointConditions formats EndpointSlice conditions as ready=..., serving=..., terminating=...
+func formatEndpointConditions(conditions discoveryv1.EndpointConditions) string {
+	format := func(condition *bool) string {
+		if condition == nil {
+			return "unset"
+		}
+		return strconv.FormatBool(*condition)
+	}
+
+	return fmt.Sprintf("ready=%s, serving=%s, terminating=%s",
+		format(conditions.Ready), format(conditions.Serving), format(conditions.Terminating))
+}
+
+// waitForEndpointSliceAddresses waits until the EndpointSlices of a service list the expected number of ready addresses
+func waitForEndpointSliceAddresses(ctx context.Context, client *resources.Resources, serviceName string, expected int) ([]discoveryv1.EndpointSlice, error) {
+	var slices []discoveryv1.EndpointSlice
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, 1*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var sliceList discoveryv1.EndpointSliceList
+		if err := client.List(ctx, &sliceList, resources.WithLabelSelector(discoveryv1.LabelServiceName+"="+serviceName)); err != nil {
+			return false, err
+		}
+
+		ready := 0
+		for _, slice := range sliceList.Items {
+			for _, endpoint := range slice.Endpoints {
+				if endpoint.Conditions.Ready != nil && *endpoint.Conditions.Ready {
+					ready += len(endpoint.Addresses)
+				}
+			}
+		}
+
+		slices = sliceList.Items
+		return ready == expected, nil
+	})
+
+	return slices, err
+}
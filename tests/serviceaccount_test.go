@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const projectedTokenAudience = "e2e-tests.example.com"
+
+func TestServiceAccountTokenProjection(t *testing.T) {
+	serviceAccountKey := any("serviceaccount-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	projectionFeature := features.New("rbac/serviceaccount-token-projection").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := newRBACServiceAccount(cfg.Namespace(), "token-projection-test-sa")
+			if err := cfg.Client().Resources().Create(ctx, sa); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceAccountKey, sa)
+
+			return ctx
+		}).
+		Assess("projected token carries the requested audience", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			sa := ctx.Value(serviceAccountKey).(*corev1.ServiceAccount)
+
+			pod := newAudienceTokenPod(cfg.Namespace(), "token-projection-test-pod", sa.Name, projectedTokenAudience)
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			exitCode, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 2*time.Minute)
+			if err != nil {
+				if logs, logErr := getPodLogs(ctx, cfg, pod, ""); logErr == nil {
+					t.Logf("Token projection pod logs:\n%s", logs)
+				}
+				t.Fatalf("Projected token audience check failed: %v", err)
+			}
+			if exitCode != 0 {
+				t.Fatalf("Token projection pod exited with non-zero code: %d", exitCode)
+			}
+			t.Logf("✓ Projected token for %s carries audience %s", sa.Name, projectedTokenAudience)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete token pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete token pod: %v", err)
+				}
+			}
+
+			// Delete ServiceAccount
+			if sa := ctx.Value(serviceAccountKey).(*corev1.ServiceAccount); sa != nil {
+				if err := cfg.Client().Resources().Delete(ctx, sa); err != nil {
+					t.Logf("Failed to delete ServiceAccount: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, projectionFeature)
+}
+
+// newAudienceTokenPod creates a pod that decodes a projected ServiceAccount token and checks its aud claim
+func newAudienceTokenPod(namespace, name, serviceAccountName, audience string) *corev1.Pod {
+	expirationSeconds := int64(3600)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "rbac-test"},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName:           serviceAccountName,
+			AutomountServiceAccountToken: &[]bool{false}[0],
+			RestartPolicy:                corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &[]bool{true}[0],
+				RunAsUser:    &[]int64{65534}[0], // nobody user
+				FSGroup:      &[]int64{65534}[0],
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:  "token",
+					Image: "alpine:latest",
+					Command: []string{
+						"sh", "-c",
+						// JWT segments are unpadded base64url, convert them before decoding
+						"payload=$(cut -d. -f2 /var/run/secrets/tokens/mytoken | tr '_-' '/+') && " +
+							"while [ $(( ${#payload} % 4 )) -ne 0 ]; do payload=\"${payload}=\"; done && " +
+							"claims=$(echo \"$payload\" | base64 -d) && " +
+							"echo \"$claims\" && " +
+							"echo \"$claims\" | grep -q '\"aud\":\\[\"" + audience + "\"\\]'",
+					},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &[]bool{false}[0],
+						RunAsNonRoot:             &[]bool{true}[0],
+						RunAsUser:                &[]int64{65534}[0],
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+						SeccompProfile: &corev1.SeccompProfile{
+							Type: corev1.SeccompProfileTypeRuntimeDefault,
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "token",
+							MountPath: "/var/run/secrets/tokens",
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "token",
+					VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{
+							Sources: []corev1.VolumeProjection{
+								{
+									ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+										Audience:          audience,
+										Path:              "mytoken",
+										ExpirationSeconds: &expirationSeconds,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestSAImagePullSecret(t *testing.T) {
+	secretKey := any("secret-key")
+	serviceAccountKey := any("serviceaccount-key")
+	podKey := any("pod-key")
+
+	trackTest(t)
+
+	pullSecretFeature := features.New("rbac/serviceaccount-image-pull-secret").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			secret := newDockerConfigSecret(cfg.Namespace(), "pull-secret-test", "registry.example.com")
+			if err := cfg.Client().Resources().Create(ctx, secret); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, secretKey, secret)
+
+			sa := newRBACServiceAccount(cfg.Namespace(), "pull-secret-test-sa")
+			sa.ImagePullSecrets = []corev1.LocalObjectReference{{Name: secret.Name}}
+			if err := cfg.Client().Resources().Create(ctx, sa); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceAccountKey, sa)
+
+			return ctx
+		}).
+		Assess("pod inherits the ServiceAccount pull secret", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			secret := ctx.Value(secretKey).(*corev1.Secret)
+			sa := ctx.Value(serviceAccountKey).(*corev1.ServiceAccount)
+
+			pod := newWritableLayerPod(cfg.Namespace(), "pull-secret-test-pod", "true")
+			pod.Spec.ServiceAccountName = sa.Name
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			// The ServiceAccount admission plugin mutates the pod spec at creation
+			var currentPod corev1.Pod
+			if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, ref := range currentPod.Spec.ImagePullSecrets {
+				names = append(names, ref.Name)
+			}
+			if !slices.Contains(names, secret.Name) {
+				t.Fatalf("Pod %s image pull secrets %v do not include %s from ServiceAccount %s",
+					pod.Name, names, secret.Name, sa.Name)
+			}
+			t.Logf("✓ Pod %s inherited image pull secret %s from ServiceAccount %s", pod.Name, secret.Name, sa.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+			if sa, ok := ctx.Value(serviceAccountKey).(*corev1.ServiceAccount); ok && sa != nil {
+				if err := cfg.Client().Resources().Delete(ctx, sa); err != nil {
+					t.Logf("Failed to delete ServiceAccount: %v", err)
+				}
+			}
+			if secret, ok := ctx.Value(secretKey).(*corev1.Secret); ok && secret != nil {
+				if err := cfg.Client().Resources().Delete(ctx, secret); err != nil {
+					t.Logf("Failed to delete Secret: %v", err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, pullSecretFeature)
+}
+
+// newDockerConfigSecret creates an image pull secret holding placeholder credentials for a registry
+func newDockerConfigSecret(namespace, name, registry string) *corev1.Secret {
+	auth := base64.StdEncoding.EncodeToString([]byte("e2e-tests:not-a-password"))
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "rbac-test"},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"` + registry + `":{"auth":"` + auth + `"}}}`),
+		},
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const statefulSetReplicas = 3
+
+func TestStatefulSet(t *testing.T) {
+	statefulSetKey := any("statefulset-key")
+	serviceKey := any("service-key")
+
+	trackTest(t)
+
+	statefulSetFeature := features.New("appsv1/statefulset").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Create headless service governing the StatefulSet
+			service := newHeadlessService(cfg.Namespace(), "statefulset-test", "statefulset-test")
+			if err := cfg.Client().Resources().Create(ctx, service); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, serviceKey, service)
+
+			// Create StatefulSet
+			statefulSet := newStatefulSet(cfg.Namespace(), "statefulset-test", service.Name, statefulSetReplicas)
+			if err := cfg.Client().Resources().Create(ctx, statefulSet); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, statefulSetKey, statefulSet)
+
+			// Wait for all replicas to be ready
+			if err := waitForStatefulSetReady(ctx, cfg.Client().Resources(), statefulSet); err != nil {
+				t.Fatalf("StatefulSet not ready: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("ordinal pods with dedicated volumes", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			statefulSet := ctx.Value(statefulSetKey).(*appsv1.StatefulSet)
+
+			for i := 0; i < statefulSetReplicas; i++ {
+				podName := fmt.Sprintf("%s-%d", statefulSet.Name, i)
+				var pod corev1.Pod
+				if err := cfg.Client().Resources().Get(ctx, podName, cfg.Namespace(), &pod); err != nil {
+					t.Fatalf("Expected pod %s: %v", podName, err)
+				}
+
+				pvcName := fmt.Sprintf("data-%s", podName)
+				var pvc corev1.PersistentVolumeClaim
+				if err := cfg.Client().Resources().Get(ctx, pvcName, cfg.Namespace(), &pvc); err != nil {
+					t.Fatalf("Expected PVC %s: %v", pvcName, err)
+				}
+				if pvc.Status.Phase != corev1.ClaimBound {
+					t.Fatalf("PVC %s not bound: phase is %s", pvcName, pvc.Status.Phase)
+				}
+
+				t.Logf("✓ Pod %s uses PVC %s bound to volume %s", podName, pvcName, pvc.Spec.VolumeName)
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete StatefulSet
+			if statefulSet := ctx.Value(statefulSetKey).(*appsv1.StatefulSet); statefulSet != nil {
+				if err := cfg.Client().Resources().Delete(ctx, statefulSet); err != nil {
+					t.Logf("Failed to delete StatefulSet: %v", err)
+				}
+			}
+
+			// Delete headless service
+			if service := ctx.Value(serviceKey).(*corev1.Service); service != nil {
+				if err := cfg.Client().Resources().Delete(ctx, service); err != nil {
+					t.Logf("Failed to delete service: %v", err)
+				}
+			}
+
+			// Delete PVCs, which the StatefulSet does not garbage-collect
+			var pvcs corev1.PersistentVolumeClaimList
+			if err := cfg.Client().Resources(cfg.Namespace()).List(ctx, &pvcs, resources.WithLabelSelector("app=statefulset-test")); err != nil {
+				t.Logf("Failed to list StatefulSet PVCs: %v", err)
+			}
+			for i := range pvcs.Items {
+				if err := cfg.Client().Resources().Delete(ctx, &pvcs.Items[i]); err != nil {
+					t.Logf("Failed to delete PVC %s: %v", pvcs.Items[i].Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, statefulSetFeature)
+}
+
+// newStatefulSet creates an nginx StatefulSet with a per-replica volume claim
+func newStatefulSet(namespace, name, serviceName string, replicas int32) *appsv1.StatefulSet {
+	labels := map[string]string{"app": "statefulset-test"}
+
+	template := newNetworkDeployment(namespace, name).Spec.Template
+	template.Labels = labels
+	template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
+		{
+			Name:      "data",
+			MountPath: "/data",
+		},
+	}
+
+	claim := newPVC(namespace, "data")
+	claim.Namespace = ""
+	claim.Labels = labels
+
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: serviceName,
+			Replicas:    &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			PodManagementPolicy:  appsv1.OrderedReadyPodManagement,
+			Template:             template,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{*claim},
+		},
+	}
+}
+
+// waitForStatefulSetReady waits for all replicas of a StatefulSet to be ready
+func waitForStatefulSetReady(ctx context.Context, client *resources.Resources, statefulSet *appsv1.StatefulSet) error {
+	return wait.PollUntilContextTimeout(ctx, 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var currentStatefulSet appsv1.StatefulSet
+		if err := client.Get(ctx, statefulSet.Name, statefulSet.Namespace, &currentStatefulSet); err != nil {
+			return false, err
+		}
+
+		return currentStatefulSet.Status.ReadyReplicas == *currentStatefulSet.Spec.Replicas, nil
+	})
+}
@@ -1,57 +1,92 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
 	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 	"sigs.k8s.io/e2e-framework/pkg/features"
 )
 
+// storageTestData is written by the first storage pod and read back by the second one
+const storageTestData = "CSI storage test data"
+
+var (
+	storageWriteCommand = "echo '" + storageTestData + "' > /data/test-file.txt && " +
+		"cat /data/test-file.txt && " +
+		"echo 'Storage test completed successfully'"
+	storageReadCommand = "cat /data/test-file.txt && " +
+		"test \"$(cat /data/test-file.txt)\" = '" + storageTestData + "'"
+)
+
+// storageThroughputCommand writes 256MiB to the volume, flushing it to storage before dd reports
+const storageThroughputCommand = "dd if=/dev/zero of=/data/throughput bs=1M count=256 conv=fsync 2>&1"
+
+const (
+	// perfBaselineConfigMap stores the baseline write throughput, under perfBaselineThroughputKey in MB/s
+	perfBaselineConfigMap     = "perf-baseline"
+	perfBaselineThroughputKey = "storage-throughput-mbps"
+	// storageRegressionTolerance is the largest accepted drop of throughput below the baseline
+	storageRegressionTolerance = 0.2
+)
+
+// staticStorageClassName has no backing StorageClass, so no provisioner acts on claims requesting it
+const staticStorageClassName = "e2e-static"
+
 func TestCSIStorage(t *testing.T) {
-	start := time.Now()
+	stages := newStageTracker(t)
 	pvcKey := any("pvc-key")
 	podKey := any("pod-key")
 
-	t.Cleanup(func() {
-		metricsCollector.RecordTestExecution(testContext, t, time.Since(start))
-	})
+	trackTestWithAttributes(t, stages.attributes)
 
 	storageFeature := features.New("csi/storage").
-		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		Setup(stages.setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
 			// Create PVC
 			pvc := newPVC(cfg.Namespace(), "test-storage-pvc")
+			createdAt := time.Now()
 			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
 				t.Fatal(err)
 			}
 			ctx = context.WithValue(ctx, pvcKey, pvc)
 
 			// Wait for PVC to be bound
-			if err := waitForPVCBound(ctx, cfg.Client().Resources(), pvc); err != nil {
+			if err := waitForPVCBound(ctx, cfg.Client().Resources(), pvc, createdAt); err != nil {
 				t.Fatalf("PVC not bound: %v", err)
 			}
 
 			// Create Pod
-			pod := newStoragePod(cfg.Namespace(), "test-storage-pod", "test-storage-pvc")
-			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
-				t.Fatal(err)
-			}
+			pod := newStoragePod(cfg.Namespace(), "test-storage-pod", "test-storage-pvc", storageWriteCommand)
 			ctx = context.WithValue(ctx, podKey, pod)
 
-			// Wait for Pod to complete
-			if err := waitForPodCompletion(ctx, cfg.Client().Resources(), pod); err != nil {
+			// Run Pod to completion, the assessment reports a failed Pod
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil && !errors.Is(err, errPodFailed) {
 				t.Fatalf("Pod did not complete: %v", err)
 			}
 
 			return ctx
-		}).
-		Assess("storage functionality", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		})).
+		Assess("storage functionality", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
 			pod := ctx.Value(podKey).(*corev1.Pod)
 
 			// Verify pod completed successfully (exit code 0)
@@ -91,10 +126,172 @@ func TestCSIStorage(t *testing.T) {
 			t.Logf("PVC %s is bound to volume %s", currentPvc.Name, currentPvc.Spec.VolumeName)
 
 			return ctx
-		}).
-		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+		})).
+		Assess("data persists across pods", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+
+			// Delete the writer and wait until it is gone, a ReadWriteOnce volume cannot attach twice
+			if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+				t.Fatalf("Failed to delete writer pod: %v", err)
+			}
+			if err := waitForDeleted(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Writer pod was not deleted: %v", err)
+			}
+
+			readerPod := newStoragePod(cfg.Namespace(), "test-storage-reader-pod", pvc.Name, storageReadCommand)
+			ctx = context.WithValue(ctx, podKey, readerPod)
+
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), readerPod, 5*time.Minute); err != nil {
+				t.Fatalf("Reader pod did not read back the data written by the first pod: %v", err)
+			}
+			t.Logf("✓ Pod %s read back %q from PVC %s", readerPod.Name, storageTestData, pvc.Name)
+
+			return ctx
+		})).
+		Teardown(stages.teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod before the PVC it mounts
+			if pvc, ok := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim); ok && pvc != nil {
+				toDelete := []dependentResource{{object: pvc}}
+				if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+					toDelete = append(toDelete, dependentResource{object: pod, dependsOn: []k8s.Object{pvc}})
+				}
+				if _, err := deleteInOrder(ctx, cfg.Client().Resources(), toDelete...); err != nil {
+					t.Logf("Failed to delete storage resources: %v", err)
+				}
+			}
+
+			return ctx
+		})).Feature()
+
+	testenv.Test(t, storageFeature)
+}
+
+func TestStaticPVBinding(t *testing.T) {
+	stages := newStageTracker(t)
+	pvKey := any("pv-key")
+	pvcKey := any("pvc-key")
+	podKey := any("pod-key")
+	createdAtKey := any("created-at-key")
+
+	trackTestWithAttributes(t, stages.attributes)
+
+	// Creating hostPath PersistentVolumes gives access to node paths, which needs the opt-in grant
+	if os.Getenv("STATIC_PV_TEST") != "true" {
+		skipTest(t, skipReasonOptIn, "STATIC_PV_TEST not set to true, skipping static PV binding test")
+	}
+
+	bindingFeature := features.New("storage/static-binding").
+		Setup(stages.setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// PersistentVolumes are cluster-scoped, derive the name from the test namespace
+			pv := newStaticPV(cfg.Namespace()+"-static-pv", "1Gi")
+			if err := cfg.Client().Resources().Create(ctx, pv); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pvKey, pv)
+
+			pvc := newPVC(cfg.Namespace(), "static-binding-pvc")
+			pvc.Spec.StorageClassName = &[]string{staticStorageClassName}[0]
+			pvc.Spec.VolumeName = pv.Name
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pvcKey, pvc)
+			ctx = context.WithValue(ctx, createdAtKey, createdAt)
+
+			return ctx
+		})).
+		Assess("PVC binds to the referenced PV", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pv := ctx.Value(pvKey).(*corev1.PersistentVolume)
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+			createdAt := ctx.Value(createdAtKey).(time.Time)
+
+			if err := waitForPVCBound(ctx, cfg.Client().Resources(), pvc, createdAt); err != nil {
+				t.Fatalf("PVC not bound: %v", err)
+			}
+
+			var currentPvc corev1.PersistentVolumeClaim
+			if err := cfg.Client().Resources().Get(ctx, pvc.Name, pvc.Namespace, &currentPvc); err != nil {
+				t.Fatal(err)
+			}
+			if currentPvc.Spec.VolumeName != pv.Name {
+				t.Fatalf("PVC bound to volume %s, expected static PV %s", currentPvc.Spec.VolumeName, pv.Name)
+			}
+
+			var currentPv corev1.PersistentVolume
+			if err := cfg.Client().Resources().Get(ctx, pv.Name, "", &currentPv); err != nil {
+				t.Fatal(err)
+			}
+			if ref := currentPv.Spec.ClaimRef; ref == nil || ref.Name != pvc.Name || ref.Namespace != pvc.Namespace {
+				t.Fatalf("PV %s claimRef %v does not point to PVC %s/%s", pv.Name, currentPv.Spec.ClaimRef, pvc.Namespace, pvc.Name)
+			}
+			t.Logf("✓ PVC %s bound to static PV %s", pvc.Name, pv.Name)
+
+			return ctx
+		})).
+		Assess("pod uses the statically bound volume", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+
+			// The kubelet creates the hostPath directory backing the PV owned by root, so the pod
+			// writes as root, without any capability
+			pod := newStoragePod(cfg.Namespace(), "static-binding-pod", pvc.Name,
+				"echo static-pv > /data/static-pv && grep -qx static-pv /data/static-pv")
+			pod.Spec.SecurityContext.RunAsNonRoot = &[]bool{false}[0]
+			pod.Spec.SecurityContext.RunAsUser = &[]int64{0}[0]
+			pod.Spec.Containers[0].SecurityContext.RunAsNonRoot = &[]bool{false}[0]
+			pod.Spec.Containers[0].SecurityContext.RunAsUser = &[]int64{0}[0]
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil {
+				t.Fatalf("Pod did not write and read back a file through the static PV: %v", err)
+			}
+			t.Logf("✓ Pod %s wrote and read back a file through static PV via PVC %s", pod.Name, pvc.Name)
+
+			return ctx
+		})).
+		Assess("PVC does not bind to a mismatched PV", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pv := newStaticPV(cfg.Namespace()+"-static-pv-small", "1Gi")
+			if err := cfg.Client().Resources().Create(ctx, pv); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := cfg.Client().Resources().Delete(ctx, pv); err != nil {
+					t.Logf("Failed to delete PV %s: %v", pv.Name, err)
+				}
+			}()
+
+			// Request more than the PV offers
+			pvc := newPVC(cfg.Namespace(), "static-binding-mismatch-pvc")
+			pvc.Spec.StorageClassName = &[]string{staticStorageClassName}[0]
+			pvc.Spec.VolumeName = pv.Name
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("5Gi")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+					t.Logf("Failed to delete PVC %s: %v", pvc.Name, err)
+				}
+			}()
+
+			waitCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+			err := waitForPVCBound(waitCtx, cfg.Client().Resources(), pvc, createdAt)
+			if err == nil {
+				t.Fatalf("PVC %s requesting 5Gi should not bind to 1Gi PV %s", pvc.Name, pv.Name)
+			}
+			if !wait.Interrupted(err) {
+				t.Fatal(err)
+			}
+			t.Logf("✓ PVC %s stayed unbound against undersized PV %s", pvc.Name, pv.Name)
+
+			return ctx
+		})).
+		Teardown(stages.teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
 			// Delete Pod
-			if pod := ctx.Value(podKey).(*corev1.Pod); pod != nil {
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
 				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
 					t.Logf("Failed to delete Pod: %v", err)
 				}
@@ -107,10 +304,405 @@ func TestCSIStorage(t *testing.T) {
 				}
 			}
 
+			// Delete PV, which is retained once released
+			if pv := ctx.Value(pvKey).(*corev1.PersistentVolume); pv != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pv); err != nil {
+					t.Logf("Failed to delete PV: %v", err)
+				}
+			}
+
+			return ctx
+		})).Feature()
+
+	testenv.Test(t, bindingFeature)
+}
+
+func TestStorageClassExpansion(t *testing.T) {
+	stages := newStageTracker(t)
+	pvcKey := any("pvc-key")
+	podKey := any("pod-key")
+	expandedSize := resource.MustParse("2Gi")
+
+	trackTestWithAttributes(t, stages.attributes)
+
+	expansionFeature := features.New("storage/volume-expansion").
+		Setup(stages.setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var classes storagev1.StorageClassList
+			if err := cfg.Client().Resources().List(ctx, &classes); err != nil {
+				t.Fatal(err)
+			}
+			class := expandableStorageClass(classes.Items)
+			if class == nil {
+				skipTest(t, skipReasonMissingPrerequisite, "No StorageClass allows volume expansion, skipping expansion test")
+			}
+			t.Logf("Using expandable StorageClass %s", class.Name)
+
+			pvc := newPVC(cfg.Namespace(), "expansion-test-pvc")
+			pvc.Spec.StorageClassName = &class.Name
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pvcKey, pvc)
+
+			// Keep the pod running so that the volume is expanded online
+			pod := newStoragePod(cfg.Namespace(), "expansion-test-pod", pvc.Name, "sleep 3600")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			return ctx
+		})).
+		Assess("PVC capacity grows to the new request", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+
+			patch := k8s.Patch{
+				PatchType: types.MergePatchType,
+				Data:      []byte(`{"spec":{"resources":{"requests":{"storage":"` + expandedSize.String() + `"}}}}`),
+			}
+			if err := cfg.Client().Resources().Patch(ctx, pvc, patch); err != nil {
+				t.Fatalf("Failed to expand PVC %s: %v", pvc.Name, err)
+			}
+
+			if err := waitForPVCResize(ctx, cfg.Client().Resources(), pvc, expandedSize); err != nil {
+				t.Fatalf("PVC %s was not resized to %s: %v", pvc.Name, expandedSize.String(), err)
+			}
+			t.Logf("✓ PVC %s capacity reached %s", pvc.Name, expandedSize.String())
+
+			return ctx
+		})).
+		Assess("pod sees the larger filesystem", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			// The filesystem overhead keeps the size below the request, but it must exceed the original 1Gi
+			originalSize := resource.MustParse("1Gi")
+			minKilobytes := originalSize.Value() / 1024
+			var sizeKilobytes int64
+			err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+				var stdout, stderr bytes.Buffer
+				command := []string{"df", "-k", "/data"}
+				if err := cfg.Client().Resources().ExecInPod(ctx, pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, command, &stdout, &stderr); err != nil {
+					return false, fmt.Errorf("df failed: %w: %s", err, stderr.String())
+				}
+
+				size, err := parseDfSizeKilobytes(stdout.String())
+				if err != nil {
+					return false, err
+				}
+				sizeKilobytes = size
+				return sizeKilobytes > minKilobytes, nil
+			})
+			if err != nil {
+				t.Fatalf("Filesystem in pod %s was not expanded (last size %dKiB): %v", pod.Name, sizeKilobytes, err)
+			}
+			t.Logf("✓ Pod %s sees a %dKiB filesystem on /data", pod.Name, sizeKilobytes)
+
+			return ctx
+		})).
+		Teardown(stages.teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			// Delete PVC
+			if pvc, ok := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim); ok && pvc != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+					t.Logf("Failed to delete PVC: %v", err)
+				}
+			}
+
+			return ctx
+		})).Feature()
+
+	testenv.Test(t, expansionFeature)
+}
+
+func TestReadWriteManyVolume(t *testing.T) {
+	stages := newStageTracker(t)
+	pvcKey := any("pvc-key")
+	podsKey := any("pods-key")
+
+	trackTestWithAttributes(t, stages.attributes)
+
+	rwxFeature := features.New("storage/read-write-many").
+		Setup(stages.setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			var classes storagev1.StorageClassList
+			if err := cfg.Client().Resources().List(ctx, &classes); err != nil {
+				t.Fatal(err)
+			}
+			className := readWriteManyStorageClass(classes.Items, os.Getenv("RWX_STORAGE_CLASS"))
+			if className == "" {
+				skipTest(t, skipReasonMissingPrerequisite, "No ReadWriteMany capable StorageClass found, set RWX_STORAGE_CLASS to select one")
+			}
+			t.Logf("Using ReadWriteMany StorageClass %s", className)
+
+			pvc := newRWManyPVC(cfg.Namespace(), "rwx-test-pvc", className)
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pvcKey, pvc)
+
+			return ctx
+		})).
+		Assess("two pods share the volume concurrently", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+
+			// Each pod writes its own file, then waits for the other pod's file, so both must run at once
+			pods := []*corev1.Pod{
+				newStoragePod(cfg.Namespace(), "rwx-test-pod-a", pvc.Name, rwxExchangeCommand("a", "b")),
+				newStoragePod(cfg.Namespace(), "rwx-test-pod-b", pvc.Name, rwxExchangeCommand("b", "a")),
+			}
+			ctx = context.WithValue(ctx, podsKey, pods)
+
+			errs := make([]error, len(pods))
+			var wg sync.WaitGroup
+			for i, pod := range pods {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _, errs[i] = runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute)
+				}()
+			}
+			wg.Wait()
+
+			for i, pod := range pods {
+				if errs[i] != nil {
+					t.Fatalf("Pod %s did not succeed: %v", pod.Name, errs[i])
+				}
+				assertPodSucceeded(ctx, t, cfg, pod)
+			}
+			t.Logf("✓ Pods %s and %s exchanged data through ReadWriteMany PVC %s", pods[0].Name, pods[1].Name, pvc.Name)
+
+			return ctx
+		})).
+		Teardown(stages.teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pods
+			if pods, ok := ctx.Value(podsKey).([]*corev1.Pod); ok {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete Pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			// Delete PVC
+			if pvc, ok := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim); ok && pvc != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+					t.Logf("Failed to delete PVC: %v", err)
+				}
+			}
+
+			return ctx
+		})).Feature()
+
+	testenv.Test(t, rwxFeature)
+}
+
+func TestStorageRegression(t *testing.T) {
+	stages := newStageTracker(t)
+	pvcKey := any("pvc-key")
+	podKey := any("pod-key")
+	throughputKey := any("throughput-key")
+	updateBaseline := os.Getenv("UPDATE_BASELINE") == "true"
+	baselineNamespace := os.Getenv("PERF_BASELINE_NAMESPACE")
+
+	trackTestWithAttributes(t, stages.attributes)
+
+	// The baseline must outlive the test namespace, which is deleted at the end of every run
+	if baselineNamespace == "" {
+		skipTest(t, skipReasonNotConfigured, "PERF_BASELINE_NAMESPACE not set, skipping storage regression test")
+	}
+
+	regressionFeature := features.New("csi/storage-regression").
+		Setup(stages.setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pvc := newPVC(cfg.Namespace(), "throughput-pvc")
+			createdAt := time.Now()
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pvcKey, pvc)
+
+			if err := waitForPVCBound(ctx, cfg.Client().Resources(), pvc, createdAt); err != nil {
+				t.Fatalf("PVC not bound: %v", err)
+			}
+
+			return ctx
+		})).
+		Assess("measure write throughput", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+
+			pod := newStoragePod(cfg.Namespace(), "throughput-pod", pvc.Name, storageThroughputCommand)
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil {
+				t.Fatalf("Throughput pod did not complete: %v", err)
+			}
+
+			logs, err := getPodLogs(ctx, cfg, pod, "")
+			if err != nil {
+				t.Fatalf("Failed to get throughput pod logs: %v", err)
+			}
+			throughput, err := parseDdThroughput(logs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("✓ Measured write throughput of %.1f MB/s on PVC %s", throughput, pvc.Name)
+
+			return context.WithValue(ctx, throughputKey, throughput)
+		})).
+		Assess("throughput has not regressed from the baseline", stages.assess(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			throughput := ctx.Value(throughputKey).(float64)
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+
+			var baseline corev1.ConfigMap
+			err := cfg.Client().Resources().Get(ctx, perfBaselineConfigMap, baselineNamespace, &baseline)
+			if apierrors.IsNotFound(err) {
+				baseline = corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      perfBaselineConfigMap,
+						Namespace: baselineNamespace,
+					},
+					Data: map[string]string{perfBaselineThroughputKey: formatThroughput(throughput)},
+				}
+				if err := cfg.Client().Resources().Create(ctx, &baseline); err != nil {
+					t.Fatalf("Failed to create baseline ConfigMap: %v", err)
+				}
+				t.Logf("✓ No baseline found, stored %.1f MB/s in ConfigMap %s/%s", throughput, baselineNamespace, perfBaselineConfigMap)
+				return ctx
+			}
+			if err != nil {
+				t.Fatalf("Failed to get baseline ConfigMap: %v", err)
+			}
+
+			baselineThroughput, err := strconv.ParseFloat(baseline.Data[perfBaselineThroughputKey], 64)
+			if err != nil || baselineThroughput <= 0 {
+				t.Fatalf("Invalid baseline %s=%q in ConfigMap %s/%s", perfBaselineThroughputKey,
+					baseline.Data[perfBaselineThroughputKey], baselineNamespace, perfBaselineConfigMap)
+			}
+
+			ratio := throughput / baselineThroughput
+			storageClass := ""
+			if pvc.Spec.StorageClassName != nil {
+				storageClass = *pvc.Spec.StorageClassName
+			}
+			metricsCollector.RecordStorageThroughputRatio(ctx, storageClass, ratio)
+			t.Logf("Write throughput %.1f MB/s is %.0f%% of the baseline %.1f MB/s", throughput, ratio*100, baselineThroughput)
+
+			if updateBaseline {
+				if baseline.Data == nil {
+					baseline.Data = map[string]string{}
+				}
+				baseline.Data[perfBaselineThroughputKey] = formatThroughput(throughput)
+				if err := cfg.Client().Resources().Update(ctx, &baseline); err != nil {
+					t.Fatalf("Failed to update baseline ConfigMap: %v", err)
+				}
+				t.Logf("✓ UPDATE_BASELINE set, stored %.1f MB/s as the new baseline", throughput)
+				return ctx
+			}
+
+			if ratio < 1-storageRegressionTolerance {
+				t.Fatalf("Write throughput regressed by more than %.0f%%: %.1f MB/s against a baseline of %.1f MB/s",
+					storageRegressionTolerance*100, throughput, baselineThroughput)
+			}
+			t.Logf("✓ Write throughput is within %.0f%% of the baseline", storageRegressionTolerance*100)
+
+			return ctx
+		})).
+		Teardown(stages.teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete Pod
+			if pod, ok := ctx.Value(podKey).(*corev1.Pod); ok && pod != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+					t.Logf("Failed to delete Pod: %v", err)
+				}
+			}
+
+			// Delete PVC
+			if pvc, ok := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim); ok && pvc != nil {
+				if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+					t.Logf("Failed to delete PVC: %v", err)
+				}
+			}
+
+			return ctx
+		})).Feature()
+
+	testenv.Test(t, regressionFeature)
+}
+
+func TestTeardownOrdering(t *testing.T) {
+	pvcKey := any("pvc-key")
+	podKey := any("pod-key")
+	deletedKey := any("deleted-key")
+
+	trackTest(t)
+
+	orderingFeature := features.New("storage/teardown-ordering").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// The PVC uses the default StorageClass, which belongs to the cluster and is not deleted
+			pvc := newPVC(cfg.Namespace(), "teardown-order-pvc")
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pvcKey, pvc)
+
+			pod := newStoragePod(cfg.Namespace(), "teardown-order-pod", pvc.Name, "sleep 3600")
+			if err := cfg.Client().Resources().Create(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, podKey, pod)
+
+			if err := waitForPodRunning(ctx, cfg.Client().Resources(), pod); err != nil {
+				t.Fatalf("Pod not running: %v", err)
+			}
+
+			return ctx
+		}).
+		Assess("pod is deleted before the PVC it mounts", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+			pod := ctx.Value(podKey).(*corev1.Pod)
+
+			// Declare the PVC first, the helper must still delete the pod using it first
+			deleted, err := deleteInOrder(ctx, cfg.Client().Resources(),
+				dependentResource{object: pvc},
+				dependentResource{object: pod, dependsOn: []k8s.Object{pvc}},
+			)
+			ctx = context.WithValue(ctx, deletedKey, deleted)
+			if err != nil {
+				t.Fatalf("Ordered teardown failed: %v", err)
+			}
+
+			if len(deleted) != 2 || deleted[0] != k8s.Object(pod) || deleted[1] != k8s.Object(pvc) {
+				t.Fatalf("Expected pod %s to be deleted before PVC %s", pod.Name, pvc.Name)
+			}
+			// A PVC still mounted by a pod is held by its kubernetes.io/pvc-protection finalizer
+			t.Logf("✓ Pod %s was deleted before PVC %s, which was not held by the pvc-protection finalizer", pod.Name, pvc.Name)
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete what the assessment left behind
+			deleted, _ := ctx.Value(deletedKey).([]k8s.Object)
+			for _, key := range []any{podKey, pvcKey} {
+				obj, ok := ctx.Value(key).(k8s.Object)
+				if !ok || slices.Contains(deleted, obj) {
+					continue
+				}
+				if err := cfg.Client().Resources().Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+					t.Logf("Failed to delete %s: %v", obj.GetName(), err)
+				}
+			}
+
 			return ctx
 		}).Feature()
 
-	testenv.Test(t, storageFeature)
+	testenv.Test(t, orderingFeature)
 }
 
 // newPVC creates a new PersistentVolumeClaim
@@ -134,8 +726,43 @@ func newPVC(namespace, name string) *corev1.PersistentVolumeClaim {
 	}
 }
 
-// newStoragePod creates a Pod that writes data to mounted storage
-func newStoragePod(namespace, name, pvcName string) *corev1.Pod {
+// newRWManyPVC creates a ReadWriteMany PersistentVolumeClaim in the given StorageClass
+func newRWManyPVC(namespace, name, storageClassName string) *corev1.PersistentVolumeClaim {
+	pvc := newPVC(namespace, name)
+	pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+	pvc.Spec.StorageClassName = &storageClassName
+	return pvc
+}
+
+// newStaticPV creates a hostPath PersistentVolume in the static storage class, for manual binding
+func newStaticPV(name, capacity string) *corev1.PersistentVolume {
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"app": "test-storage"},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse(capacity),
+			},
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+			StorageClassName:              staticStorageClassName,
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: "/tmp/e2e-tests/" + name,
+					Type: &hostPathType,
+				},
+			},
+		},
+	}
+}
+
+// newStoragePod creates a Pod that runs a shell command against storage mounted on /data
+func newStoragePod(namespace, name, pvcName, command string) *corev1.Pod {
 	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
@@ -154,14 +781,9 @@ func newStoragePod(namespace, name, pvcName string) *corev1.Pod {
 			},
 			Containers: []corev1.Container{
 				{
-					Name:  "storage-test",
-					Image: "alpine:latest",
-					Command: []string{
-						"sh", "-c",
-						"echo 'CSI storage test data' > /data/test-file.txt && " +
-							"cat /data/test-file.txt && " +
-							"echo 'Storage test completed successfully'",
-					},
+					Name:    "storage-test",
+					Image:   "alpine:latest",
+					Command: []string{"sh", "-c", command},
 					SecurityContext: &corev1.SecurityContext{
 						AllowPrivilegeEscalation: &[]bool{false}[0],
 						RunAsNonRoot:             &[]bool{true}[0],
@@ -195,9 +817,9 @@ func newStoragePod(namespace, name, pvcName string) *corev1.Pod {
 	}
 }
 
-// waitForPVCBound waits for a PVC to be bound
-func waitForPVCBound(ctx context.Context, client *resources.Resources, pvc *corev1.PersistentVolumeClaim) error {
-	return wait.PollUntilContextTimeout(ctx, 5*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
+// waitForPVCBound waits for a PVC created at createdAt to be bound
+func waitForPVCBound(ctx context.Context, client *resources.Resources, pvc *corev1.PersistentVolumeClaim, createdAt time.Time) error {
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, 2*time.Minute, true, func(ctx context.Context) (bool, error) {
 		var currentPvc corev1.PersistentVolumeClaim
 		if err := client.Get(ctx, pvc.Name, pvc.Namespace, &currentPvc); err != nil {
 			return false, err
@@ -205,24 +827,186 @@ func waitForPVCBound(ctx context.Context, client *resources.Resources, pvc *core
 
 		return currentPvc.Status.Phase == corev1.ClaimBound, nil
 	})
+	if err == nil {
+		recordResourceCreation(ctx, "PersistentVolumeClaim", pvc.Name, createdAt)
+	}
+
+	return err
 }
 
-// waitForPodCompletion waits for a Pod to complete successfully
-func waitForPodCompletion(ctx context.Context, client *resources.Resources, pod *corev1.Pod) error {
-	return wait.PollUntilContextTimeout(ctx, 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
-		var currentPod corev1.Pod
-		if err := client.Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
-			return false, err
+// assertPodSucceeded fails the test unless the Pod succeeded with a zero exit code
+func assertPodSucceeded(ctx context.Context, t *testing.T, cfg *envconf.Config, pod *corev1.Pod) {
+	t.Helper()
+
+	var currentPod corev1.Pod
+	if err := cfg.Client().Resources().Get(ctx, pod.Name, pod.Namespace, &currentPod); err != nil {
+		t.Fatal(err)
+	}
+
+	if currentPod.Status.Phase != corev1.PodSucceeded {
+		t.Fatalf("Pod %s did not succeed: phase is %s", currentPod.Name, currentPod.Status.Phase)
+	}
+
+	// Check container exit code
+	if len(currentPod.Status.ContainerStatuses) > 0 {
+		containerStatus := currentPod.Status.ContainerStatuses[0]
+		if containerStatus.State.Terminated == nil {
+			t.Fatalf("Pod %s container not terminated", currentPod.Name)
+		}
+		if containerStatus.State.Terminated.ExitCode != 0 {
+			t.Fatalf("Pod %s container exited with non-zero code: %d", currentPod.Name, containerStatus.State.Terminated.ExitCode)
+		}
+	}
+}
+
+// expandableStorageClass returns the default StorageClass if it allows volume expansion,
+// otherwise the first StorageClass that does
+func expandableStorageClass(classes []storagev1.StorageClass) *storagev1.StorageClass {
+	var expandable *storagev1.StorageClass
+	for i := range classes {
+		class := &classes[i]
+		if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+			continue
 		}
+		if class.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
+			return class
+		}
+		if expandable == nil {
+			expandable = class
+		}
+	}
+
+	return expandable
+}
 
-		switch currentPod.Status.Phase {
-		case corev1.PodSucceeded:
-			return true, nil
-		case corev1.PodFailed:
-			return false, nil
-		default:
-			return false, nil
+// waitForPVCResize waits for a PVC to report at least the given storage capacity. Resizing goes
+// through the external resizer and the kubelet, which can take several minutes on cloud disks.
+func waitForPVCResize(ctx context.Context, client *resources.Resources, pvc *corev1.PersistentVolumeClaim, size resource.Quantity) error {
+	return wait.PollUntilContextTimeout(ctx, 30*time.Second, 10*time.Minute, true, func(ctx context.Context) (bool, error) {
+		var currentPvc corev1.PersistentVolumeClaim
+		if err := client.Get(ctx, pvc.Name, pvc.Namespace, &currentPvc); err != nil {
+			return false, err
 		}
+
+		capacity, ok := currentPvc.Status.Capacity[corev1.ResourceStorage]
+		return ok && capacity.Cmp(size) >= 0, nil
 	})
 }
 
+// parseDfSizeKilobytes returns the total size column of the last line of `df -k` output
+func parseDfSizeKilobytes(output string) (int64, error) {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected df output: %q", output)
+	}
+
+	return strconv.ParseInt(fields[1], 10, 64)
+}
+
+// rwxProvisioners are substrings of provisioner names of drivers known to support ReadWriteMany
+var rwxProvisioners = []string{"nfs", "cephfs", "efs", "file", "glusterfs", "longhorn"}
+
+// readWriteManyStorageClass returns the configured StorageClass if it exists, otherwise the first
+// StorageClass whose provisioner is known to support ReadWriteMany
+func readWriteManyStorageClass(classes []storagev1.StorageClass, configured string) string {
+	for _, class := range classes {
+		if configured != "" {
+			if class.Name == configured {
+				return class.Name
+			}
+			continue
+		}
+
+		for _, provisioner := range rwxProvisioners {
+			if strings.Contains(class.Provisioner, provisioner) {
+				return class.Name
+			}
+		}
+	}
+
+	return ""
+}
+
+// rwxExchangeCommand writes a file named after self and waits up to 2 minutes for the file named after peer
+func rwxExchangeCommand(self, peer string) string {
+	return fmt.Sprintf("echo 'written by %[1]s' > /data/%[1]s.txt && "+
+		"for i in $(seq 60); do test -f /data/%[2]s.txt && break; sleep 2; done && "+
+		"cat /data/%[2]s.txt && "+
+		"test \"$(cat /data/%[2]s.txt)\" = 'written by %[2]s'", self, peer)
+}
+
+// ddThroughputPattern matches the byte count and elapsed seconds reported by busybox and GNU dd
+var ddThroughputPattern = regexp.MustCompile(`(\d+) bytes .*copied, ([0-9.]+) s`)
+
+// parseDdThroughput returns the throughput in MB/s reported in dd output
+func parseDdThroughput(output string) (float64, error) {
+	match := ddThroughputPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("no throughput in dd output: %q", output)
+	}
+
+	written, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0, err
+	}
+	if seconds <= 0 {
+		return 0, fmt.Errorf("invalid dd elapsed time in output: %q", output)
+	}
+
+	return written / seconds / 1e6, nil
+}
+
+// formatThroughput formats a throughput in MB/s for storage in the baseline ConfigMap
+func formatThroughput(throughput float64) string {
+	return strconv.FormatFloat(throughput, 'f', 2, 64)
+}
+
+func TestParseDdThroughput(t *testing.T) {
+	unitTest(t)
+
+	tests := []struct {
+		name     string
+		output   string
+		expected float64
+		wantErr  bool
+	}{
+		{
+			name:     "busybox",
+			output:   "256+0 records in\n256+0 records out\n268435456 bytes (256.0MB) copied, 2.000000 seconds, 128.0MB/s\n",
+			expected: 134.217728,
+		},
+		{
+			name:     "gnu",
+			output:   "268435456 bytes (268 MB, 256 MiB) copied, 1 s, 268 MB/s\n",
+			expected: 268.435456,
+		},
+		{
+			name:    "no summary",
+			output:  "dd: error writing '/data/throughput': No space left on device\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throughput, err := parseDdThroughput(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %f", throughput)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(throughput-tt.expected) > 1e-6 {
+				t.Errorf("expected %f MB/s, got %f MB/s", tt.expected, throughput)
+			}
+		})
+	}
+}
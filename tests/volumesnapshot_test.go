@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	snapshotGroupVersion      = "snapshot.storage.k8s.io/v1"
+	groupSnapshotGroupVersion = "groupsnapshot.storage.k8s.io/v1alpha1"
+	snapshotTestData          = "volume snapshot test data"
+)
+
+func TestVolumeSnapshot(t *testing.T) {
+	pvcKey := any("pvc-key")
+	snapshotKey := any("snapshot-key")
+	restoredKey := any("restored-key")
+	podsKey := any("pods-key")
+
+	trackTest(t)
+
+	snapshotFeature := features.New("storage/volume-snapshot").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			if _, err := listVolumeSnapshots(ctx, cfg, cfg.Namespace()); err != nil {
+				if meta.IsNoMatchError(err) {
+					skipTest(t, skipReasonAPIUnavailable, "%s VolumeSnapshot CRD not installed, skipping snapshot test", snapshotGroupVersion)
+				}
+				t.Fatal(err)
+			}
+
+			pvc := newPVC(cfg.Namespace(), "snapshot-test-pvc")
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, pvcKey, pvc)
+
+			pod := newStoragePod(cfg.Namespace(), "snapshot-test-writer", pvc.Name,
+				fmt.Sprintf("echo '%s' > /data/test-file.txt", snapshotTestData))
+			ctx = context.WithValue(ctx, podsKey, []*corev1.Pod{pod})
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil {
+				t.Fatalf("Failed to write data to PVC %s: %v", pvc.Name, err)
+			}
+
+			return ctx
+		}).
+		Assess("snapshot becomes ready", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			pvc := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim)
+
+			snapshot := newVolumeSnapshot(cfg.Namespace(), "snapshot-test", pvc.Name, os.Getenv("VOLUME_SNAPSHOT_CLASS"))
+			if err := cfg.Client().Resources().Create(ctx, snapshot); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, snapshotKey, snapshot)
+
+			if err := waitForSnapshotReady(ctx, cfg.Client().Resources(), snapshot); err != nil {
+				t.Fatalf("VolumeSnapshot not ready: %v", err)
+			}
+			t.Logf("✓ VolumeSnapshot %s of PVC %s is ready to use", snapshot.GetName(), pvc.Name)
+
+			return ctx
+		}).
+		Assess("restored volume holds the written data", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			snapshot := ctx.Value(snapshotKey).(*unstructured.Unstructured)
+
+			pvc := newPVC(cfg.Namespace(), "snapshot-test-restored")
+			pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+				APIGroup: &[]string{"snapshot.storage.k8s.io"}[0],
+				Kind:     "VolumeSnapshot",
+				Name:     snapshot.GetName(),
+			}
+			if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, restoredKey, pvc)
+
+			pod := newStoragePod(cfg.Namespace(), "snapshot-test-reader", pvc.Name,
+				fmt.Sprintf("cat /data/test-file.txt && test \"$(cat /data/test-file.txt)\" = '%s'", snapshotTestData))
+			pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+			ctx = context.WithValue(ctx, podsKey, append(pods, pod))
+			if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil {
+				t.Fatalf("Restored PVC %s does not hold the snapshot data: %v", pvc.Name, err)
+			}
+			t.Logf("✓ PVC %s restored from snapshot %s holds the written data", pvc.Name, snapshot.GetName())
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete pods
+			if pods, ok := ctx.Value(podsKey).([]*corev1.Pod); ok {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			// Delete the restored PVC before the snapshot it was restored from
+			if pvc, ok := ctx.Value(restoredKey).(*corev1.PersistentVolumeClaim); ok {
+				if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+					t.Logf("Failed to delete PVC %s: %v", pvc.Name, err)
+				}
+			}
+
+			if snapshot, ok := ctx.Value(snapshotKey).(*unstructured.Unstructured); ok {
+				if err := cfg.Client().Resources().Delete(ctx, snapshot); err != nil {
+					t.Logf("Failed to delete VolumeSnapshot: %v", err)
+				}
+			}
+
+			if pvc, ok := ctx.Value(pvcKey).(*corev1.PersistentVolumeClaim); ok {
+				if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+					t.Logf("Failed to delete PVC %s: %v", pvc.Name, err)
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, snapshotFeature)
+}
+
+func TestVolumeGroupSnapshot(t *testing.T) {
+	pvcsKey := any("pvcs-key")
+	groupSnapshotKey := any("group-snapshot-key")
+	restoredKey := any("restored-key")
+	podsKey := any("pods-key")
+	pvcNames := []string{"group-snapshot-pvc-a", "group-snapshot-pvc-b"}
+
+	trackTest(t)
+
+	groupSnapshotFeature := features.New("storage/volume-group-snapshot").
+		Setup(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			available, err := apiResourceAvailable(cfg, groupSnapshotGroupVersion, "volumegroupsnapshots")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !available {
+				skipTest(t, skipReasonAPIUnavailable, "%s VolumeGroupSnapshot API not served, skipping group snapshot test", groupSnapshotGroupVersion)
+			}
+
+			// Create PVCs labelled for the group snapshot and write distinct data to each
+			var pvcs []*corev1.PersistentVolumeClaim
+			var pods []*corev1.Pod
+			for _, name := range pvcNames {
+				pvc := newPVC(cfg.Namespace(), name)
+				pvc.Labels["group-snapshot"] = "e2e"
+				if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+					t.Fatal(err)
+				}
+				pvcs = append(pvcs, pvc)
+				ctx = context.WithValue(ctx, pvcsKey, pvcs)
+
+				pod := newStoragePod(cfg.Namespace(), name+"-writer", pvc.Name,
+					fmt.Sprintf("echo '%s' > /data/test-file.txt", groupSnapshotTestData(name)))
+				pods = append(pods, pod)
+				ctx = context.WithValue(ctx, podsKey, pods)
+				if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil {
+					t.Fatalf("Failed to write data to PVC %s: %v", pvc.Name, err)
+				}
+			}
+
+			return ctx
+		}).
+		Assess("group snapshot becomes ready", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			groupSnapshot := newVolumeGroupSnapshot(cfg.Namespace(), "e2e-group-snapshot",
+				map[string]any{"group-snapshot": "e2e"}, os.Getenv("VOLUME_GROUP_SNAPSHOT_CLASS"))
+			if err := cfg.Client().Resources().Create(ctx, groupSnapshot); err != nil {
+				t.Fatal(err)
+			}
+			ctx = context.WithValue(ctx, groupSnapshotKey, groupSnapshot)
+
+			if err := waitForSnapshotReady(ctx, cfg.Client().Resources(), groupSnapshot); err != nil {
+				t.Fatalf("VolumeGroupSnapshot not ready: %v", err)
+			}
+			t.Logf("✓ VolumeGroupSnapshot %s is ready to use", groupSnapshot.GetName())
+
+			return ctx
+		}).
+		Assess("restored volumes hold the written data", func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			groupSnapshot := ctx.Value(groupSnapshotKey).(*unstructured.Unstructured)
+
+			snapshots, err := groupSnapshotMembers(groupSnapshot)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var restored []*corev1.PersistentVolumeClaim
+			for _, name := range pvcNames {
+				snapshotName, ok := snapshots[name]
+				if !ok {
+					t.Fatalf("VolumeGroupSnapshot %s has no snapshot for PVC %s", groupSnapshot.GetName(), name)
+				}
+
+				pvc := newPVC(cfg.Namespace(), name+"-restored")
+				pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+					APIGroup: &[]string{"snapshot.storage.k8s.io"}[0],
+					Kind:     "VolumeSnapshot",
+					Name:     snapshotName,
+				}
+				if err := cfg.Client().Resources().Create(ctx, pvc); err != nil {
+					t.Fatal(err)
+				}
+				restored = append(restored, pvc)
+				ctx = context.WithValue(ctx, restoredKey, restored)
+
+				pod := newStoragePod(cfg.Namespace(), name+"-reader", pvc.Name,
+					fmt.Sprintf("cat /data/test-file.txt && test \"$(cat /data/test-file.txt)\" = '%s'", groupSnapshotTestData(name)))
+				pods, _ := ctx.Value(podsKey).([]*corev1.Pod)
+				ctx = context.WithValue(ctx, podsKey, append(pods, pod))
+				if _, _, err := runPodToCompletion(ctx, cfg.Client().Resources(), pod, 5*time.Minute); err != nil {
+					t.Fatalf("Restored PVC %s does not hold the data of %s: %v", pvc.Name, name, err)
+				}
+				t.Logf("✓ PVC %s restored from snapshot %s holds the data of %s", pvc.Name, snapshotName, name)
+			}
+
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, cfg *envconf.Config) context.Context {
+			// Delete pods
+			if pods, ok := ctx.Value(podsKey).([]*corev1.Pod); ok {
+				for _, pod := range pods {
+					if err := cfg.Client().Resources().Delete(ctx, pod); err != nil {
+						t.Logf("Failed to delete pod %s: %v", pod.Name, err)
+					}
+				}
+			}
+
+			// Delete restored PVCs before the snapshots they were restored from
+			if pvcs, ok := ctx.Value(restoredKey).([]*corev1.PersistentVolumeClaim); ok {
+				for _, pvc := range pvcs {
+					if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+						t.Logf("Failed to delete PVC %s: %v", pvc.Name, err)
+					}
+				}
+			}
+
+			// Deleting the group snapshot also deletes its member snapshots
+			if groupSnapshot, ok := ctx.Value(groupSnapshotKey).(*unstructured.Unstructured); ok {
+				if err := cfg.Client().Resources().Delete(ctx, groupSnapshot); err != nil {
+					t.Logf("Failed to delete VolumeGroupSnapshot: %v", err)
+				}
+			}
+
+			// Delete source PVCs
+			if pvcs, ok := ctx.Value(pvcsKey).([]*corev1.PersistentVolumeClaim); ok {
+				for _, pvc := range pvcs {
+					if err := cfg.Client().Resources().Delete(ctx, pvc); err != nil {
+						t.Logf("Failed to delete PVC %s: %v", pvc.Name, err)
+					}
+				}
+			}
+
+			return ctx
+		}).Feature()
+
+	testenv.Test(t, groupSnapshotFeature)
+}
+
+// newVolumeSnapshot creates a VolumeSnapshot of a PVC, using the default snapshot class when className is empty
+func newVolumeSnapshot(namespace, name, pvcName, className string) *unstructured.Unstructured {
+	spec := map[string]any{
+		"source": map[string]any{"persistentVolumeClaimName": pvcName},
+	}
+	if className != "" {
+		spec["volumeSnapshotClassName"] = className
+	}
+
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": snapshotGroupVersion,
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]any{"app": "test-storage"},
+		},
+		"spec": spec,
+	}}
+}
+
+// listVolumeSnapshots lists the VolumeSnapshots of a namespace, returning a no-match error when the CRD is absent
+func listVolumeSnapshots(ctx context.Context, cfg *envconf.Config, namespace string) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(snapshotGroupVersion)
+	list.SetKind("VolumeSnapshotList")
+	if err := cfg.Client().Resources().WithNamespace(namespace).List(ctx, list); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// groupSnapshotTestData returns the data written to a PVC before the group snapshot
+func groupSnapshotTestData(pvcName string) string {
+	return "group snapshot data for " + pvcName
+}
+
+// newVolumeGroupSnapshot creates a VolumeGroupSnapshot of the PVCs matching the given labels,
+// using the default group snapshot class when className is empty
+func newVolumeGroupSnapshot(namespace, name string, matchLabels map[string]any, className string) *unstructured.Unstructured {
+	spec := map[string]any{
+		"source": map[string]any{
+			"selector": map[string]any{"matchLabels": matchLabels},
+		},
+	}
+	if className != "" {
+		spec["volumeGroupSnapshotClassName"] = className
+	}
+
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": groupSnapshotGroupVersion,
+		"kind":       "VolumeGroupSnapshot",
+		"metadata": map[string]any{
+			"name":      name,
+			"namespace": namespace,
+			"labels":    map[string]any{"app": "test-storage"},
+		},
+		"spec": spec,
+	}}
+}
+
+// waitForSnapshotReady waits for a snapshot object to report status.readyToUse, failing early on a snapshot error
+func waitForSnapshotReady(ctx context.Context, client *resources.Resources, snapshot *unstructured.Unstructured) error {
+	return wait.PollUntilContextTimeout(ctx, 5*time.Second, 5*time.Minute, true, func(ctx context.Context) (bool, error) {
+		if err := client.Get(ctx, snapshot.GetName(), snapshot.GetNamespace(), snapshot); err != nil {
+			return false, err
+		}
+
+		if message, found, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message"); found {
+			return false, fmt.Errorf("snapshot %s failed: %s", snapshot.GetName(), message)
+		}
+
+		ready, _, err := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+		return ready, err
+	})
+}
+
+// groupSnapshotMembers maps each source PVC name to its VolumeSnapshot within a ready VolumeGroupSnapshot
+func groupSnapshotMembers(groupSnapshot *unstructured.Unstructured) (map[string]string, error) {
+	pairs, found, err := unstructured.NestedSlice(groupSnapshot.Object, "status", "pvcVolumeSnapshotRefList")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("VolumeGroupSnapshot %s has no status.pvcVolumeSnapshotRefList", groupSnapshot.GetName())
+	}
+
+	members := map[string]string{}
+	for _, p := range pairs {
+		pair, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+		pvcName, _, _ := unstructured.NestedString(pair, "persistentVolumeClaimRef", "name")
+		snapshotName, _, _ := unstructured.NestedString(pair, "volumeSnapshotRef", "name")
+		if pvcName != "" && snapshotName != "" {
+			members[pvcName] = snapshotName
+		}
+	}
+
+	return members, nil
+}